@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	"github.com/eggsbenjamin/square_enix/internal/app/exporter"
+	"github.com/eggsbenjamin/square_enix/internal/app/ingester"
+	"github.com/eggsbenjamin/square_enix/internal/app/models"
+	"github.com/eggsbenjamin/square_enix/internal/app/repository"
+	"github.com/eggsbenjamin/square_enix/pkg/env"
+)
+
+const elementsUsage = "usage: elements import|export [flags]"
+
+// elementsCommand imports elements from, or exports them to, a file or stdin and stdout. An export can be
+// imported elsewhere as it's written in the formats the ingester reads.
+func elementsCommand(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New(elementsUsage)
+	}
+	action, args := args[0], args[1:]
+
+	if action != "import" && action != "export" {
+		return fmt.Errorf("unknown elements command %q, %s", action, elementsUsage)
+	}
+
+	fs := flag.NewFlagSet("elements "+action, flag.ExitOnError)
+
+	var (
+		cfg struct {
+			DB dbConfig
+		}
+		selector string
+	)
+	loader := env.NewLoader(fs, &cfg)
+	format := fs.String("format", ingester.FORMAT_NDJSON, "format of the elements, ndjson or csv")
+	file := fs.String("file", "-", "file the elements are read from or written to, - for stdin or stdout")
+	if action == "export" {
+		fs.StringVar(&selector, "selector", "", `json selector restricting the elements exported, e.g. {"tags": ["fruit"]}`)
+	}
+	if err := loader.Load(args); err != nil {
+		return err
+	}
+
+	var elementSelector models.ElementSelector
+	if selector != "" {
+		if err := json.Unmarshal([]byte(selector), &elementSelector); err != nil {
+			return fmt.Errorf("invalid selector: %s", err)
+		}
+
+		if err := elementSelector.Validate(); err != nil {
+			return fmt.Errorf("invalid selector: %s", err)
+		}
+	}
+
+	conn, err := cfg.DB.connect()
+	if err != nil {
+		return err
+	}
+	defer closeDB(conn)
+
+	if err := checkSchema(ctx, conn); err != nil {
+		return err
+	}
+
+	if action == "import" {
+		var r io.Reader = os.Stdin
+		if *file != "-" {
+			f, err := os.Open(*file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			r = f
+		}
+
+		result, err := ingester.NewIngester(db.NewDB(conn), repository.NewElementRepositoryFactory()).IngestStreamContext(ctx, r, *format)
+		if err != nil {
+			return err
+		}
+
+		for _, rejection := range result.Rejections {
+			log.Printf("rejected row %d: %s", rejection.Row, rejection.Reason)
+		}
+
+		log.Printf("inserted %d elements, rejected %d", result.Inserted, result.Rejected)
+		return nil
+	}
+
+	var w io.WriteCloser = os.Stdout
+	if *file != "-" {
+		if w, err = os.Create(*file); err != nil {
+			return err
+		}
+	}
+
+	exported, err := exporter.NewExporter(db.NewQuerier(conn), repository.NewElementRepositoryFactory()).ExportContext(ctx, w, *format, elementSelector)
+	if err != nil {
+		w.Close()
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	log.Printf("exported %d elements", exported)
+	return nil
+}
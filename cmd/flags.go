@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	"github.com/eggsbenjamin/square_enix/internal/app/leader"
+	"github.com/eggsbenjamin/square_enix/internal/app/metrics"
+	"github.com/eggsbenjamin/square_enix/internal/app/migrations"
+	"github.com/eggsbenjamin/square_enix/internal/app/processor"
+	"github.com/eggsbenjamin/square_enix/internal/app/repository"
+	"github.com/eggsbenjamin/square_enix/internal/app/transformer"
+	"github.com/eggsbenjamin/square_enix/internal/app/workers"
+	"github.com/eggsbenjamin/square_enix/pkg/env"
+)
+
+// Every subcommand composes its config from the structs below and loads it with env.Loader, so each field can
+// be set by a flag, the env var named in its usage or the config file.
+
+// dbConfig is the database connection. The env vars were named MYSQL_* while MySQL was the only driver, the
+// old names are still read. SQLite only needs a name, the path of the database file or :memory:.
+type dbConfig struct {
+	Driver   string `env:"DB_DRIVER" flag:"db-driver" default:"mysql" usage:"database driver: mysql, postgres or sqlite"`
+	User     string `env:"DB_USER,MYSQL_USER" flag:"db-user" usage:"database user, required unless the driver is sqlite"`
+	Password string `env:"DB_PASSWORD,MYSQL_PASSWORD" flag:"db-password" usage:"database password"`
+	Host     string `env:"DB_HOST,MYSQL_HOST" flag:"db-host" usage:"database host, required unless the driver is sqlite"`
+	Port     int    `env:"DB_PORT,MYSQL_PORT" flag:"db-port" usage:"database port, the driver's default port if not given"`
+	Name     string `env:"DB_NAME,MYSQL_DB" flag:"db-name" required:"true" usage:"database name, or the path of a sqlite database file"`
+	TLS      string `env:"DB_TLS,MYSQL_TLS" flag:"db-tls" default:"false" usage:"TLS mode: false, true, skip-verify or preferred"`
+	TLSCA    string `env:"DB_TLS_CA,MYSQL_TLS_CA" flag:"db-tls-ca" usage:"PEM file of the CA the server's certificate is verified against"`
+	TLSCert  string `env:"DB_TLS_CERT,MYSQL_TLS_CERT" flag:"db-tls-cert" usage:"PEM file of the client certificate"`
+	TLSKey   string `env:"DB_TLS_KEY,MYSQL_TLS_KEY" flag:"db-tls-key" usage:"PEM file of the client certificate's key"`
+}
+
+func (c *dbConfig) Validate() error {
+	errs := env.Errors{}
+
+	if _, err := db.NewDialect(c.Driver); err != nil {
+		errs = append(errs, fmt.Errorf("-db-driver must be mysql, postgres or sqlite, got %q", c.Driver))
+	}
+
+	if c.Driver != db.DRIVER_SQLITE {
+		if c.User == "" {
+			errs = append(errs, fmt.Errorf("-db-user/DB_USER is required unless the driver is sqlite"))
+		}
+
+		if c.Host == "" {
+			errs = append(errs, fmt.Errorf("-db-host/DB_HOST is required unless the driver is sqlite"))
+		}
+	}
+
+	if c.Port < 0 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("-db-port must be between 1 and 65535, or 0 for the driver's default, got %d", c.Port))
+	}
+
+	switch c.TLS {
+	case "false", "true", "skip-verify", "preferred":
+	default:
+		errs = append(errs, fmt.Errorf("-db-tls must be false, true, skip-verify or preferred, got %q", c.TLS))
+	}
+
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		errs = append(errs, fmt.Errorf("-db-tls-cert and -db-tls-key must be given together"))
+	}
+
+	if c.TLS == "false" && (c.TLSCA != "" || c.TLSCert != "") {
+		errs = append(errs, fmt.Errorf("-db-tls must be enabled to use a CA or client certificate"))
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (c *dbConfig) connect() (*sqlx.DB, error) {
+	return db.Connect(db.Config{
+		Driver:   c.Driver,
+		User:     c.User,
+		Password: c.Password,
+		Host:     c.Host,
+		Port:     c.Port,
+		Name:     c.Name,
+		TLS:      c.TLS,
+		TLSCA:    c.TLSCA,
+		TLSCert:  c.TLSCert,
+		TLSKey:   c.TLSKey,
+	})
+}
+
+// processorConfig controls how the workers retry failing elements, and how long they claim a batch's elements.
+type processorConfig struct {
+	MaxAttempts  int           `env:"MAX_ATTEMPTS" flag:"max-attempts" default:"3" usage:"times a failing element is attempted before it's dead lettered"`
+	RetryBackoff time.Duration `env:"RETRY_BACKOFF" flag:"retry-backoff" default:"5s" usage:"wait before a failed element is retried, doubled after each attempt"`
+	ClaimTTL     time.Duration `env:"CLAIM_TTL" flag:"claim-ttl" default:"5m" usage:"time a batch's elements are claimed for without being renewed before the batch is rolled back"`
+}
+
+func (c *processorConfig) Validate() error {
+	errs := env.Errors{}
+
+	if c.MaxAttempts < 1 {
+		errs = append(errs, fmt.Errorf("-max-attempts must be at least 1, got %d", c.MaxAttempts))
+	}
+
+	if c.ClaimTTL < time.Second {
+		errs = append(errs, fmt.Errorf("-claim-ttl must be at least 1s, got %s", c.ClaimTTL))
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (c *processorConfig) config() processor.Config {
+	return processor.Config{
+		MaxAttempts:  c.MaxAttempts,
+		RetryBackoff: c.RetryBackoff,
+		ClaimTTL:     c.ClaimTTL,
+	}
+}
+
+// serveConfig controls the http server.
+type serveConfig struct {
+	Port            int           `env:"PORT" flag:"port" required:"true" usage:"port the api listens on"`
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" default:"30s" usage:"time in flight requests are given to drain on shutdown"`
+	StuckAfter      time.Duration `env:"STUCK_AFTER" flag:"stuck-after" default:"10m" usage:"time without progress after which a running process's status reports it stuck"`
+}
+
+func (c *serveConfig) Validate() error {
+	errs := env.Errors{}
+
+	if c.Port < 1 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("-port must be between 1 and 65535, got %d", c.Port))
+	}
+
+	if c.StuckAfter <= 0 {
+		errs = append(errs, fmt.Errorf("-stuck-after must be positive, got %s", c.StuckAfter))
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// workerConfig controls the batch workers.
+type workerConfig struct {
+	Workers           int           `env:"WORKER_COUNT" flag:"workers" default:"1" usage:"number of workers processing batches concurrently"`
+	BatchSize         int           `env:"BATCH_SIZE" flag:"batch-size" required:"true" usage:"number of elements processed per batch"`
+	PollInterval      time.Duration `env:"POLL_INTERVAL" flag:"poll-interval" required:"true" usage:"time a worker waits after finding no work, e.g. 500ms"`
+	LeaseTTL          time.Duration `env:"LEADER_LEASE_TTL" flag:"leader-lease-ttl" default:"15s" usage:"time the lease of the instance leading the workers lasts unless it's renewed"`
+	HeartbeatInterval time.Duration `env:"WORKER_HEARTBEAT_INTERVAL" flag:"heartbeat-interval" default:"5s" usage:"time between a worker's heartbeats, it's dead after missing 3"`
+}
+
+func (c *workerConfig) Validate() error {
+	errs := env.Errors{}
+
+	if c.Workers < 1 {
+		errs = append(errs, fmt.Errorf("-workers must be at least 1, got %d", c.Workers))
+	}
+
+	if c.BatchSize < 1 {
+		errs = append(errs, fmt.Errorf("-batch-size must be at least 1, got %d", c.BatchSize))
+	}
+
+	if c.PollInterval <= 0 {
+		errs = append(errs, fmt.Errorf("-poll-interval must be positive, got %s", c.PollInterval))
+	}
+
+	if c.HeartbeatInterval <= 0 {
+		errs = append(errs, fmt.Errorf("-heartbeat-interval must be positive, got %s", c.HeartbeatInterval))
+	}
+
+	if c.LeaseTTL < time.Second {
+		errs = append(errs, fmt.Errorf("-leader-lease-ttl must be at least 1s, got %s", c.LeaseTTL))
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// checkSchema refuses to run against a schema missing the tables and columns this binary expects.
+func checkSchema(ctx context.Context, conn *sqlx.DB) error {
+	loaded, err := migrations.Load(conn.DriverName())
+	if err != nil {
+		return err
+	}
+
+	if err := migrations.NewMigrator(conn, loaded).Check(ctx); err != nil {
+		return fmt.Errorf("error checking schema version, run `migrate up`: %s", err)
+	}
+
+	return nil
+}
+
+func newProcessor(conn *sqlx.DB, cfg processor.Config, recorder metrics.Recorder, elector leader.Elector) processor.Processor {
+	return processor.NewProcessor(
+		db.NewDB(conn),
+		repository.NewProcessRepositoryFactory(),
+		repository.NewElementRepositoryFactory(),
+		repository.NewElementErrorRepositoryFactory(),
+		repository.NewTransitionRepositoryFactory(),
+		repository.NewClaimRepositoryFactory(),
+		transformer.NewDefaultRegistry(),
+		cfg,
+		recorder,
+		elector,
+	)
+}
+
+// newElector returns an elector campaigning for the lease of the instance leading the workers, as this instance
+// identified by its host and process id.
+func newElector(conn *sqlx.DB, cfg workerConfig) leader.Elector {
+	return leader.NewElector(
+		db.NewDB(conn),
+		repository.NewLeaseRepositoryFactory(),
+		leader.WORKER_LEASE,
+		fmt.Sprintf("%s-%d", hostname(), os.Getpid()),
+		cfg.LeaseTTL,
+	)
+}
+
+// newRegistry returns the registry of the workers of every instance, registering this instance's workers.
+func newRegistry(conn *sqlx.DB, elector leader.Elector, cfg workerConfig) workers.Registry {
+	return workers.NewRegistry(
+		db.NewDB(conn),
+		repository.NewWorkerRepositoryFactory(),
+		elector,
+		workers.Config{
+			Hostname:          hostname(),
+			PID:               os.Getpid(),
+			Version:           version,
+			HeartbeatInterval: cfg.HeartbeatInterval,
+		},
+	)
+}
+
+func hostname() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+
+	return hostname
+}
@@ -1,36 +1,110 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"time"
 
 	"github.com/eggsbenjamin/square_enix/internal/app/httphandlers"
+	"github.com/eggsbenjamin/square_enix/internal/app/ingester"
+	"github.com/eggsbenjamin/square_enix/internal/app/metrics"
 	"github.com/eggsbenjamin/square_enix/internal/app/processor"
+	"github.com/eggsbenjamin/square_enix/internal/app/workers"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-func startHTTPListeners(proc processor.Processor, port int) {
+// startHTTPListeners serves the api until ctx is done, then stops accepting connections and waits up
+// to shutdownTimeout for in flight requests to drain.
+func startHTTPListeners(
+	ctx context.Context,
+	proc processor.Processor,
+	ing ingester.Ingester,
+	registry workers.Registry,
+	recorder metrics.Recorder,
+	port int,
+	shutdownTimeout time.Duration,
+) error {
+	createHandler := httphandlers.NewCreateHandler(proc)
+	previewHandler := httphandlers.NewPreviewHandler(proc)
+	listHandler := httphandlers.NewListHandler(proc)
 	startHandler := httphandlers.NewStartHandler(proc)
 	pauseHandler := httphandlers.NewPauseHandler(proc)
+	cancelHandler := httphandlers.NewCancelHandler(proc)
+	restartHandler := httphandlers.NewRestartHandler(proc)
+	revertHandler := httphandlers.NewRevertHandler(proc)
 	statHandler := httphandlers.NewStatHandler(proc)
+	historyHandler := httphandlers.NewHistoryHandler(proc)
+	failuresHandler := httphandlers.NewFailuresHandler(proc)
+	redriveHandler := httphandlers.NewRedriveHandler(proc)
+	elementsHandler := httphandlers.NewElementsHandler(ing)
+	bulkElementsHandler := httphandlers.NewBulkElementsHandler(ing)
+	elementHistoryHandler := httphandlers.NewElementHistoryHandler(proc)
+	workersHandler := httphandlers.NewWorkersHandler(registry)
 
 	mux := chi.NewRouter()
 
 	mux.Use(middleware.RequestID)
 	mux.Use(middleware.RealIP)
 	mux.Use(middleware.Logger)
+	mux.Use(metrics.Middleware(recorder))
 	mux.Use(middleware.Recoverer)
 	mux.Use(middleware.Timeout(30 * time.Second))
 
+	mux.Handle("/metrics", promhttp.Handler())
+
 	mux.Route("/process", func(r chi.Router) {
-		r.Put("/start", startHandler.Handle)
-		r.Put("/pause", pauseHandler.Handle)
-		r.Get("/stat", statHandler.Handle)
+		r.Post("/", createHandler.Handle)
+		r.Get("/", listHandler.Handle)
+		r.Post("/preview", previewHandler.Handle)
+		r.Put("/{id}/start", startHandler.Handle)
+		r.Put("/{id}/pause", pauseHandler.Handle)
+		r.Put("/{id}/cancel", cancelHandler.Handle)
+		r.Put("/{id}/restart", restartHandler.Handle)
+		r.Put("/{id}/revert", revertHandler.Handle)
+		r.Get("/{id}/stat", statHandler.Handle)
+		r.Get("/{id}/history", historyHandler.Handle)
+		r.Get("/{id}/failures", failuresHandler.Handle)
+		r.Put("/{id}/failures/redrive", redriveHandler.Handle)
+	})
+
+	mux.Route("/elements", func(r chi.Router) {
+		r.Post("/", elementsHandler.Handle)
+		r.Post("/bulk", bulkElementsHandler.Handle)
+		r.Get("/{id}/history", elementHistoryHandler.Handle)
 	})
 
-	log.Printf("listening on port %d", port)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", port), mux))
+	mux.Get("/workers", workersHandler.Handle)
+
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%d", port),
+		Handler: mux,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		log.Printf("listening on port %d", port)
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Println("draining http server")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+
+	log.Println("http server stopped")
+	return nil
 }
@@ -1,43 +1,100 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
 
-	"github.com/eggsbenjamin/square_enix/internal/app/db"
-	"github.com/eggsbenjamin/square_enix/internal/app/processor"
-	"github.com/eggsbenjamin/square_enix/internal/app/repository"
-	"github.com/eggsbenjamin/square_enix/pkg/env"
 	"github.com/jmoiron/sqlx"
 )
 
+// version is the binary's version, recorded against its workers. It's set when building a release with
+// -ldflags "-X main.version=<version>".
+var version = "dev"
+
+// command is a subcommand of the binary. Long running commands run until ctx is done.
+type command struct {
+	run         func(ctx context.Context, args []string) error
+	description string
+}
+
+var commands = map[string]command{
+	"serve":    {serveCommand, "serve the api"},
+	"worker":   {workerCommand, "run the batch workers"},
+	"migrate":  {migrateCommand, "migrate the schema: up, down [steps] or version"},
+	"process":  {processCommand, "operate processes: start, pause, status or cancel"},
+	"elements": {elementsCommand, "import or export elements: import or export"},
+}
+
 func main() {
-	dsn := fmt.Sprintf(
-		"%s@tcp(%s:3306)/%s?parseTime=true",
-		env.MustGetEnv("MYSQL_USER"),
-		env.MustGetEnv("MYSQL_HOST"),
-		env.MustGetEnv("MYSQL_DB"),
-	)
-	conn, err := sqlx.Connect("mysql", dsn)
-	if err != nil {
-		log.Fatalf("error opening db: %q", err)
+	ctx, cancel := shutdownContext()
+	defer cancel()
+
+	name, args := "", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
 	}
 
-	db := db.NewDB(conn)
-	proc := processor.NewProcessor(
-		db,
-		repository.NewProcessRepositoryFactory(),
-		repository.NewElementRepositoryFactory(),
-	)
-
-	go pollProcess(
-		proc,
-		env.MustGetIntEnv("BATCH_SIZE"),
-		env.MustGetIntEnv("POLL_INTERVAL"),
-	)
-
-	startHTTPListeners(
-		proc,
-		env.MustGetIntEnv("PORT"),
-	)
+	// without a subcommand the api and the workers run in one process
+	run := runCommand
+	if name == "help" {
+		usage()
+		return
+	} else if name != "" {
+		cmd, ok := commands[name]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+			usage()
+			os.Exit(2)
+		}
+		run = cmd.run
+	}
+
+	if err := run(ctx, args); err != nil {
+		log.Fatalf("error running %s: %q", strings.TrimSpace("square_enix "+name), err)
+	}
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(os.Stderr, "usage: square_enix [command] [flags]\n\nWithout a command the api and the workers run in one process.\n\ncommands:\n")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].description)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun `square_enix <command> -h` for a command's flags.\n")
+}
+
+// shutdownContext returns a context that is cancelled on SIGINT or SIGTERM. A second signal exits immediately.
+func shutdownContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		sig := <-signals
+		log.Printf("received %s, shutting down", sig)
+		cancel()
+
+		sig = <-signals
+		log.Fatalf("received %s, exiting", sig)
+	}()
+
+	return ctx, cancel
+}
+
+func closeDB(conn *sqlx.DB) {
+	if err := conn.Close(); err != nil {
+		log.Printf("error closing db: %q", err)
+	}
 }
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/migrations"
+	"github.com/eggsbenjamin/square_enix/pkg/env"
+)
+
+func migrateCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+
+	var cfg struct {
+		DB dbConfig
+	}
+	if err := env.NewLoader(fs, &cfg).Load(args); err != nil {
+		return err
+	}
+
+	loaded, err := migrations.Load(cfg.DB.Driver)
+	if err != nil {
+		return err
+	}
+
+	conn, err := cfg.DB.connect()
+	if err != nil {
+		return err
+	}
+	defer closeDB(conn)
+
+	return migrate(ctx, migrations.NewMigrator(conn, loaded), fs.Args())
+}
+
+// migrate runs the migrate subcommand. `up` applies every pending migration, `down [steps]` rolls back the
+// latest migration, or the given number of them, and `version` prints the schema's version.
+func migrate(ctx context.Context, migrator migrations.Migrator, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate [flags] up|down [steps]|version")
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+
+		log.Printf("applied %d migrations", applied)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("steps must be a positive integer, got %q", args[1])
+			}
+		}
+
+		rolledBack, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+
+		log.Printf("rolled back %d migrations", rolledBack)
+	case "version":
+		version, err := migrator.Version(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("schema version %d, latest %d\n", version, migrator.Latest())
+	default:
+		return fmt.Errorf("unknown migrate command %q, usage: migrate [flags] up|down [steps]|version", args[0])
+	}
+
+	return nil
+}
@@ -1,28 +1,64 @@
 package main
 
 import (
+	"context"
 	"log"
 	"time"
 
+	"github.com/eggsbenjamin/square_enix/internal/app/metrics"
 	"github.com/eggsbenjamin/square_enix/internal/app/processor"
+	"github.com/eggsbenjamin/square_enix/internal/app/workers"
 )
 
-func pollProcess(proc processor.Processor, batchSize, pollInterval int) {
+// pollProcess claims and processes batches until ctx is done. Batches are processed back to back while there
+// are elements to claim, the worker only sleeps for the poll interval once a batch comes back empty or fails.
+// A batch that is in flight when ctx is done is rolled back before pollProcess returns. The batches are tracked by
+// the worker's registration.
+func pollProcess(
+	ctx context.Context,
+	worker int,
+	registration workers.Registration,
+	proc processor.Processor,
+	recorder metrics.Recorder,
+	batchSize int,
+	pollInterval time.Duration,
+) {
+	batchCtx := processor.WithBatchStarted(ctx, registration.BatchStarted)
+
 	for {
-		log.Println("Querying processes")
-		runningProcess, err := proc.RunningProcessExists()
+		recorder.PollIteration()
+		processed := 0
+
+		log.Printf("worker %d: querying processes\n", worker)
+		runningProcess, err := proc.RunningProcessExistsContext(ctx)
 		if err != nil {
-			log.Fatalf("error getting process info: %q\n", err)
+			log.Printf("worker %d: error getting process info: %q\n", worker, err)
 		}
 
 		if runningProcess {
-			log.Println("Running process found. Processing batch...")
+			log.Printf("worker %d: running process found. Processing batch...\n", worker)
 
-			if err := proc.ProcessBatch(batchSize); err != nil {
-				log.Fatalf("error processing batch: %q\n", err)
+			processed, err = proc.ProcessBatchContext(batchCtx, batchSize)
+			registration.BatchFinished()
+			if err != nil {
+				log.Printf("worker %d: error processing batch: %q\n", worker, err)
 			}
 		}
 
-		time.Sleep(time.Duration(pollInterval) * time.Second)
+		if processed > 0 && err == nil {
+			if ctx.Err() != nil {
+				log.Printf("worker %d: stopped\n", worker)
+				return
+			}
+
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Printf("worker %d: stopped\n", worker)
+			return
+		case <-time.After(pollInterval):
+		}
 	}
 }
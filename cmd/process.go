@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/leader"
+	"github.com/eggsbenjamin/square_enix/internal/app/metrics"
+	"github.com/eggsbenjamin/square_enix/internal/app/processor"
+	"github.com/eggsbenjamin/square_enix/pkg/env"
+)
+
+const processUsage = "usage: process start [flags] | process pause|status|cancel [flags] <id>"
+
+// processCommand operates a process from a shell, the same as the api does:
+//   - start: creates and starts a process, printing it
+//   - pause, cancel: pauses or cancels a process
+//   - status: prints a process's status document
+func processCommand(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New(processUsage)
+	}
+	action, args := args[0], args[1:]
+
+	switch action {
+	case "start", "pause", "status", "cancel":
+	default:
+		return fmt.Errorf("unknown process command %q, %s", action, processUsage)
+	}
+
+	fs := flag.NewFlagSet("process "+action, flag.ExitOnError)
+
+	var cfg struct {
+		DB dbConfig
+	}
+	loader := env.NewLoader(fs, &cfg)
+	actor := fs.String("actor", env.GetEnv("USER", "cli"), "actor the process transition is attributed to")
+	reason := fs.String("reason", "", "reason recorded against the process transition")
+
+	var (
+		opts     processor.StartOptions
+		selector string
+	)
+	if action == "start" {
+		fs.StringVar(&opts.Name, "name", "", "unique name of the process")
+		fs.StringVar(&selector, "selector", "", `json selector restricting the elements processed, e.g. {"max_id": 1000}`)
+		fs.StringVar(&opts.Transformer, "transformer", "", "transformer applied to the elements, upper by default")
+		fs.StringVar(&opts.TransformerConfig, "transformer-config", "", "json config of the transformer")
+	}
+	if err := loader.Load(args); err != nil {
+		return err
+	}
+
+	if selector != "" {
+		if err := json.Unmarshal([]byte(selector), &opts.Selector); err != nil {
+			return fmt.Errorf("invalid selector: %s", err)
+		}
+	}
+
+	var id int
+	if action != "start" {
+		if fs.NArg() != 1 {
+			return errors.New(processUsage)
+		}
+
+		var err error
+		if id, err = strconv.Atoi(fs.Arg(0)); err != nil {
+			return fmt.Errorf("invalid process id %q", fs.Arg(0))
+		}
+	}
+
+	conn, err := cfg.DB.connect()
+	if err != nil {
+		return err
+	}
+	defer closeDB(conn)
+
+	if err := checkSchema(ctx, conn); err != nil {
+		return err
+	}
+
+	proc := newProcessor(conn, processor.Config{}, metrics.NewNopRecorder(), leader.NewSoleElector())
+
+	ctx = processor.WithActor(ctx, *actor)
+	if *reason != "" {
+		ctx = processor.WithReason(ctx, *reason)
+	}
+
+	switch action {
+	case "start":
+		process, err := proc.StartContext(ctx, opts)
+		if err != nil {
+			return err
+		}
+
+		return printJSON(process)
+	case "pause":
+		if _, err := proc.PauseContext(ctx, id); err != nil {
+			return err
+		}
+
+		log.Printf("process %d paused", id)
+	case "cancel":
+		if _, err := proc.CancelContext(ctx, id); err != nil {
+			return err
+		}
+
+		log.Printf("process %d cancelled", id)
+	case "status":
+		status, err := proc.GetStatusContext(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return printJSON(status)
+	}
+
+	return nil
+}
+
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	"github.com/eggsbenjamin/square_enix/internal/app/ingester"
+	"github.com/eggsbenjamin/square_enix/internal/app/leader"
+	"github.com/eggsbenjamin/square_enix/internal/app/metrics"
+	"github.com/eggsbenjamin/square_enix/internal/app/processor"
+	"github.com/eggsbenjamin/square_enix/internal/app/repository"
+	"github.com/eggsbenjamin/square_enix/pkg/env"
+)
+
+// serveCommand serves the api without processing batches, so the api can be scaled independently of the workers.
+func serveCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+
+	var cfg struct {
+		DB    dbConfig
+		Serve serveConfig
+	}
+	if err := env.NewLoader(fs, &cfg).Load(args); err != nil {
+		return err
+	}
+
+	conn, err := cfg.DB.connect()
+	if err != nil {
+		return err
+	}
+	defer closeDB(conn)
+
+	if err := checkSchema(ctx, conn); err != nil {
+		return err
+	}
+
+	recorder := metrics.NewPrometheusRecorder(prometheus.DefaultRegisterer)
+	metrics.RegisterDBStats(prometheus.DefaultRegisterer, conn.Stats)
+
+	return startHTTPListeners(
+		ctx,
+		// the api doesn't process batches, so has no need to campaign to lead the workers
+		newProcessor(conn, processor.Config{StuckAfter: cfg.Serve.StuckAfter}, recorder, leader.NewSoleElector()),
+		ingester.NewIngester(db.NewDB(conn), repository.NewElementRepositoryFactory()),
+		// nor does it register workers, it only lists them
+		newRegistry(conn, leader.NewSoleElector(), workerConfig{}),
+		recorder,
+		cfg.Serve.Port,
+		cfg.Serve.ShutdownTimeout,
+	)
+}
+
+// runCommand serves the api and runs the batch workers in one process.
+func runCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("square_enix", flag.ExitOnError)
+
+	var cfg struct {
+		DB        dbConfig
+		Serve     serveConfig
+		Worker    workerConfig
+		Processor processorConfig
+	}
+	if err := env.NewLoader(fs, &cfg).Load(args); err != nil {
+		return err
+	}
+
+	conn, err := cfg.DB.connect()
+	if err != nil {
+		return err
+	}
+	defer closeDB(conn)
+
+	if err := checkSchema(ctx, conn); err != nil {
+		return err
+	}
+
+	recorder := metrics.NewPrometheusRecorder(prometheus.DefaultRegisterer)
+	metrics.RegisterDBStats(prometheus.DefaultRegisterer, conn.Stats)
+
+	processorCfg := cfg.Processor.config()
+	processorCfg.StuckAfter = cfg.Serve.StuckAfter
+
+	elector := newElector(conn, cfg.Worker)
+	proc := newProcessor(conn, processorCfg, recorder, elector)
+	registry := newRegistry(conn, elector, cfg.Worker)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	runWorkers(ctx, &wg, proc, elector, registry, recorder, cfg.Worker)
+
+	if err = startHTTPListeners(
+		ctx,
+		proc,
+		ingester.NewIngester(db.NewDB(conn), repository.NewElementRepositoryFactory()),
+		registry,
+		recorder,
+		cfg.Serve.Port,
+		cfg.Serve.ShutdownTimeout,
+	); err != nil {
+		log.Printf("error serving http: %q", err)
+		cancel()
+	}
+
+	// wait for any in flight batches to commit or roll back before closing the db
+	wg.Wait()
+
+	log.Println("shutdown complete")
+	return err
+}
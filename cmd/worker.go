@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/leader"
+	"github.com/eggsbenjamin/square_enix/internal/app/metrics"
+	"github.com/eggsbenjamin/square_enix/internal/app/processor"
+	"github.com/eggsbenjamin/square_enix/internal/app/workers"
+	"github.com/eggsbenjamin/square_enix/pkg/env"
+)
+
+// claimReapInterval is how often the leader reaps the expired claims of elements.
+const claimReapInterval = 30 * time.Second
+
+// workerCommand runs the batch workers without serving the api, so the workers can be scaled independently of
+// the api. Metrics are served on a port of their own if one is given.
+func workerCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("worker", flag.ExitOnError)
+
+	var cfg struct {
+		DB          dbConfig
+		Worker      workerConfig
+		Processor   processorConfig
+		MetricsPort int `env:"METRICS_PORT" flag:"metrics-port" default:"0" usage:"port metrics are served on, 0 to not serve them"`
+	}
+	if err := env.NewLoader(fs, &cfg).Load(args); err != nil {
+		return err
+	}
+
+	conn, err := cfg.DB.connect()
+	if err != nil {
+		return err
+	}
+	defer closeDB(conn)
+
+	if err := checkSchema(ctx, conn); err != nil {
+		return err
+	}
+
+	recorder := metrics.NewPrometheusRecorder(prometheus.DefaultRegisterer)
+	metrics.RegisterDBStats(prometheus.DefaultRegisterer, conn.Stats)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	elector := newElector(conn, cfg.Worker)
+	runWorkers(
+		ctx,
+		&wg,
+		newProcessor(conn, cfg.Processor.config(), recorder, elector),
+		elector,
+		newRegistry(conn, elector, cfg.Worker),
+		recorder,
+		cfg.Worker,
+	)
+
+	if cfg.MetricsPort > 0 {
+		if err = serveMetrics(ctx, cfg.MetricsPort); err != nil {
+			log.Printf("error serving metrics: %q", err)
+			cancel()
+		}
+	} else {
+		<-ctx.Done()
+	}
+
+	// wait for any in flight batches to commit or roll back before closing the db
+	wg.Wait()
+
+	log.Println("shutdown complete")
+	return err
+}
+
+// runWorkers starts the configured number of workers, each claiming and committing its own batches. SKIP
+// LOCKED stops them claiming the same elements. Each worker is registered in the registry, and heartbeats, while
+// it runs. The elector campaigns alongside them for this instance to lead the workers of every instance, which
+// reaps the expired claims of elements while it leads. wg is done once every worker has stopped and the elector
+// has released its lease.
+func runWorkers(
+	ctx context.Context,
+	wg *sync.WaitGroup,
+	proc processor.Processor,
+	elector leader.Elector,
+	registry workers.Registry,
+	recorder metrics.Recorder,
+	cfg workerConfig,
+) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		elector.Run(ctx)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		reapClaims(ctx, proc, elector)
+	}()
+
+	for worker := 1; worker <= cfg.Workers; worker++ {
+		registration := registry.Register(worker)
+
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+
+			registration.Run(ctx)
+		}()
+
+		go func(worker int) {
+			defer wg.Done()
+
+			pollProcess(ctx, worker, registration, proc, recorder, cfg.BatchSize, cfg.PollInterval)
+		}(worker)
+	}
+}
+
+// reapClaims reaps the expired claims of elements every claimReapInterval, while this instance leads the workers,
+// until ctx is done.
+func reapClaims(ctx context.Context, proc processor.Processor, elector leader.Elector) {
+	ticker := time.NewTicker(claimReapInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !elector.IsLeader() {
+			continue
+		}
+
+		reaped, err := proc.ReapClaimsContext(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("error reaping expired claims: %q\n", err)
+			}
+			continue
+		}
+
+		if reaped > 0 {
+			log.Printf("reaped %d expired element claims\n", reaped)
+		}
+	}
+}
+
+// serveMetrics serves the metrics endpoint until ctx is done.
+func serveMetrics(ctx context.Context, port int) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%d", port),
+		Handler: mux,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		log.Printf("serving metrics on port %d", port)
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	return server.Shutdown(context.Background())
+}
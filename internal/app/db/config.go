@@ -0,0 +1,198 @@
+package db
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// mysqlTLSConfigName is the name a custom TLS config is registered with the MySQL driver under.
+	mysqlTLSConfigName = "square_enix"
+
+	// SQLITE_MEMORY is the name of a SQLite database held in memory rather than in a file.
+	SQLITE_MEMORY = ":memory:"
+
+	// sqliteBusyTimeout is how long, in milliseconds, a SQLite connection waits for another's write lock.
+	sqliteBusyTimeout = 30000
+)
+
+var (
+	defaultPorts = map[string]int{
+		DRIVER_MYSQL:    3306,
+		DRIVER_POSTGRES: 5432,
+	}
+
+	// postgresSSLModes maps the TLS modes of the MySQL driver to their PostgreSQL sslmode.
+	postgresSSLModes = map[string]string{
+		"false":       "disable",
+		"true":        "verify-full",
+		"skip-verify": "require",
+		"preferred":   "prefer",
+	}
+)
+
+// Config is a database connection. TLS is one of the MySQL driver's modes, false, true, skip-verify or
+// preferred, which are mapped to the equivalent sslmode for PostgreSQL. TLSCA, TLSCert and TLSKey are PEM
+// files of the CA the server is verified against and of a client certificate. SQLite only uses Name, the path
+// of the database file or SQLITE_MEMORY.
+type Config struct {
+	Driver   string
+	User     string
+	Password string
+	Host     string
+	Port     int
+	Name     string
+	TLS      string
+	TLSCA    string
+	TLSCert  string
+	TLSKey   string
+}
+
+// Connect opens a connection pool to the database and checks that it can be reached. Every connection to an
+// in memory SQLite database opens a database of its own, so the pool is limited to one.
+func Connect(cfg Config) (*sqlx.DB, error) {
+	dsn, err := cfg.DSN()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := sqlx.Open(cfg.Driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Driver == DRIVER_SQLITE && cfg.Name == SQLITE_MEMORY {
+		conn.SetMaxOpenConns(1)
+		conn.SetConnMaxLifetime(0)
+	}
+
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// DSN returns the data source name of the connection for its driver. The port defaults to the driver's.
+func (c Config) DSN() (string, error) {
+	port := c.Port
+	if port == 0 {
+		port = defaultPorts[c.Driver]
+	}
+	addr := net.JoinHostPort(c.Host, strconv.Itoa(port))
+
+	tlsMode := c.TLS
+	if tlsMode == "" {
+		tlsMode = "false"
+	}
+
+	switch c.Driver {
+	case DRIVER_MYSQL:
+		cfg := mysql.NewConfig()
+		cfg.User = c.User
+		cfg.Passwd = c.Password
+		cfg.Net = "tcp"
+		cfg.Addr = addr
+		cfg.DBName = c.Name
+		cfg.ParseTime = true
+		if tlsMode != "false" {
+			cfg.TLSConfig = tlsMode
+		}
+
+		if c.TLSCA != "" || c.TLSCert != "" {
+			tlsConfig, err := c.tlsConfig(tlsMode)
+			if err != nil {
+				return "", err
+			}
+
+			if err := mysql.RegisterTLSConfig(mysqlTLSConfigName, tlsConfig); err != nil {
+				return "", err
+			}
+			cfg.TLSConfig = mysqlTLSConfigName
+		}
+
+		return cfg.FormatDSN(), nil
+	case DRIVER_POSTGRES:
+		sslMode, ok := postgresSSLModes[tlsMode]
+		if !ok {
+			return "", fmt.Errorf("invalid TLS mode %q", c.TLS)
+		}
+
+		query := url.Values{"sslmode": {sslMode}}
+		if c.TLSCA != "" {
+			query.Set("sslrootcert", c.TLSCA)
+		}
+		if c.TLSCert != "" {
+			query.Set("sslcert", c.TLSCert)
+			query.Set("sslkey", c.TLSKey)
+		}
+
+		dsn := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(c.User, c.Password),
+			Host:     addr,
+			Path:     "/" + c.Name,
+			RawQuery: query.Encode(),
+		}
+		if c.Password == "" {
+			dsn.User = url.User(c.User)
+		}
+
+		return dsn.String(), nil
+	case DRIVER_SQLITE:
+		// transactions begin immediately so they take the write lock up front, rather than failing to upgrade
+		// a read lock when another transaction has written
+		query := url.Values{
+			"_txlock":       {"immediate"},
+			"_foreign_keys": {"on"},
+			"_busy_timeout": {strconv.Itoa(sqliteBusyTimeout)},
+		}
+		if c.Name != SQLITE_MEMORY {
+			query.Set("_journal_mode", "WAL")
+		}
+
+		return "file:" + c.Name + "?" + query.Encode(), nil
+	}
+
+	return "", fmt.Errorf("%w: %q", ErrUnsupportedDriver, c.Driver)
+}
+
+// tlsConfig builds the TLS config of a MySQL connection with a custom CA or client certificate.
+func (c Config) tlsConfig(mode string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         c.Host,
+		InsecureSkipVerify: mode == "skip-verify",
+	}
+
+	if c.TLSCA != "" {
+		pem, err := ioutil.ReadFile(c.TLSCA)
+		if err != nil {
+			return nil, fmt.Errorf("error reading TLS CA: %s", err)
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in TLS CA %s", c.TLSCA)
+		}
+	}
+
+	if c.TLSCert != "" {
+		cert, err := tls.LoadX509KeyPair(c.TLSCert, c.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("error loading TLS client certificate: %s", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
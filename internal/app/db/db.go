@@ -3,7 +3,9 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"log"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
@@ -14,11 +16,25 @@ type Querier interface {
 	NamedExec(query string, arg interface{}) (sql.Result, error)
 	Get(dest interface{}, query string, args ...interface{}) error
 	Select(dest interface{}, query string, args ...interface{}) error
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error)
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	DriverName() string
+	Rebind(query string) string
+}
+
+// Tx is a transaction, a *sqlx.Tx for the databases this package connects to.
+type Tx interface {
+	Querier
+	Commit() error
+	Rollback() error
 }
 
 type DB interface {
 	Querier
-	Beginx() (*sqlx.Tx, error)
+	Beginx() (Tx, error)
+	BeginTxx(ctx context.Context, opts *sql.TxOptions) (Tx, error)
 }
 
 type querier struct {
@@ -40,3 +56,30 @@ func NewDB(conn *sqlx.DB) DB {
 		conn,
 	}
 }
+
+func (d *db) Beginx() (Tx, error) {
+	tx, err := d.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+func (d *db) BeginTxx(ctx context.Context, opts *sql.TxOptions) (Tx, error) {
+	tx, err := d.DB.BeginTxx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// Rollback rolls back tx. A transaction begun with a context is rolled back by database/sql when the
+// context is done, so a transaction that is already done isn't an error. Other errors are logged: a transaction
+// whose connection has been lost is rolled back by the database.
+func Rollback(tx Tx) {
+	if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+		log.Printf("error rolling back transaction: %q", err)
+	}
+}
@@ -0,0 +1,24 @@
+// +build unit
+
+package db_test
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	mock_db "github.com/eggsbenjamin/square_enix/internal/app/db/mocks"
+)
+
+func TestRollback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// a lost connection is logged rather than exiting
+	tx := mock_db.NewMockTx(ctrl)
+	tx.EXPECT().Rollback().Return(driver.ErrBadConn)
+
+	db.Rollback(tx)
+}
@@ -0,0 +1,94 @@
+// Package dbtest connects the integration tests to the database given by the DB_* env vars, so the suite runs
+// against whichever driver DB_DRIVER names, MySQL by default. A SQLite database is migrated on connecting, and
+// held in memory unless DB_NAME is given, so DB_DRIVER=sqlite runs the suite with no database server.
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	"github.com/eggsbenjamin/square_enix/internal/app/migrations"
+	"github.com/eggsbenjamin/square_enix/pkg/env"
+)
+
+// serialTables are the tables with an id assigned by the database.
+var serialTables = []string{"Process", "Element", "ProcessTransition"}
+
+// Conn is a connection to the test database. Its Exec, Get and Select take queries with ? placeholders, and
+// times as args, on every driver.
+type Conn struct {
+	*sqlx.DB
+	q db.Querier
+}
+
+// Connect connects to the test database. The MYSQL_* env vars are read if the DB_* ones aren't set.
+func Connect() (*Conn, error) {
+	cfg := db.Config{
+		Driver:   env.GetEnv("DB_DRIVER", db.DRIVER_MYSQL),
+		User:     getEnv("DB_USER", "MYSQL_USER"),
+		Password: getEnv("DB_PASSWORD", "MYSQL_PASSWORD"),
+		Host:     getEnv("DB_HOST", "MYSQL_HOST"),
+		Port:     env.GetIntEnv("DB_PORT", env.GetIntEnv("MYSQL_PORT", 0)),
+		Name:     getEnv("DB_NAME", "MYSQL_DB"),
+	}
+	if cfg.Driver == db.DRIVER_SQLITE && cfg.Name == "" {
+		cfg.Name = db.SQLITE_MEMORY
+	}
+
+	conn, err := db.Connect(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Driver == db.DRIVER_SQLITE {
+		loaded, err := migrations.Load(cfg.Driver)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := migrations.NewMigrator(conn, loaded).Up(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Conn{conn, db.Rebound(db.NewQuerier(conn))}, nil
+}
+
+func (c *Conn) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return c.q.Exec(query, args...)
+}
+
+// SyncSequences moves PostgreSQL's sequences past the greatest ids, which a fixture inserting rows with explicit
+// ids must call before rows are given ids by the database. It does nothing on other drivers.
+func (c *Conn) SyncSequences() error {
+	if c.DriverName() != db.DRIVER_POSTGRES {
+		return nil
+	}
+
+	for _, table := range serialTables {
+		if _, err := c.DB.Exec(
+			"SELECT setval(pg_get_serial_sequence($1, 'id'), COALESCE(MAX(id), 0) + 1, false) FROM "+table,
+			strings.ToLower(table),
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Conn) Get(dest interface{}, query string, args ...interface{}) error {
+	return c.q.Get(dest, query, args...)
+}
+
+func (c *Conn) Select(dest interface{}, query string, args ...interface{}) error {
+	return c.q.Select(dest, query, args...)
+}
+
+func getEnv(k, fallback string) string {
+	return env.GetEnv(k, env.GetEnv(fallback, ""))
+}
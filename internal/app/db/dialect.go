@@ -0,0 +1,361 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	// mysqlDuplicateEntry is the error number of a duplicate key, and mysqlUnknownThread that of KILL when
+	// there's no session with the id.
+	mysqlDuplicateEntry = 1062
+	mysqlUnknownThread  = 1094
+
+	// postgresUniqueViolation is the SQLSTATE of a duplicate key.
+	postgresUniqueViolation = "23505"
+)
+
+const (
+	DRIVER_MYSQL    = "mysql"
+	DRIVER_POSTGRES = "postgres"
+	DRIVER_SQLITE   = "sqlite"
+)
+
+var (
+	ErrUnsupportedDriver = errors.New("unsupported driver")
+	ErrLockTimeout       = errors.New("timed out acquiring lock")
+)
+
+// Dialect abstracts the SQL that differs between the databases the repositories run on. Queries are written
+// with ? placeholders, the Querier returned by Rebound rebinds them to the driver's.
+type Dialect interface {
+	Driver() string
+
+	// InsertReturningIDs executes a single or multi-row INSERT into a table with an id column and returns the
+	// ids assigned to the rows in the order they were given.
+	InsertReturningIDs(ctx context.Context, q Querier, query string, args ...interface{}) ([]int, error)
+
+	// InsertIgnore returns an INSERT of rows rows into columns of table that skips rows conflicting with a
+	// unique key.
+	InsertIgnore(table string, columns []string, rows int) string
+
+	// Upsert returns an INSERT of a row into columns of table that instead updates the update columns of the
+	// row conflicting with it on key.
+	Upsert(table string, columns, key, update []string) string
+
+	// RegexpOperator is the operator matching a column against a regular expression.
+	RegexpOperator() string
+
+	// LikeEscape is the ESCAPE clause of a LIKE whose pattern escapes wildcards with a backslash, empty where
+	// the backslash is the default.
+	LikeEscape() string
+
+	// SkipLocked is the locking clause of a SELECT that locks the rows it returns, skipping those locked by
+	// other transactions.
+	SkipLocked() string
+
+	// ForUpdate is the locking clause of a SELECT that locks the rows it returns, waiting for other transactions
+	// holding them to end.
+	ForUpdate() string
+
+	// IsUniqueViolation reports whether err is the violation of a primary or unique key.
+	IsUniqueViolation(err error) bool
+
+	// TableExists returns a query counting the tables named table in the default schema.
+	TableExists(table string) string
+
+	// Args converts the args of a query to the types the database stores and compares consistently.
+	Args(args []interface{}) []interface{}
+
+	// Lock takes a session level lock named name on conn, waiting up to timeout for it, and Unlock releases it.
+	// The lock is also released when conn is closed.
+	Lock(ctx context.Context, conn *sqlx.Conn, name string, timeout time.Duration) error
+	Unlock(ctx context.Context, conn *sqlx.Conn, name string) error
+
+	// SessionID returns the id of the database session q's queries run in, for KillSession. It's 0 where a
+	// session's locks can't outlive its client, so there's no need to kill one.
+	SessionID(ctx context.Context, q Querier) (int64, error)
+
+	// KillSession terminates the session with id, if it's still connected, rolling back its transaction and
+	// releasing its locks.
+	KillSession(ctx context.Context, q Querier, id int64) error
+}
+
+// NewDialect returns the dialect of a driver.
+func NewDialect(driver string) (Dialect, error) {
+	switch driver {
+	case DRIVER_MYSQL:
+		return mysqlDialect{}, nil
+	case DRIVER_POSTGRES:
+		return postgresDialect{}, nil
+	case DRIVER_SQLITE:
+		return sqliteDialect{}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedDriver, driver)
+}
+
+// DialectOf returns the dialect of q's driver, MySQL if it isn't supported.
+func DialectOf(q interface{ DriverName() string }) Dialect {
+	dialect, err := NewDialect(q.DriverName())
+	if err != nil {
+		return mysqlDialect{}
+	}
+
+	return dialect
+}
+
+// Rebound returns q with the ? placeholders of its queries rebound to those of its driver, and their args
+// converted by its dialect.
+func Rebound(q Querier) Querier {
+	dialect := DialectOf(q)
+	if dialect.Driver() == DRIVER_MYSQL {
+		return q
+	}
+
+	return &reboundQuerier{q, dialect}
+}
+
+type reboundQuerier struct {
+	Querier
+	dialect Dialect
+}
+
+func (r *reboundQuerier) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return r.Querier.Exec(r.Rebind(query), r.dialect.Args(args)...)
+}
+
+func (r *reboundQuerier) Get(dest interface{}, query string, args ...interface{}) error {
+	return r.Querier.Get(dest, r.Rebind(query), r.dialect.Args(args)...)
+}
+
+func (r *reboundQuerier) Select(dest interface{}, query string, args ...interface{}) error {
+	return r.Querier.Select(dest, r.Rebind(query), r.dialect.Args(args)...)
+}
+
+func (r *reboundQuerier) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return r.Querier.ExecContext(ctx, r.Rebind(query), r.dialect.Args(args)...)
+}
+
+func (r *reboundQuerier) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return r.Querier.GetContext(ctx, dest, r.Rebind(query), r.dialect.Args(args)...)
+}
+
+func (r *reboundQuerier) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return r.Querier.SelectContext(ctx, dest, r.Rebind(query), r.dialect.Args(args)...)
+}
+
+// values returns the VALUES list of rows rows of columns columns.
+func values(columns, rows int) string {
+	row := "(" + strings.TrimSuffix(strings.Repeat("?, ", columns), ", ") + ")"
+	return strings.TrimSuffix(strings.Repeat(row+", ", rows), ", ")
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Driver() string {
+	return DRIVER_MYSQL
+}
+
+// InsertReturningIDs relies on a multi-row INSERT ... VALUES being a "simple insert", for which InnoDB
+// allocates auto increment ids consecutively starting at LAST_INSERT_ID().
+func (mysqlDialect) InsertReturningIDs(ctx context.Context, q Querier, query string, args ...interface{}) ([]int, error) {
+	res, err := q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	firstID, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, rows)
+	for i := 0; i < int(rows); i++ {
+		ids = append(ids, int(firstID)+i)
+	}
+
+	return ids, nil
+}
+
+func (mysqlDialect) InsertIgnore(table string, columns []string, rows int) string {
+	return "INSERT IGNORE INTO " + table + " (" + strings.Join(columns, ", ") + ") VALUES " + values(len(columns), rows)
+}
+
+func (mysqlDialect) Upsert(table string, columns, key, update []string) string {
+	assignments := make([]string, 0, len(update))
+	for _, column := range update {
+		assignments = append(assignments, column+" = VALUES("+column+")")
+	}
+
+	return "INSERT INTO " + table + " (" + strings.Join(columns, ", ") + ") VALUES " + values(len(columns), 1) +
+		" ON DUPLICATE KEY UPDATE " + strings.Join(assignments, ", ")
+}
+
+func (mysqlDialect) RegexpOperator() string {
+	return "REGEXP"
+}
+
+func (mysqlDialect) LikeEscape() string {
+	return ""
+}
+
+func (mysqlDialect) SkipLocked() string {
+	return "FOR UPDATE SKIP LOCKED"
+}
+
+func (mysqlDialect) ForUpdate() string {
+	return "FOR UPDATE"
+}
+
+func (mysqlDialect) IsUniqueViolation(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry
+}
+
+func (mysqlDialect) TableExists(table string) string {
+	return "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = '" + table + "'"
+}
+
+func (mysqlDialect) Args(args []interface{}) []interface{} {
+	return args
+}
+
+func (mysqlDialect) Lock(ctx context.Context, conn *sqlx.Conn, name string, timeout time.Duration) error {
+	var acquired sql.NullInt64
+	if err := conn.GetContext(ctx, &acquired, "SELECT GET_LOCK(?, ?)", name, int(timeout.Seconds())); err != nil {
+		return err
+	}
+
+	if acquired.Int64 != 1 {
+		return ErrLockTimeout
+	}
+
+	return nil
+}
+
+func (mysqlDialect) Unlock(ctx context.Context, conn *sqlx.Conn, name string) error {
+	_, err := conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", name)
+	return err
+}
+
+func (mysqlDialect) SessionID(ctx context.Context, q Querier) (int64, error) {
+	var id int64
+	err := q.GetContext(ctx, &id, "SELECT CONNECTION_ID()")
+	return id, err
+}
+
+// KillSession formats id into the statement, KILL can't be prepared with a placeholder.
+func (mysqlDialect) KillSession(ctx context.Context, q Querier, id int64) error {
+	if _, err := q.ExecContext(ctx, fmt.Sprintf("KILL %d", id)); err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlUnknownThread {
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+// postgresDialect locks with advisory locks keyed by the hash of the lock's name.
+type postgresDialect struct{}
+
+func (postgresDialect) Driver() string {
+	return DRIVER_POSTGRES
+}
+
+// InsertReturningIDs relies on the rows of a multi-row INSERT ... VALUES being inserted, and returned, in the
+// order they're given.
+func (postgresDialect) InsertReturningIDs(ctx context.Context, q Querier, query string, args ...interface{}) ([]int, error) {
+	ids := []int{}
+	return ids, q.SelectContext(ctx, &ids, query+" RETURNING id", args...)
+}
+
+func (postgresDialect) InsertIgnore(table string, columns []string, rows int) string {
+	return "INSERT INTO " + table + " (" + strings.Join(columns, ", ") + ") VALUES " + values(len(columns), rows) +
+		" ON CONFLICT DO NOTHING"
+}
+
+func (postgresDialect) Upsert(table string, columns, key, update []string) string {
+	assignments := make([]string, 0, len(update))
+	for _, column := range update {
+		assignments = append(assignments, column+" = EXCLUDED."+column)
+	}
+
+	return "INSERT INTO " + table + " (" + strings.Join(columns, ", ") + ") VALUES " + values(len(columns), 1) +
+		" ON CONFLICT (" + strings.Join(key, ", ") + ") DO UPDATE SET " + strings.Join(assignments, ", ")
+}
+
+func (postgresDialect) RegexpOperator() string {
+	return "~"
+}
+
+func (postgresDialect) LikeEscape() string {
+	return ""
+}
+
+func (postgresDialect) SkipLocked() string {
+	return "FOR UPDATE SKIP LOCKED"
+}
+
+func (postgresDialect) ForUpdate() string {
+	return "FOR UPDATE"
+}
+
+func (postgresDialect) IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == postgresUniqueViolation
+}
+
+func (postgresDialect) TableExists(table string) string {
+	return "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = '" + strings.ToLower(table) + "'"
+}
+
+func (postgresDialect) Args(args []interface{}) []interface{} {
+	return args
+}
+
+// Lock cancels the wait for the lock after timeout, pg_advisory_lock waits indefinitely otherwise.
+func (postgresDialect) Lock(ctx context.Context, conn *sqlx.Conn, name string, timeout time.Duration) error {
+	lockCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if _, err := conn.ExecContext(lockCtx, "SELECT pg_advisory_lock(hashtext($1))", name); err != nil {
+		if lockCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			return ErrLockTimeout
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (postgresDialect) Unlock(ctx context.Context, conn *sqlx.Conn, name string) error {
+	_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock(hashtext($1))", name)
+	return err
+}
+
+func (postgresDialect) SessionID(ctx context.Context, q Querier) (int64, error) {
+	var id int64
+	err := q.GetContext(ctx, &id, "SELECT pg_backend_pid()")
+	return id, err
+}
+
+func (postgresDialect) KillSession(ctx context.Context, q Querier, id int64) error {
+	_, err := q.ExecContext(ctx, "SELECT pg_terminate_backend($1)", id)
+	return err
+}
@@ -0,0 +1,130 @@
+// +build unit
+
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialect(t *testing.T) {
+	t.Run("Unsupported Driver", func(t *testing.T) {
+		_, err := NewDialect("oracle")
+		require.Error(t, err)
+	})
+
+	t.Run("MySQL", func(t *testing.T) {
+		dialect, err := NewDialect(DRIVER_MYSQL)
+		require.NoError(t, err)
+
+		require.Equal(t, "INSERT IGNORE INTO ElementTag (element_id, tag) VALUES (?, ?), (?, ?)", dialect.InsertIgnore("ElementTag", []string{"element_id", "tag"}, 2))
+		require.Equal(
+			t,
+			"INSERT INTO A (id, x, y) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE x = VALUES(x), y = VALUES(y)",
+			dialect.Upsert("A", []string{"id", "x", "y"}, []string{"id"}, []string{"x", "y"}),
+		)
+	})
+
+	t.Run("PostgreSQL", func(t *testing.T) {
+		dialect, err := NewDialect(DRIVER_POSTGRES)
+		require.NoError(t, err)
+
+		require.Equal(t, "INSERT INTO ElementTag (element_id, tag) VALUES (?, ?), (?, ?) ON CONFLICT DO NOTHING", dialect.InsertIgnore("ElementTag", []string{"element_id", "tag"}, 2))
+		require.Equal(
+			t,
+			"INSERT INTO A (id, x, y) VALUES (?, ?, ?) ON CONFLICT (id) DO UPDATE SET x = EXCLUDED.x, y = EXCLUDED.y",
+			dialect.Upsert("A", []string{"id", "x", "y"}, []string{"id"}, []string{"x", "y"}),
+		)
+	})
+
+	t.Run("SQLite", func(t *testing.T) {
+		dialect, err := NewDialect(DRIVER_SQLITE)
+		require.NoError(t, err)
+
+		require.Equal(t, "INSERT OR IGNORE INTO ElementTag (element_id, tag) VALUES (?, ?), (?, ?)", dialect.InsertIgnore("ElementTag", []string{"element_id", "tag"}, 2))
+		require.Equal(
+			t,
+			"INSERT INTO A (id, x, y) VALUES (?, ?, ?) ON CONFLICT (id) DO UPDATE SET x = EXCLUDED.x, y = EXCLUDED.y",
+			dialect.Upsert("A", []string{"id", "x", "y"}, []string{"id"}, []string{"x", "y"}),
+		)
+
+		at := time.Date(2019, 3, 1, 12, 30, 15, 500, time.FixedZone("CET", 3600))
+		var unset *time.Time
+		require.Equal(
+			t,
+			[]interface{}{1, "2019-03-01 11:30:15.0000005", "2019-03-01 11:30:15.0000005", unset},
+			dialect.Args([]interface{}{1, at, &at, unset}),
+		)
+	})
+}
+
+func TestSQLiteRegexp(t *testing.T) {
+	matched, err := sqliteRegexp("^te.t$", "test")
+	require.NoError(t, err)
+	require.True(t, matched)
+
+	matched, err = sqliteRegexp("^te.t$", []byte("tests"))
+	require.NoError(t, err)
+	require.False(t, matched)
+
+	matched, err = sqliteRegexp("^te.t$", nil)
+	require.NoError(t, err)
+	require.False(t, matched)
+
+	_, err = sqliteRegexp("(", "test")
+	require.Error(t, err)
+}
+
+func TestConfigDSN(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		dsn    string
+	}{
+		{
+			"MySQL",
+			Config{Driver: DRIVER_MYSQL, User: "root", Host: "localhost", Name: "square_enix"},
+			"root@tcp(localhost:3306)/square_enix?parseTime=true",
+		},
+		{
+			"MySQL Password, Port and TLS",
+			Config{Driver: DRIVER_MYSQL, User: "root", Password: "secret", Host: "db", Port: 3307, Name: "square_enix", TLS: "skip-verify"},
+			"root:secret@tcp(db:3307)/square_enix?parseTime=true&tls=skip-verify",
+		},
+		{
+			"PostgreSQL",
+			Config{Driver: DRIVER_POSTGRES, User: "postgres", Host: "localhost", Name: "square_enix"},
+			"postgres://postgres@localhost:5432/square_enix?sslmode=disable",
+		},
+		{
+			"PostgreSQL Password, Port and TLS",
+			Config{Driver: DRIVER_POSTGRES, User: "postgres", Password: "p@ss", Host: "db", Port: 5433, Name: "square_enix", TLS: "true", TLSCA: "/ca.pem"},
+			"postgres://postgres:p%40ss@db:5433/square_enix?sslmode=verify-full&sslrootcert=%2Fca.pem",
+		},
+		{
+			"SQLite",
+			Config{Driver: DRIVER_SQLITE, Name: "/tmp/square_enix.db"},
+			"file:/tmp/square_enix.db?_busy_timeout=30000&_foreign_keys=on&_journal_mode=WAL&_txlock=immediate",
+		},
+		{
+			"SQLite In Memory",
+			Config{Driver: DRIVER_SQLITE, Name: SQLITE_MEMORY},
+			"file::memory:?_busy_timeout=30000&_foreign_keys=on&_txlock=immediate",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dsn, err := test.config.DSN()
+			require.NoError(t, err)
+			require.Equal(t, test.dsn, dsn)
+		})
+	}
+
+	t.Run("Unsupported Driver", func(t *testing.T) {
+		_, err := Config{Driver: "oracle"}.DSN()
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,163 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+)
+
+// sqliteTimeFormat is the format timestamps are stored in, that of CURRENT_TIMESTAMP with any fraction of a
+// second, so that those written by the database and by the application compare as strings in time order.
+const sqliteTimeFormat = "2006-01-02 15:04:05.999999999"
+
+// regexps caches the compiled patterns of the regexp function by pattern.
+var regexps sync.Map
+
+func init() {
+	sql.Register(DRIVER_SQLITE, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc("regexp", sqliteRegexp, true)
+		},
+	})
+	sqlx.BindDriver(DRIVER_SQLITE, sqlx.QUESTION)
+}
+
+// sqliteRegexp implements the REGEXP operator, which SQLite calls as regexp(pattern, value).
+func sqliteRegexp(pattern string, value interface{}) (bool, error) {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		return false, nil
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		s = fmt.Sprint(v)
+	}
+
+	re, ok := regexps.Load(pattern)
+	if !ok {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, err
+		}
+		re, _ = regexps.LoadOrStore(pattern, compiled)
+	}
+
+	return re.(*regexp.Regexp).MatchString(s), nil
+}
+
+// sqliteDialect relies on the database having a single writer. Connections begin their transactions with
+// BEGIN IMMEDIATE, which takes the database's write lock, so the transactions that lock rows on the
+// other databases run one at a time instead.
+type sqliteDialect struct{}
+
+func (sqliteDialect) Driver() string {
+	return DRIVER_SQLITE
+}
+
+// InsertReturningIDs relies on the write lock held by the INSERT, the rows are assigned consecutive ids ending
+// at the id of the last.
+func (sqliteDialect) InsertReturningIDs(ctx context.Context, q Querier, query string, args ...interface{}) ([]int, error) {
+	res, err := q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	lastID, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, rows)
+	for i := int(rows) - 1; i >= 0; i-- {
+		ids = append(ids, int(lastID)-i)
+	}
+
+	return ids, nil
+}
+
+func (sqliteDialect) InsertIgnore(table string, columns []string, rows int) string {
+	return "INSERT OR IGNORE INTO " + table + " (" + strings.Join(columns, ", ") + ") VALUES " + values(len(columns), rows)
+}
+
+// Upsert has the syntax of PostgreSQL's.
+func (sqliteDialect) Upsert(table string, columns, key, update []string) string {
+	return postgresDialect{}.Upsert(table, columns, key, update)
+}
+
+func (sqliteDialect) RegexpOperator() string {
+	return "REGEXP"
+}
+
+func (sqliteDialect) LikeEscape() string {
+	return ` ESCAPE '\'`
+}
+
+func (sqliteDialect) SkipLocked() string {
+	return ""
+}
+
+func (sqliteDialect) ForUpdate() string {
+	return ""
+}
+
+func (sqliteDialect) IsUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) &&
+		(sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique || sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey)
+}
+
+func (sqliteDialect) TableExists(table string) string {
+	return "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = '" + table + "'"
+}
+
+// Args formats times in UTC, as timestamps are stored.
+func (sqliteDialect) Args(args []interface{}) []interface{} {
+	converted := make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		switch t := arg.(type) {
+		case time.Time:
+			arg = t.UTC().Format(sqliteTimeFormat)
+		case *time.Time:
+			if t != nil {
+				arg = t.UTC().Format(sqliteTimeFormat)
+			}
+		}
+		converted = append(converted, arg)
+	}
+
+	return converted
+}
+
+// Lock is a no-op, SQLite has no session level locks. A database file must be migrated by one process at a time.
+func (sqliteDialect) Lock(ctx context.Context, conn *sqlx.Conn, name string, timeout time.Duration) error {
+	return nil
+}
+
+func (sqliteDialect) Unlock(ctx context.Context, conn *sqlx.Conn, name string) error {
+	return nil
+}
+
+// SessionID is 0, a process's connections, and the write lock of its transaction, are closed when it dies.
+func (sqliteDialect) SessionID(ctx context.Context, q Querier) (int64, error) {
+	return 0, nil
+}
+
+func (sqliteDialect) KillSession(ctx context.Context, q Querier, id int64) error {
+	return nil
+}
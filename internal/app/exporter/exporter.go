@@ -0,0 +1,127 @@
+//go:generate mockgen -package exporter -source=exporter.go -destination ./mocks/exporter.go
+
+package exporter
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	"github.com/eggsbenjamin/square_enix/internal/app/ingester"
+	"github.com/eggsbenjamin/square_enix/internal/app/models"
+	"github.com/eggsbenjamin/square_enix/internal/app/repository"
+)
+
+const (
+	// pageSize is the number of elements read from the db at a time.
+	pageSize = 500
+
+	// csvTagSeparator separates the tags within the tags column, as the ingester expects.
+	csvTagSeparator = ";"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported format")
+
+// Exporter writes elements out in the formats the ingester reads, so an export can be imported elsewhere.
+type Exporter interface {
+	Export(w io.Writer, format string, selector models.ElementSelector) (int, error)
+	ExportContext(ctx context.Context, w io.Writer, format string, selector models.ElementSelector) (int, error)
+}
+
+type exporter struct {
+	db                 db.Querier
+	elementRepoFactory repository.ElementRepositoryFactory
+}
+
+func NewExporter(
+	db db.Querier,
+	elementRepoFactory repository.ElementRepositoryFactory,
+) Exporter {
+	return &exporter{
+		db:                 db,
+		elementRepoFactory: elementRepoFactory,
+	}
+}
+
+func (e *exporter) Export(w io.Writer, format string, selector models.ElementSelector) (int, error) {
+	return e.ExportContext(context.Background(), w, format, selector)
+}
+
+// ExportContext writes the elements matched by selector to w in id order, returning how many were written.
+// Elements are read a page at a time rather than all at once.
+//   - ndjson: one `{"id": 1, "data": "...", "created_at": "...", "tags": [...]}` object per line
+//   - csv: a header row of id, data, created_at and tags, with the tags semicolon separated
+func (e *exporter) ExportContext(ctx context.Context, w io.Writer, format string, selector models.ElementSelector) (int, error) {
+	var write func(element models.Element) error
+	flush := func() error { return nil }
+
+	switch format {
+	case ingester.FORMAT_NDJSON:
+		encoder := json.NewEncoder(w)
+		write = func(element models.Element) error {
+			return encoder.Encode(element)
+		}
+	case ingester.FORMAT_CSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write([]string{"id", "data", "created_at", "tags"}); err != nil {
+			return 0, errors.Wrap(err, "error writing csv header")
+		}
+
+		write = func(element models.Element) error {
+			return writer.Write([]string{
+				strconv.Itoa(element.ID),
+				element.Data,
+				element.CreatedAt.UTC().Format(time.RFC3339),
+				strings.Join(element.Tags, csvTagSeparator),
+			})
+		}
+		flush = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+	default:
+		return 0, errors.Wrap(ErrUnsupportedFormat, format)
+	}
+
+	elementRepo := e.elementRepoFactory.CreateElementRepository(e.db)
+
+	exported, afterID := 0, 0
+	for {
+		elements, err := elementRepo.ListElements(ctx, afterID, pageSize, selector)
+		if err != nil {
+			return exported, errors.Wrap(err, "error listing elements")
+		}
+
+		if len(elements) == 0 {
+			return exported, errors.Wrap(flush(), "error writing elements")
+		}
+
+		ids := make([]int, 0, len(elements))
+		for _, element := range elements {
+			ids = append(ids, element.ID)
+		}
+
+		tags, err := elementRepo.GetTagsByElementIDs(ctx, ids)
+		if err != nil {
+			return exported, errors.Wrap(err, "error retreiving element tags")
+		}
+
+		for _, element := range elements {
+			element.Tags = tags[element.ID]
+
+			if err := write(element); err != nil {
+				return exported, errors.Wrap(err, "error writing element")
+			}
+			exported++
+		}
+
+		afterID = elements[len(elements)-1].ID
+	}
+}
@@ -0,0 +1,81 @@
+// +build unit
+
+package exporter_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/exporter"
+	"github.com/eggsbenjamin/square_enix/internal/app/ingester"
+	"github.com/eggsbenjamin/square_enix/internal/app/models"
+	mock_repository "github.com/eggsbenjamin/square_enix/internal/app/repository/mocks"
+)
+
+func TestExport(t *testing.T) {
+	createdAt := time.Date(2019, 1, 1, 12, 0, 0, 0, time.UTC)
+	selector := models.ElementSelector{Tags: []string{"fruit"}}
+
+	setup := func(t *testing.T) (exporter.Exporter, *gomock.Controller) {
+		ctrl := gomock.NewController(t)
+
+		elementRepo := mock_repository.NewMockElementRepository(ctrl)
+		elementRepoFactory := mock_repository.NewMockElementRepositoryFactory(ctrl)
+		elementRepoFactory.EXPECT().CreateElementRepository(gomock.Any()).Return(elementRepo).AnyTimes()
+
+		gomock.InOrder(
+			elementRepo.EXPECT().ListElements(gomock.Any(), 0, gomock.Any(), selector).Return([]models.Element{
+				{ID: 1, Data: "apple", CreatedAt: createdAt},
+				{ID: 3, Data: "banana, ripe", CreatedAt: createdAt},
+			}, nil),
+			elementRepo.EXPECT().GetTagsByElementIDs(gomock.Any(), []int{1, 3}).Return(map[int][]string{
+				1: {"fruit", "red"},
+			}, nil),
+			elementRepo.EXPECT().ListElements(gomock.Any(), 3, gomock.Any(), selector).Return([]models.Element{}, nil),
+		)
+
+		return exporter.NewExporter(nil, elementRepoFactory), ctrl
+	}
+
+	t.Run("CSV", func(t *testing.T) {
+		exp, ctrl := setup(t)
+		defer ctrl.Finish()
+
+		var buf bytes.Buffer
+		exported, err := exp.Export(&buf, ingester.FORMAT_CSV, selector)
+		require.NoError(t, err)
+		require.Equal(t, 2, exported)
+		require.Equal(
+			t,
+			"id,data,created_at,tags\n1,apple,2019-01-01T12:00:00Z,fruit;red\n3,\"banana, ripe\",2019-01-01T12:00:00Z,\n",
+			buf.String(),
+		)
+	})
+
+	t.Run("NDJSON", func(t *testing.T) {
+		exp, ctrl := setup(t)
+		defer ctrl.Finish()
+
+		var buf bytes.Buffer
+		exported, err := exp.ExportContext(context.Background(), &buf, ingester.FORMAT_NDJSON, selector)
+		require.NoError(t, err)
+		require.Equal(t, 2, exported)
+		require.Equal(
+			t,
+			`{"id":1,"data":"apple","created_at":"2019-01-01T12:00:00Z","tags":["fruit","red"]}`+"\n"+
+				`{"id":3,"data":"banana, ripe","created_at":"2019-01-01T12:00:00Z"}`+"\n",
+			buf.String(),
+		)
+	})
+
+	t.Run("Unsupported Format", func(t *testing.T) {
+		_, err := exporter.NewExporter(nil, nil).Export(&bytes.Buffer{}, "xml", selector)
+		require.Equal(t, exporter.ErrUnsupportedFormat, errors.Cause(err))
+	})
+}
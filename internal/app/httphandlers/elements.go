@@ -0,0 +1,135 @@
+package httphandlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/pkg/errors"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/ingester"
+	"github.com/eggsbenjamin/square_enix/internal/app/processor"
+)
+
+type ElementsHandler struct {
+	ingester ingester.Ingester
+}
+
+func NewElementsHandler(ingester ingester.Ingester) *ElementsHandler {
+	return &ElementsHandler{
+		ingester: ingester,
+	}
+}
+
+func (s *ElementsHandler) Handle(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var inputs []ingester.Input
+	if err := json.NewDecoder(req.Body).Decode(&inputs); err != nil {
+		writeJSON(w, http.StatusBadRequest, message{fmt.Sprintf("invalid request body: %s", err)})
+		return
+	}
+
+	result, err := s.ingester.IngestContext(req.Context(), inputs)
+	if err != nil {
+		log.Printf("error ingesting elements: %q", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"message":"internal server error"}`))
+		return
+	}
+
+	log.Printf("inserted %d elements, rejected %d", result.Inserted, result.Rejected)
+	writeJSON(w, http.StatusOK, result)
+}
+
+// partialIngestion reports the elements committed by a bulk upload that failed part way.
+type partialIngestion struct {
+	Message string `json:"message"`
+	ingester.Result
+}
+
+type BulkElementsHandler struct {
+	ingester ingester.Ingester
+}
+
+func NewBulkElementsHandler(ingester ingester.Ingester) *BulkElementsHandler {
+	return &BulkElementsHandler{
+		ingester: ingester,
+	}
+}
+
+// bulkFormat determines the upload format from the format query param, falling back to the Content-Type.
+func bulkFormat(req *http.Request) string {
+	if format := req.URL.Query().Get("format"); format != "" {
+		return format
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		return ingester.FORMAT_CSV
+	default:
+		return ingester.FORMAT_NDJSON
+	}
+}
+
+func (s *BulkElementsHandler) Handle(w http.ResponseWriter, req *http.Request) {
+	format := bulkFormat(req)
+
+	w.Header().Set("Content-Type", "application/json")
+
+	result, err := s.ingester.IngestStreamContext(req.Context(), req.Body, format)
+	if err != nil {
+		if errors.Cause(err) == ingester.ErrUnsupportedFormat {
+			writeJSON(w, http.StatusBadRequest, message{err.Error()})
+			return
+		}
+
+		log.Printf("error ingesting elements after inserting %d: %q", result.Inserted, err)
+		writeJSON(w, http.StatusInternalServerError, partialIngestion{"internal server error", result})
+		return
+	}
+
+	log.Printf("inserted %d elements, rejected %d", result.Inserted, result.Rejected)
+	writeJSON(w, http.StatusOK, result)
+}
+
+type ElementHistoryHandler struct {
+	proc processor.Processor
+}
+
+func NewElementHistoryHandler(proc processor.Processor) *ElementHistoryHandler {
+	return &ElementHistoryHandler{
+		proc: proc,
+	}
+}
+
+func (s *ElementHistoryHandler) Handle(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(chi.URLParam(req, "id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message":"invalid element id"}`))
+		return
+	}
+
+	history, err := s.proc.GetElementHistoryContext(req.Context(), id)
+	if err != nil {
+		if err == processor.ErrNoElementExists {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"element does not exist"}`))
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"message":"internal server error"}`))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, history)
+}
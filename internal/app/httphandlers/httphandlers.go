@@ -1,13 +1,230 @@
 package httphandlers
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/go-chi/chi"
+	"github.com/pkg/errors"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/models"
 	"github.com/eggsbenjamin/square_enix/internal/app/processor"
 )
 
+type message struct {
+	Message string `json:"message"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("error marshalling response: %q", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"message":"internal server error"}`))
+		return
+	}
+
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// processID parses the {id} url param of process routes.
+func processID(req *http.Request) (int, error) {
+	return strconv.Atoi(chi.URLParam(req, "id"))
+}
+
+// transitionContext attributes the process transitions a request makes to the actor named in its X-Actor
+// header, with the reason given in its reason query param.
+func transitionContext(req *http.Request) context.Context {
+	actor := req.Header.Get("X-Actor")
+	if actor == "" {
+		actor = "anonymous"
+	}
+
+	ctx := processor.WithActor(req.Context(), actor)
+	if reason := req.URL.Query().Get("reason"); reason != "" {
+		ctx = processor.WithReason(ctx, reason)
+	}
+
+	return ctx
+}
+
+// processContext is the transitionContext of a request to change a process, which only applies to the process
+// at the version given in its If-Match header. The header takes the process's ETag, its version quoted; a
+// missing header or * applies to any version.
+func processContext(req *http.Request) (context.Context, error) {
+	ctx := transitionContext(req)
+
+	ifMatch := strings.TrimSpace(req.Header.Get("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		return ctx, nil
+	}
+
+	unquoted, err := strconv.Unquote(ifMatch)
+	if err != nil {
+		return nil, errors.Errorf("invalid If-Match header: %s", ifMatch)
+	}
+
+	version, err := strconv.Atoi(unquoted)
+	if err != nil {
+		return nil, errors.Errorf("invalid If-Match header: %s", ifMatch)
+	}
+
+	return processor.WithVersion(ctx, version), nil
+}
+
+// etag returns the ETag of a process at version.
+func etag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// transformerConfig returns the raw JSON transformer config of a request, empty if it wasn't given.
+func transformerConfig(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+
+	return string(raw)
+}
+
+type createRequest struct {
+	Name              string                 `json:"name"`
+	Selector          models.ElementSelector `json:"selector"`
+	Transformer       string                 `json:"transformer"`
+	TransformerConfig json.RawMessage        `json:"transformer_config"`
+}
+
+type CreateHandler struct {
+	proc processor.Processor
+}
+
+func NewCreateHandler(proc processor.Processor) *CreateHandler {
+	return &CreateHandler{
+		proc: proc,
+	}
+}
+
+func (s *CreateHandler) Handle(w http.ResponseWriter, req *http.Request) {
+	log.Print("creating process")
+
+	w.Header().Set("Content-Type", "application/json")
+
+	var body createRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, message{fmt.Sprintf("invalid request body: %s", err)})
+		return
+	}
+
+	process, err := s.proc.StartContext(transitionContext(req), processor.StartOptions{
+		Name:              body.Name,
+		Selector:          body.Selector,
+		Transformer:       body.Transformer,
+		TransformerConfig: transformerConfig(body.TransformerConfig),
+	})
+	if err != nil {
+		if cause := errors.Cause(err); cause == processor.ErrInvalidTransformer || cause == processor.ErrInvalidSelector {
+			writeJSON(w, http.StatusBadRequest, message{err.Error()})
+			return
+		}
+
+		if err == processor.ErrInvalidProcessName {
+			writeJSON(w, http.StatusBadRequest, message{"name is required and must be at most 100 characters"})
+			return
+		}
+
+		if err == processor.ErrProcessNameExists {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"message":"active process with name exists"}`))
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"message":"internal server error"}`))
+		return
+	}
+
+	log.Printf("process %d created", process.ID)
+	w.Header().Set("ETag", etag(process.Version))
+	writeJSON(w, http.StatusCreated, process)
+}
+
+type previewRequest struct {
+	Selector          models.ElementSelector `json:"selector"`
+	Transformer       string                 `json:"transformer"`
+	TransformerConfig json.RawMessage        `json:"transformer_config"`
+	SampleSize        int                    `json:"sample_size"`
+}
+
+type PreviewHandler struct {
+	proc processor.Processor
+}
+
+func NewPreviewHandler(proc processor.Processor) *PreviewHandler {
+	return &PreviewHandler{
+		proc: proc,
+	}
+}
+
+func (s *PreviewHandler) Handle(w http.ResponseWriter, req *http.Request) {
+	log.Print("previewing process")
+
+	w.Header().Set("Content-Type", "application/json")
+
+	var body previewRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, message{fmt.Sprintf("invalid request body: %s", err)})
+		return
+	}
+
+	preview, err := s.proc.PreviewContext(req.Context(), processor.PreviewOptions{
+		Selector:          body.Selector,
+		Transformer:       body.Transformer,
+		TransformerConfig: transformerConfig(body.TransformerConfig),
+		SampleSize:        body.SampleSize,
+	})
+	if err != nil {
+		if cause := errors.Cause(err); cause == processor.ErrInvalidTransformer || cause == processor.ErrInvalidSelector {
+			writeJSON(w, http.StatusBadRequest, message{err.Error()})
+			return
+		}
+
+		log.Printf("error previewing process: %q", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"message":"internal server error"}`))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, preview)
+}
+
+type ListHandler struct {
+	proc processor.Processor
+}
+
+func NewListHandler(proc processor.Processor) *ListHandler {
+	return &ListHandler{
+		proc: proc,
+	}
+}
+
+func (s *ListHandler) Handle(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	processes, err := s.proc.GetProcessesContext(req.Context())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"message":"internal server error"}`))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, processes)
+}
+
 type StartHandler struct {
 	proc processor.Processor
 }
@@ -19,14 +236,43 @@ func NewStartHandler(proc processor.Processor) *StartHandler {
 }
 
 func (s *StartHandler) Handle(w http.ResponseWriter, req *http.Request) {
-	log.Print("starting process")
-
 	w.Header().Set("Content-Type", "application/json")
 
-	if err := s.proc.Start(); err != nil {
-		if err == processor.ErrRunningProcessExists {
-			w.WriteHeader(http.StatusTooManyRequests)
-			w.Write([]byte(`{"message":"running process exists"}`))
+	id, err := processID(req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message":"invalid process id"}`))
+		return
+	}
+
+	log.Printf("resuming process %d", id)
+
+	ctx, err := processContext(req)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, message{err.Error()})
+		return
+	}
+
+	process, err := s.proc.ResumeContext(ctx, id)
+	if err != nil {
+		if err == processor.ErrNoProcessExists {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"process does not exist"}`))
+			return
+		}
+
+		if err == processor.ErrVersionMismatch {
+			writeJSON(w, http.StatusPreconditionFailed, message{err.Error()})
+			return
+		}
+
+		if err == processor.ErrConcurrentModification {
+			writeJSON(w, http.StatusConflict, message{err.Error()})
+			return
+		}
+
+		if _, ok := err.(*models.TransitionError); ok {
+			writeJSON(w, http.StatusConflict, message{err.Error()})
 			return
 		}
 
@@ -35,7 +281,8 @@ func (s *StartHandler) Handle(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	log.Print("process started")
+	log.Printf("process %d resumed", id)
+	w.Header().Set("ETag", etag(process.Version))
 	w.WriteHeader(http.StatusAccepted)
 	w.Write([]byte(`{"message":"process started"}`))
 }
@@ -53,11 +300,55 @@ func NewStatHandler(proc processor.Processor) *StatHandler {
 func (s *StatHandler) Handle(w http.ResponseWriter, req *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	stat, err := s.proc.GetLatestsStat()
+	id, err := processID(req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message":"invalid process id"}`))
+		return
+	}
+
+	status, err := s.proc.GetStatusContext(req.Context(), id)
+	if err != nil {
+		if err == processor.ErrNoProcessExists {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"process does not exist"}`))
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"message":"internal server error"}`))
+		return
+	}
+
+	w.Header().Set("ETag", etag(status.Version))
+	writeJSON(w, http.StatusOK, status)
+}
+
+type HistoryHandler struct {
+	proc processor.Processor
+}
+
+func NewHistoryHandler(proc processor.Processor) *HistoryHandler {
+	return &HistoryHandler{
+		proc: proc,
+	}
+}
+
+func (s *HistoryHandler) Handle(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := processID(req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message":"invalid process id"}`))
+		return
+	}
+
+	history, err := s.proc.GetHistoryContext(req.Context(), id)
 	if err != nil {
 		if err == processor.ErrNoProcessExists {
-			w.WriteHeader(http.StatusPreconditionFailed)
-			w.Write([]byte(`{"message":"no processes exist"}`))
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"process does not exist"}`))
 			return
 		}
 
@@ -66,9 +357,7 @@ func (s *StatHandler) Handle(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(fmt.Sprintf(`{"stat": %d}`, stat)))
-	return
+	writeJSON(w, http.StatusOK, history)
 }
 
 type PauseHandler struct {
@@ -82,15 +371,43 @@ func NewPauseHandler(proc processor.Processor) *PauseHandler {
 }
 
 func (s *PauseHandler) Handle(w http.ResponseWriter, req *http.Request) {
-	log.Print("pausing process")
-
 	w.Header().Set("Content-Type", "application/json")
 
-	if err := s.proc.Pause(); err != nil {
-		if err == processor.ErrNoProcessExists ||
-			err == processor.ErrNoRunningProcessExists {
-			w.WriteHeader(http.StatusPreconditionFailed)
-			w.Write([]byte(`{"message":"no applicable processes exist"}`))
+	id, err := processID(req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message":"invalid process id"}`))
+		return
+	}
+
+	log.Printf("pausing process %d", id)
+
+	ctx, err := processContext(req)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, message{err.Error()})
+		return
+	}
+
+	process, err := s.proc.PauseContext(ctx, id)
+	if err != nil {
+		if err == processor.ErrNoProcessExists {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"process does not exist"}`))
+			return
+		}
+
+		if err == processor.ErrVersionMismatch {
+			writeJSON(w, http.StatusPreconditionFailed, message{err.Error()})
+			return
+		}
+
+		if err == processor.ErrConcurrentModification {
+			writeJSON(w, http.StatusConflict, message{err.Error()})
+			return
+		}
+
+		if _, ok := err.(*models.TransitionError); ok {
+			writeJSON(w, http.StatusConflict, message{err.Error()})
 			return
 		}
 
@@ -99,7 +416,300 @@ func (s *PauseHandler) Handle(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	log.Print("process paused")
+	log.Printf("process %d paused", id)
+	w.Header().Set("ETag", etag(process.Version))
 	w.WriteHeader(http.StatusAccepted)
 	w.Write([]byte(`{"message":"process paused"}`))
 }
+
+type CancelHandler struct {
+	proc processor.Processor
+}
+
+func NewCancelHandler(proc processor.Processor) *CancelHandler {
+	return &CancelHandler{
+		proc: proc,
+	}
+}
+
+func (s *CancelHandler) Handle(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := processID(req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message":"invalid process id"}`))
+		return
+	}
+
+	log.Printf("cancelling process %d", id)
+
+	ctx, err := processContext(req)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, message{err.Error()})
+		return
+	}
+
+	process, err := s.proc.CancelContext(ctx, id)
+	if err != nil {
+		if err == processor.ErrNoProcessExists {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"process does not exist"}`))
+			return
+		}
+
+		if err == processor.ErrVersionMismatch {
+			writeJSON(w, http.StatusPreconditionFailed, message{err.Error()})
+			return
+		}
+
+		if err == processor.ErrConcurrentModification {
+			writeJSON(w, http.StatusConflict, message{err.Error()})
+			return
+		}
+
+		if _, ok := err.(*models.TransitionError); ok {
+			writeJSON(w, http.StatusConflict, message{err.Error()})
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"message":"internal server error"}`))
+		return
+	}
+
+	log.Printf("process %d cancelled", id)
+	w.Header().Set("ETag", etag(process.Version))
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte(`{"message":"process cancelled"}`))
+}
+
+type RestartHandler struct {
+	proc processor.Processor
+}
+
+func NewRestartHandler(proc processor.Processor) *RestartHandler {
+	return &RestartHandler{
+		proc: proc,
+	}
+}
+
+func (s *RestartHandler) Handle(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := processID(req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message":"invalid process id"}`))
+		return
+	}
+
+	log.Printf("restarting process %d", id)
+
+	ctx, err := processContext(req)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, message{err.Error()})
+		return
+	}
+
+	process, err := s.proc.RestartContext(ctx, id)
+	if err != nil {
+		if err == processor.ErrNoProcessExists {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"process does not exist"}`))
+			return
+		}
+
+		if err == processor.ErrVersionMismatch {
+			writeJSON(w, http.StatusPreconditionFailed, message{err.Error()})
+			return
+		}
+
+		if err == processor.ErrConcurrentModification {
+			writeJSON(w, http.StatusConflict, message{err.Error()})
+			return
+		}
+
+		if err == processor.ErrProcessNameExists {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"message":"active process with name exists"}`))
+			return
+		}
+
+		if _, ok := err.(*models.TransitionError); ok {
+			writeJSON(w, http.StatusConflict, message{err.Error()})
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"message":"internal server error"}`))
+		return
+	}
+
+	log.Printf("process %d restarted as %d", id, process.ID)
+	w.Header().Set("ETag", etag(process.Version))
+	writeJSON(w, http.StatusCreated, process)
+}
+
+type RevertHandler struct {
+	proc processor.Processor
+}
+
+func NewRevertHandler(proc processor.Processor) *RevertHandler {
+	return &RevertHandler{
+		proc: proc,
+	}
+}
+
+func (s *RevertHandler) Handle(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := processID(req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message":"invalid process id"}`))
+		return
+	}
+
+	log.Printf("reverting process %d", id)
+
+	ctx, err := processContext(req)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, message{err.Error()})
+		return
+	}
+
+	process, err := s.proc.RevertContext(ctx, id)
+	if err != nil {
+		if err == processor.ErrNoProcessExists {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"process does not exist"}`))
+			return
+		}
+
+		if err == processor.ErrVersionMismatch {
+			writeJSON(w, http.StatusPreconditionFailed, message{err.Error()})
+			return
+		}
+
+		if err == processor.ErrConcurrentModification {
+			writeJSON(w, http.StatusConflict, message{err.Error()})
+			return
+		}
+
+		if _, ok := err.(*models.TransitionError); ok {
+			writeJSON(w, http.StatusConflict, message{err.Error()})
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"message":"internal server error"}`))
+		return
+	}
+
+	log.Printf("process %d reverting", id)
+	w.Header().Set("ETag", etag(process.Version))
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte(`{"message":"process reverting"}`))
+}
+
+type FailuresHandler struct {
+	proc processor.Processor
+}
+
+func NewFailuresHandler(proc processor.Processor) *FailuresHandler {
+	return &FailuresHandler{
+		proc: proc,
+	}
+}
+
+func (s *FailuresHandler) Handle(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := processID(req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message":"invalid process id"}`))
+		return
+	}
+
+	deadLetteredOnly := req.URL.Query().Get("dead_lettered") == "true"
+
+	failures, err := s.proc.GetFailuresContext(req.Context(), id, deadLetteredOnly)
+	if err != nil {
+		if err == processor.ErrNoProcessExists {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"process does not exist"}`))
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"message":"internal server error"}`))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, failures)
+}
+
+type RedriveHandler struct {
+	proc processor.Processor
+}
+
+func NewRedriveHandler(proc processor.Processor) *RedriveHandler {
+	return &RedriveHandler{
+		proc: proc,
+	}
+}
+
+func (s *RedriveHandler) Handle(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := processID(req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message":"invalid process id"}`))
+		return
+	}
+
+	log.Printf("redriving dead lettered elements of process %d", id)
+
+	ctx, err := processContext(req)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, message{err.Error()})
+		return
+	}
+
+	redriven, process, err := s.proc.RedriveContext(ctx, id)
+	if err != nil {
+		if err == processor.ErrNoProcessExists {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"process does not exist"}`))
+			return
+		}
+
+		if err == processor.ErrVersionMismatch {
+			writeJSON(w, http.StatusPreconditionFailed, message{err.Error()})
+			return
+		}
+
+		if errors.Cause(err) == processor.ErrConcurrentModification {
+			writeJSON(w, http.StatusConflict, message{processor.ErrConcurrentModification.Error()})
+			return
+		}
+
+		if errors.Cause(err) == processor.ErrProcessNameExists {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"message":"active process with name exists"}`))
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"message":"internal server error"}`))
+		return
+	}
+
+	log.Printf("redrove %d elements of process %d", redriven, id)
+	w.Header().Set("ETag", etag(process.Version))
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte(fmt.Sprintf(`{"redriven": %d}`, redriven)))
+}
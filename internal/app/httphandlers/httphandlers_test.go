@@ -0,0 +1,74 @@
+// +build unit
+
+package httphandlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/httphandlers"
+	"github.com/eggsbenjamin/square_enix/internal/app/models"
+	"github.com/eggsbenjamin/square_enix/internal/app/processor"
+	mock_processor "github.com/eggsbenjamin/square_enix/internal/app/processor/mocks"
+)
+
+func TestRestartHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		process  models.Process
+		err      error
+		expected int
+		body     string
+	}{
+		{
+			name:     "Restarts",
+			process:  models.Process{ID: 2, Name: "test", Status: models.PROCESS_STATUS_RUNNING},
+			expected: http.StatusCreated,
+		},
+		{
+			name:     "No Process",
+			err:      processor.ErrNoProcessExists,
+			expected: http.StatusNotFound,
+			body:     `{"message":"process does not exist"}`,
+		},
+		{
+			name:     "Stale Version",
+			err:      processor.ErrVersionMismatch,
+			expected: http.StatusPreconditionFailed,
+			body:     `{"message":"process not at given version"}`,
+		},
+		{
+			name:     "Reverting",
+			err:      &models.TransitionError{From: models.PROCESS_STATUS_REVERTING, To: models.PROCESS_STATUS_CANCELLED},
+			expected: http.StatusConflict,
+			body:     `{"message":"process can't move from REVERTING to CANCELLED"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			proc := mock_processor.NewMockProcessor(ctrl)
+			proc.EXPECT().RestartContext(gomock.Any(), 1).Return(test.process, test.err)
+
+			router := chi.NewRouter()
+			router.Put("/process/{id}/restart", httphandlers.NewRestartHandler(proc).Handle)
+
+			req := httptest.NewRequest(http.MethodPut, "/process/1/restart", nil)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, test.expected, rec.Code)
+			if test.body != "" {
+				require.JSONEq(t, test.body, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package httphandlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/models"
+	"github.com/eggsbenjamin/square_enix/internal/app/workers"
+)
+
+type WorkersHandler struct {
+	registry workers.Registry
+}
+
+func NewWorkersHandler(registry workers.Registry) *WorkersHandler {
+	return &WorkersHandler{
+		registry: registry,
+	}
+}
+
+// Handle lists the workers of every instance, only those with the status given by the status query param if
+// it's given.
+func (s *WorkersHandler) Handle(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	all, err := s.registry.GetWorkers(req.Context())
+	if err != nil {
+		log.Printf("error retrieving workers: %q", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"message":"internal server error"}`))
+		return
+	}
+
+	status := models.WorkerStatus(strings.ToUpper(req.URL.Query().Get("status")))
+	if status == "" {
+		writeJSON(w, http.StatusOK, all)
+		return
+	}
+
+	matched := []models.Worker{}
+	for _, worker := range all {
+		if worker.Status == status {
+			matched = append(matched, worker)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, matched)
+}
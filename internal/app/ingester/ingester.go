@@ -0,0 +1,344 @@
+//go:generate mockgen -package ingester -source=ingester.go -destination ./mocks/ingester.go
+
+package ingester
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	"github.com/eggsbenjamin/square_enix/internal/app/models"
+	"github.com/eggsbenjamin/square_enix/internal/app/repository"
+)
+
+const (
+	FORMAT_NDJSON = "ndjson"
+	FORMAT_CSV    = "csv"
+
+	// MaxDataLength is the length of the Element.data VARCHAR column.
+	MaxDataLength = 50
+
+	// MaxTagLength is the length of the ElementTag.tag VARCHAR column.
+	MaxTagLength = 50
+
+	// csvTagSeparator separates the tags within the tags column of a csv upload.
+	csvTagSeparator = ";"
+
+	// chunkSize is the number of valid rows buffered from a stream before they are inserted.
+	chunkSize = 500
+
+	maxLineLength = 1024 * 1024
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported format")
+
+// Rejection describes an input row that was not inserted. Rows are numbered from 1.
+type Rejection struct {
+	Row    int    `json:"row"`
+	Reason string `json:"reason"`
+}
+
+type Result struct {
+	IDs        []int       `json:"ids"`
+	Inserted   int         `json:"inserted"`
+	Rejected   int         `json:"rejected"`
+	Rejections []Rejection `json:"rejections"`
+}
+
+// Input is a single element as submitted for ingestion, with the tags processes can select it by.
+type Input struct {
+	Data *string  `json:"data"`
+	Tags []string `json:"tags"`
+}
+
+type Ingester interface {
+	Ingest(inputs []Input) (Result, error)
+	IngestContext(ctx context.Context, inputs []Input) (Result, error)
+	IngestStream(r io.Reader, format string) (Result, error)
+	IngestStreamContext(ctx context.Context, r io.Reader, format string) (Result, error)
+}
+
+type ingester struct {
+	db                 db.DB
+	elementRepoFactory repository.ElementRepositoryFactory
+}
+
+func NewIngester(
+	db db.DB,
+	elementRepoFactory repository.ElementRepositoryFactory,
+) Ingester {
+	return &ingester{
+		db:                 db,
+		elementRepoFactory: elementRepoFactory,
+	}
+}
+
+// batch accumulates valid rows and inserts them a chunk at a time.
+type batch struct {
+	insert  func(elements []models.Element) ([]int, error)
+	pending []models.Element
+	result  Result
+}
+
+func newBatch(insert func(elements []models.Element) ([]int, error)) *batch {
+	return &batch{
+		insert: insert,
+		result: Result{
+			IDs:        []int{},
+			Rejections: []Rejection{},
+		},
+	}
+}
+
+func (b *batch) reject(row int, reason string) {
+	b.result.Rejected++
+	b.result.Rejections = append(b.result.Rejections, Rejection{Row: row, Reason: reason})
+}
+
+func (b *batch) add(row int, input Input) error {
+	if input.Data == nil {
+		b.reject(row, "data is required")
+		return nil
+	}
+
+	if n := utf8.RuneCountInString(*input.Data); n > MaxDataLength {
+		b.reject(row, fmt.Sprintf("data is %d characters, maximum is %d", n, MaxDataLength))
+		return nil
+	}
+
+	tags := []string{}
+	seen := map[string]bool{}
+	for _, tag := range input.Tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+
+		if n := utf8.RuneCountInString(tag); n > MaxTagLength {
+			b.reject(row, fmt.Sprintf("tag is %d characters, maximum is %d", n, MaxTagLength))
+			return nil
+		}
+
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	b.pending = append(b.pending, models.Element{Data: *input.Data, Tags: tags})
+	if len(b.pending) >= chunkSize {
+		return b.flush()
+	}
+
+	return nil
+}
+
+func (b *batch) flush() error {
+	if len(b.pending) == 0 {
+		return nil
+	}
+
+	ids, err := b.insert(b.pending)
+	if err != nil {
+		return errors.Wrap(err, "error inserting elements")
+	}
+
+	b.result.IDs = append(b.result.IDs, ids...)
+	b.result.Inserted += len(ids)
+	b.pending = b.pending[:0]
+
+	return nil
+}
+
+// run executes fn against a new batch inside a transaction, committing only if fn and the final flush succeed.
+func (i *ingester) run(ctx context.Context, fn func(b *batch) error) (Result, error) {
+	tx, err := i.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Result{}, errors.Wrap(err, "error beginning transaction")
+	}
+
+	repo := i.elementRepoFactory.CreateElementRepository(tx)
+	b := newBatch(func(elements []models.Element) ([]int, error) {
+		return repo.InsertElements(ctx, elements)
+	})
+
+	if err := fn(b); err == nil {
+		err = b.flush()
+	}
+	if err != nil {
+		db.Rollback(tx)
+
+		return Result{}, err
+	}
+
+	return b.result, tx.Commit()
+}
+
+// runChunked executes fn against a new batch that commits each chunk in a transaction of its own. If fn or a
+// flush fails, the result of the chunks already committed is returned with the error.
+func (i *ingester) runChunked(ctx context.Context, fn func(b *batch) error) (Result, error) {
+	b := newBatch(func(elements []models.Element) ([]int, error) {
+		tx, err := i.db.BeginTxx(ctx, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "error beginning transaction")
+		}
+
+		ids, err := i.elementRepoFactory.CreateElementRepository(tx).InsertElements(ctx, elements)
+		if err != nil {
+			db.Rollback(tx)
+
+			return nil, err
+		}
+
+		return ids, tx.Commit()
+	})
+
+	err := fn(b)
+	if err == nil {
+		err = b.flush()
+	}
+
+	return b.result, err
+}
+
+func (i *ingester) Ingest(inputs []Input) (Result, error) {
+	return i.IngestContext(context.Background(), inputs)
+}
+
+func (i *ingester) IngestContext(ctx context.Context, inputs []Input) (Result, error) {
+	return i.run(ctx, func(b *batch) error {
+		for row, input := range inputs {
+			if err := b.add(row+1, input); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (i *ingester) IngestStream(r io.Reader, format string) (Result, error) {
+	return i.IngestStreamContext(context.Background(), r, format)
+}
+
+// IngestStreamContext reads elements from r in the given format without buffering the whole upload, committing
+// each chunk as it's inserted. An upload that fails part way returns the chunks committed with the error.
+//   - ndjson: one `{"data": "...", "tags": [...]}` object per line, blank lines are skipped
+//   - csv: data is read from the first column, or from the "data" column when a header row is present. A
+//     header row may also name a "tags" column of semicolon separated tags
+func (i *ingester) IngestStreamContext(ctx context.Context, r io.Reader, format string) (Result, error) {
+	switch format {
+	case FORMAT_NDJSON:
+		return i.runChunked(ctx, func(b *batch) error {
+			return readNDJSON(r, b)
+		})
+	case FORMAT_CSV:
+		return i.runChunked(ctx, func(b *batch) error {
+			return readCSV(r, b)
+		})
+	default:
+		return Result{}, errors.Wrap(ErrUnsupportedFormat, format)
+	}
+}
+
+func readNDJSON(r io.Reader, b *batch) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineLength)
+
+	row := 0
+	for scanner.Scan() {
+		row++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var input Input
+		if err := json.Unmarshal([]byte(line), &input); err != nil {
+			b.reject(row, fmt.Sprintf("invalid json: %s", err))
+			continue
+		}
+
+		if err := b.add(row, input); err != nil {
+			return err
+		}
+	}
+
+	return errors.Wrap(scanner.Err(), "error reading ndjson")
+}
+
+func readCSV(r io.Reader, b *batch) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	columns := csvColumns{data: 0, tags: -1}
+	row := 0
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+
+		row++
+
+		if err != nil {
+			if parseErr, ok := err.(*csv.ParseError); ok {
+				b.reject(row, parseErr.Error())
+				continue
+			}
+			return errors.Wrap(err, "error reading csv")
+		}
+
+		if row == 1 {
+			if header, ok := headerColumns(record); ok {
+				columns = header
+				continue
+			}
+		}
+
+		if columns.data >= len(record) {
+			b.reject(row, "data column missing")
+			continue
+		}
+
+		data := record[columns.data]
+		input := Input{Data: &data}
+		if columns.tags >= 0 && columns.tags < len(record) && record[columns.tags] != "" {
+			input.Tags = strings.Split(record[columns.tags], csvTagSeparator)
+		}
+
+		if err := b.add(row, input); err != nil {
+			return err
+		}
+	}
+}
+
+// csvColumns are the indexes of the columns read from a csv upload, -1 when a column is absent.
+type csvColumns struct {
+	data int
+	tags int
+}
+
+// headerColumns returns the indexes of the "data" and "tags" columns if record is a header row, i.e. it
+// has a "data" column.
+func headerColumns(record []string) (csvColumns, bool) {
+	columns := csvColumns{data: -1, tags: -1}
+	for i, field := range record {
+		switch strings.ToLower(strings.TrimSpace(field)) {
+		case "data":
+			columns.data = i
+		case "tags":
+			columns.tags = i
+		}
+	}
+
+	return columns, columns.data >= 0
+}
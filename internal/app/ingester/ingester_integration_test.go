@@ -0,0 +1,175 @@
+// +build integration
+
+package ingester_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	"github.com/eggsbenjamin/square_enix/internal/app/db/dbtest"
+	"github.com/eggsbenjamin/square_enix/internal/app/ingester"
+	"github.com/eggsbenjamin/square_enix/internal/app/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngester(t *testing.T) {
+	conn, err := dbtest.Connect()
+	require.NoError(t, err)
+
+	ing := ingester.NewIngester(
+		db.NewDB(conn.DB),
+		repository.NewElementRepositoryFactory(),
+	)
+
+	tooLong := strings.Repeat("x", ingester.MaxDataLength+1)
+
+	t.Run("Ingest", func(t *testing.T) {
+		defer func() {
+			if err := ResetDB(conn); err != nil {
+				t.Logf("error resetting Element table: %q\n", err)
+			}
+		}()
+
+		require.NoError(t, ResetDB(conn))
+
+		valid := "test"
+		result, err := ing.Ingest([]ingester.Input{{Data: &valid}, {Data: &tooLong}, {}})
+		require.NoError(t, err)
+		require.Equal(t, 1, result.Inserted)
+		require.Equal(t, 1, len(result.IDs))
+		require.Equal(t, 2, result.Rejected)
+		require.Equal(t, 2, result.Rejections[0].Row)
+		require.Equal(t, 3, result.Rejections[1].Row)
+	})
+
+	t.Run("IngestStream NDJSON", func(t *testing.T) {
+		defer func() {
+			if err := ResetDB(conn); err != nil {
+				t.Logf("error resetting Element table: %q\n", err)
+			}
+		}()
+
+		require.NoError(t, ResetDB(conn))
+
+		body := `{"data": "one"}
+{"data": "two"}
+
+not json
+{"data": "` + tooLong + `"}
+`
+		result, err := ing.IngestStream(strings.NewReader(body), ingester.FORMAT_NDJSON)
+		require.NoError(t, err)
+		require.Equal(t, 2, result.Inserted)
+		require.Equal(t, 2, result.Rejected)
+		require.Equal(t, 4, result.Rejections[0].Row)
+		require.Equal(t, 5, result.Rejections[1].Row)
+
+		var count int
+		require.NoError(t, conn.Get(&count, "SELECT COUNT(*) FROM Element"))
+		require.Equal(t, 2, count)
+	})
+
+	t.Run("IngestStream CSV", func(t *testing.T) {
+		defer func() {
+			if err := ResetDB(conn); err != nil {
+				t.Logf("error resetting Element table: %q\n", err)
+			}
+		}()
+
+		require.NoError(t, ResetDB(conn))
+
+		body := "id,data\n1,one\n2,two\n3," + tooLong + "\n4\n"
+		result, err := ing.IngestStream(strings.NewReader(body), ingester.FORMAT_CSV)
+		require.NoError(t, err)
+		require.Equal(t, 2, result.Inserted)
+		require.Equal(t, 2, result.Rejected)
+
+		var data []string
+		require.NoError(t, conn.Select(&data, "SELECT data FROM Element ORDER BY id"))
+		require.Equal(t, []string{"one", "two"}, data)
+	})
+
+	t.Run("IngestStream CSV Tags", func(t *testing.T) {
+		defer func() {
+			if err := ResetDB(conn); err != nil {
+				t.Logf("error resetting Element table: %q\n", err)
+			}
+		}()
+
+		require.NoError(t, ResetDB(conn))
+
+		body := "tags,data\nred;fruit;red,apple\n,plain\n"
+		result, err := ing.IngestStream(strings.NewReader(body), ingester.FORMAT_CSV)
+		require.NoError(t, err)
+		require.Equal(t, 2, result.Inserted)
+
+		var tags []string
+		require.NoError(t, conn.Select(&tags, "SELECT tag FROM ElementTag WHERE element_id = ? ORDER BY tag", result.IDs[0]))
+		require.Equal(t, []string{"fruit", "red"}, tags)
+
+		var count int
+		require.NoError(t, conn.Get(&count, "SELECT COUNT(*) FROM ElementTag WHERE element_id = ?", result.IDs[1]))
+		require.Equal(t, 0, count)
+	})
+
+	t.Run("IngestStream Commits Chunks", func(t *testing.T) {
+		defer func() {
+			if err := ResetDB(conn); err != nil {
+				t.Logf("error resetting Element table: %q\n", err)
+			}
+		}()
+
+		require.NoError(t, ResetDB(conn))
+
+		// the upload fails after a chunk and a half
+		var body strings.Builder
+		for i := 0; i < 750; i++ {
+			fmt.Fprintf(&body, "{\"data\": \"%d\"}\n", i)
+		}
+		r := io.MultiReader(strings.NewReader(body.String()), &failingReader{})
+
+		result, err := ing.IngestStream(r, ingester.FORMAT_NDJSON)
+		require.Error(t, err)
+		require.Equal(t, 500, result.Inserted)
+		require.Len(t, result.IDs, 500)
+
+		var count int
+		require.NoError(t, conn.Get(&count, "SELECT COUNT(*) FROM Element"))
+		require.Equal(t, 500, count)
+	})
+
+	t.Run("IngestStream Unsupported Format", func(t *testing.T) {
+		_, err := ing.IngestStream(strings.NewReader(""), "xml")
+		require.Error(t, err)
+	})
+}
+
+type failingReader struct{}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func ResetDB(conn *dbtest.Conn) error {
+	if _, err := conn.Exec("DELETE FROM ProcessElementError"); err != nil {
+		return err
+	}
+
+	if _, err := conn.Exec("DELETE FROM ProcessElement"); err != nil {
+		return err
+	}
+
+	if _, err := conn.Exec("DELETE FROM ElementTag"); err != nil {
+		return err
+	}
+
+	if _, err := conn.Exec("DELETE FROM Element"); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ingester.go
+
+// Package ingester is a generated GoMock package.
+package ingester
+
+import (
+	context "context"
+	ingester "github.com/eggsbenjamin/square_enix/internal/app/ingester"
+	gomock "github.com/golang/mock/gomock"
+	io "io"
+	reflect "reflect"
+)
+
+// MockIngester is a mock of Ingester interface
+type MockIngester struct {
+	ctrl     *gomock.Controller
+	recorder *MockIngesterMockRecorder
+}
+
+// MockIngesterMockRecorder is the mock recorder for MockIngester
+type MockIngesterMockRecorder struct {
+	mock *MockIngester
+}
+
+// NewMockIngester creates a new mock instance
+func NewMockIngester(ctrl *gomock.Controller) *MockIngester {
+	mock := &MockIngester{ctrl: ctrl}
+	mock.recorder = &MockIngesterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockIngester) EXPECT() *MockIngesterMockRecorder {
+	return m.recorder
+}
+
+// Ingest mocks base method
+func (m *MockIngester) Ingest(inputs []ingester.Input) (ingester.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ingest", inputs)
+	ret0, _ := ret[0].(ingester.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Ingest indicates an expected call of Ingest
+func (mr *MockIngesterMockRecorder) Ingest(inputs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ingest", reflect.TypeOf((*MockIngester)(nil).Ingest), inputs)
+}
+
+// IngestContext mocks base method
+func (m *MockIngester) IngestContext(ctx context.Context, inputs []ingester.Input) (ingester.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IngestContext", ctx, inputs)
+	ret0, _ := ret[0].(ingester.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IngestContext indicates an expected call of IngestContext
+func (mr *MockIngesterMockRecorder) IngestContext(ctx, inputs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IngestContext", reflect.TypeOf((*MockIngester)(nil).IngestContext), ctx, inputs)
+}
+
+// IngestStream mocks base method
+func (m *MockIngester) IngestStream(r io.Reader, format string) (ingester.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IngestStream", r, format)
+	ret0, _ := ret[0].(ingester.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IngestStream indicates an expected call of IngestStream
+func (mr *MockIngesterMockRecorder) IngestStream(r, format interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IngestStream", reflect.TypeOf((*MockIngester)(nil).IngestStream), r, format)
+}
+
+// IngestStreamContext mocks base method
+func (m *MockIngester) IngestStreamContext(ctx context.Context, r io.Reader, format string) (ingester.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IngestStreamContext", ctx, r, format)
+	ret0, _ := ret[0].(ingester.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IngestStreamContext indicates an expected call of IngestStreamContext
+func (mr *MockIngesterMockRecorder) IngestStreamContext(ctx, r, format interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IngestStreamContext", reflect.TypeOf((*MockIngester)(nil).IngestStreamContext), ctx, r, format)
+}
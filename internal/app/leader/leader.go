@@ -0,0 +1,146 @@
+//go:generate mockgen -package leader -source=leader.go -destination ./mocks/leader.go
+
+package leader
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	"github.com/eggsbenjamin/square_enix/internal/app/repository"
+)
+
+// WORKER_LEASE names the lease held by the leader of the workers, which runs the checks that complete processes.
+const WORKER_LEASE = "worker-leader"
+
+// Elector elects one of the instances campaigning for a lease as their leader.
+type Elector interface {
+	// IsLeader reports whether the instance holds the lease.
+	IsLeader() bool
+
+	// Run campaigns for the lease, renewing it while it's held, until ctx is done. The lease is then released.
+	Run(ctx context.Context)
+}
+
+type elector struct {
+	db               db.DB
+	leaseRepoFactory repository.LeaseRepositoryFactory
+	name             string
+	holder           string
+	ttl              time.Duration
+
+	mu sync.Mutex
+	// leadingUntil is when the instance stops considering itself the leader unless the lease is renewed
+	leadingUntil time.Time
+}
+
+// NewElector returns an elector campaigning for the lease named name as holder, which must identify the
+// instance uniquely. The lease lasts ttl and is renewed every third of it.
+func NewElector(
+	db db.DB,
+	leaseRepoFactory repository.LeaseRepositoryFactory,
+	name string,
+	holder string,
+	ttl time.Duration,
+) Elector {
+	return &elector{
+		db:               db,
+		leaseRepoFactory: leaseRepoFactory,
+		name:             name,
+		holder:           holder,
+		ttl:              ttl,
+	}
+}
+
+// IsLeader measures the lease from before it was last acquired, by the instance's own clock, so the instance
+// stops leading before any other instance can take the lease over.
+func (e *elector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return time.Now().Before(e.leadingUntil)
+}
+
+func (e *elector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		e.campaign(ctx)
+
+		select {
+		case <-ctx.Done():
+			e.release()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// campaign acquires or renews the lease. An instance failing to renew it carries on leading until the lease
+// it holds expires.
+func (e *elector) campaign(ctx context.Context) {
+	start := time.Now()
+
+	_, acquired, err := e.leaseRepoFactory.CreateLeaseRepository(e.db).AcquireLease(ctx, e.name, e.holder, e.ttl)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("error acquiring %s lease: %q\n", e.name, err)
+		}
+		return
+	}
+
+	wasLeader := e.IsLeader()
+
+	e.mu.Lock()
+	if acquired {
+		e.leadingUntil = start.Add(e.ttl)
+	} else {
+		e.leadingUntil = time.Time{}
+	}
+	e.mu.Unlock()
+
+	if acquired && !wasLeader {
+		log.Printf("acquired %s lease as %s\n", e.name, e.holder)
+	} else if !acquired && wasLeader {
+		log.Printf("lost %s lease\n", e.name)
+	}
+}
+
+// release gives up the lease, if it's held, so another instance can take over without waiting for it to expire.
+func (e *elector) release() {
+	if !e.IsLeader() {
+		return
+	}
+
+	e.mu.Lock()
+	e.leadingUntil = time.Time{}
+	e.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.ttl)
+	defer cancel()
+
+	if err := e.leaseRepoFactory.CreateLeaseRepository(e.db).ReleaseLease(ctx, e.name, e.holder); err != nil {
+		log.Printf("error releasing %s lease: %q\n", e.name, err)
+		return
+	}
+
+	log.Printf("released %s lease\n", e.name)
+}
+
+type soleElector struct{}
+
+// NewSoleElector returns an elector for an instance that runs alone, such as in tests, which is always the leader.
+func NewSoleElector() Elector {
+	return soleElector{}
+}
+
+func (soleElector) IsLeader() bool {
+	return true
+}
+
+func (soleElector) Run(ctx context.Context) {
+	<-ctx.Done()
+}
@@ -0,0 +1,81 @@
+// +build unit
+
+package leader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/repository/memory"
+)
+
+func TestElector(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("One Leader", func(t *testing.T) {
+		db := memory.NewDB()
+		a := NewElector(db, memory.NewLeaseRepositoryFactory(), WORKER_LEASE, "a", time.Minute).(*elector)
+		b := NewElector(db, memory.NewLeaseRepositoryFactory(), WORKER_LEASE, "b", time.Minute).(*elector)
+
+		a.campaign(ctx)
+		b.campaign(ctx)
+		require.True(t, a.IsLeader())
+		require.False(t, b.IsLeader())
+
+		// renewing
+		a.campaign(ctx)
+		require.True(t, a.IsLeader())
+
+		a.release()
+		require.False(t, a.IsLeader())
+
+		b.campaign(ctx)
+		a.campaign(ctx)
+		require.True(t, b.IsLeader())
+		require.False(t, a.IsLeader())
+	})
+
+	t.Run("Lease Expires", func(t *testing.T) {
+		db := memory.NewDB()
+		a := NewElector(db, memory.NewLeaseRepositoryFactory(), WORKER_LEASE, "a", 50*time.Millisecond).(*elector)
+		b := NewElector(db, memory.NewLeaseRepositoryFactory(), WORKER_LEASE, "b", 50*time.Millisecond).(*elector)
+
+		a.campaign(ctx)
+		require.True(t, a.IsLeader())
+
+		time.Sleep(60 * time.Millisecond)
+		require.False(t, a.IsLeader())
+
+		b.campaign(ctx)
+		require.True(t, b.IsLeader())
+
+		a.campaign(ctx)
+		require.False(t, a.IsLeader())
+	})
+
+	t.Run("Run", func(t *testing.T) {
+		db := memory.NewDB()
+		a := NewElector(db, memory.NewLeaseRepositoryFactory(), WORKER_LEASE, "a", time.Minute)
+		b := NewElector(db, memory.NewLeaseRepositoryFactory(), WORKER_LEASE, "b", time.Minute).(*elector)
+
+		runCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			a.Run(runCtx)
+		}()
+
+		require.Eventually(t, a.IsLeader, time.Second, time.Millisecond)
+
+		// the lease is released once a stops running
+		cancel()
+		<-done
+		require.False(t, a.IsLeader())
+
+		b.campaign(ctx)
+		require.True(t, b.IsLeader())
+	})
+}
@@ -0,0 +1,60 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: leader.go
+
+// Package leader is a generated GoMock package.
+package leader
+
+import (
+	context "context"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// MockElector is a mock of Elector interface
+type MockElector struct {
+	ctrl     *gomock.Controller
+	recorder *MockElectorMockRecorder
+}
+
+// MockElectorMockRecorder is the mock recorder for MockElector
+type MockElectorMockRecorder struct {
+	mock *MockElector
+}
+
+// NewMockElector creates a new mock instance
+func NewMockElector(ctrl *gomock.Controller) *MockElector {
+	mock := &MockElector{ctrl: ctrl}
+	mock.recorder = &MockElectorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockElector) EXPECT() *MockElectorMockRecorder {
+	return m.recorder
+}
+
+// IsLeader mocks base method
+func (m *MockElector) IsLeader() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsLeader")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsLeader indicates an expected call of IsLeader
+func (mr *MockElectorMockRecorder) IsLeader() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsLeader", reflect.TypeOf((*MockElector)(nil).IsLeader))
+}
+
+// Run mocks base method
+func (m *MockElector) Run(ctx context.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Run", ctx)
+}
+
+// Run indicates an expected call of Run
+func (mr *MockElectorMockRecorder) Run(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockElector)(nil).Run), ctx)
+}
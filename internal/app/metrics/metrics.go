@@ -0,0 +1,219 @@
+//go:generate mockgen -package metrics -source=metrics.go -destination ./mocks/metrics.go
+
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	namespace = "square_enix"
+
+	RESULT_PROCESSED     = "processed"
+	RESULT_FAILED        = "failed"
+	RESULT_DEAD_LETTERED = "dead_lettered"
+	RESULT_REVERTED      = "reverted"
+
+	TX_COMMIT   = "commit"
+	TX_ROLLBACK = "rollback"
+)
+
+// Recorder records processing and http metrics.
+type Recorder interface {
+	ElementsProcessed(processID int, result string, count int)
+	Batch(size int, duration time.Duration)
+	LockWait(duration time.Duration)
+	Transaction(outcome string)
+	PollIteration()
+	HTTPRequest(method, route string, status int, duration time.Duration)
+}
+
+type prometheusRecorder struct {
+	elementsProcessed *prometheus.CounterVec
+	batchDuration     prometheus.Histogram
+	batchSize         prometheus.Histogram
+	lockWait          prometheus.Histogram
+	transactions      *prometheus.CounterVec
+	pollIterations    prometheus.Counter
+	httpDuration      *prometheus.HistogramVec
+}
+
+// NewPrometheusRecorder returns a Recorder whose metrics are registered with reg.
+func NewPrometheusRecorder(reg prometheus.Registerer) Recorder {
+	r := &prometheusRecorder{
+		elementsProcessed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "elements_processed_total",
+			Help:      "Elements processed, by process and result.",
+		}, []string{"process_id", "result"}),
+		batchDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "batch_duration_seconds",
+			Help:      "Time taken to process and commit a batch.",
+			Buckets:   prometheus.ExponentialBuckets(0.005, 2, 12),
+		}),
+		batchSize: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "batch_size",
+			Help:      "Number of elements claimed per batch.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+		}),
+		lockWait: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "lock_wait_seconds",
+			Help:      "Time taken to claim and lock a batch of elements.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 12),
+		}),
+		transactions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "transactions_total",
+			Help:      "Processor transactions, by outcome.",
+		}, []string{"outcome"}),
+		pollIterations: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "poll_iterations_total",
+			Help:      "Iterations of the batch workers' poll loop.",
+		}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency, by method, route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+	}
+
+	reg.MustRegister(
+		r.elementsProcessed,
+		r.batchDuration,
+		r.batchSize,
+		r.lockWait,
+		r.transactions,
+		r.pollIterations,
+		r.httpDuration,
+	)
+
+	return r
+}
+
+func (r *prometheusRecorder) ElementsProcessed(processID int, result string, count int) {
+	r.elementsProcessed.WithLabelValues(strconv.Itoa(processID), result).Add(float64(count))
+}
+
+func (r *prometheusRecorder) Batch(size int, duration time.Duration) {
+	r.batchSize.Observe(float64(size))
+	r.batchDuration.Observe(duration.Seconds())
+}
+
+func (r *prometheusRecorder) LockWait(duration time.Duration) {
+	r.lockWait.Observe(duration.Seconds())
+}
+
+func (r *prometheusRecorder) Transaction(outcome string) {
+	r.transactions.WithLabelValues(outcome).Inc()
+}
+
+func (r *prometheusRecorder) PollIteration() {
+	r.pollIterations.Inc()
+}
+
+func (r *prometheusRecorder) HTTPRequest(method, route string, status int, duration time.Duration) {
+	r.httpDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+type nopRecorder struct{}
+
+// NewNopRecorder returns a Recorder that discards everything it's given.
+func NewNopRecorder() Recorder {
+	return nopRecorder{}
+}
+
+func (nopRecorder) ElementsProcessed(processID int, result string, count int)            {}
+func (nopRecorder) Batch(size int, duration time.Duration)                               {}
+func (nopRecorder) LockWait(duration time.Duration)                                      {}
+func (nopRecorder) Transaction(outcome string)                                           {}
+func (nopRecorder) PollIteration()                                                       {}
+func (nopRecorder) HTTPRequest(method, route string, status int, duration time.Duration) {}
+
+// RegisterDBStats registers gauges reporting the connection pool stats returned by stats, typically sqlx.DB.Stats.
+func RegisterDBStats(reg prometheus.Registerer, stats func() sql.DBStats) {
+	gauge := func(name, help string, value func(s sql.DBStats) float64) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "db",
+			Name:      name,
+			Help:      help,
+		}, func() float64 {
+			return value(stats())
+		})
+	}
+
+	counter := func(name, help string, value func(s sql.DBStats) float64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "db",
+			Name:      name,
+			Help:      help,
+		}, func() float64 {
+			return value(stats())
+		})
+	}
+
+	reg.MustRegister(
+		gauge("max_open_connections", "Maximum number of open connections to the db.", func(s sql.DBStats) float64 {
+			return float64(s.MaxOpenConnections)
+		}),
+		gauge("open_connections", "Number of open connections, in use and idle.", func(s sql.DBStats) float64 {
+			return float64(s.OpenConnections)
+		}),
+		gauge("in_use_connections", "Number of connections in use.", func(s sql.DBStats) float64 {
+			return float64(s.InUse)
+		}),
+		gauge("idle_connections", "Number of idle connections.", func(s sql.DBStats) float64 {
+			return float64(s.Idle)
+		}),
+		counter("wait_count_total", "Number of connections waited for.", func(s sql.DBStats) float64 {
+			return float64(s.WaitCount)
+		}),
+		counter("wait_duration_seconds_total", "Time spent waiting for connections.", func(s sql.DBStats) float64 {
+			return s.WaitDuration.Seconds()
+		}),
+		counter("max_idle_closed_total", "Connections closed due to the idle connection limit.", func(s sql.DBStats) float64 {
+			return float64(s.MaxIdleClosed)
+		}),
+		counter("max_lifetime_closed_total", "Connections closed due to the connection lifetime limit.", func(s sql.DBStats) float64 {
+			return float64(s.MaxLifetimeClosed)
+		}),
+	)
+}
+
+// Middleware records the latency of each request against the pattern of the chi route that served it, so that
+// requests for different ids share a series. Unrouted requests are recorded against the route "unmatched".
+func Middleware(recorder Recorder) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, req.ProtoMajor)
+
+			next.ServeHTTP(ww, req)
+
+			route := "unmatched"
+			if rctx := chi.RouteContext(req.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			recorder.HTTPRequest(req.Method, route, status, time.Since(start))
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// +build unit
+
+package metrics_test
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/metrics"
+	mock_metrics "github.com/eggsbenjamin/square_enix/internal/app/metrics/mocks"
+)
+
+func TestMiddleware(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	recorder := mock_metrics.NewMockRecorder(ctrl)
+
+	mux := chi.NewRouter()
+	mux.Use(metrics.Middleware(recorder))
+	mux.Route("/process", func(r chi.Router) {
+		r.Get("/{id}/stat", func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+	})
+
+	t.Run("Route Pattern", func(t *testing.T) {
+		recorder.EXPECT().HTTPRequest(http.MethodGet, "/process/{id}/stat", http.StatusNotFound, gomock.Any())
+
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/process/1/stat", nil))
+	})
+
+	t.Run("Unmatched", func(t *testing.T) {
+		recorder.EXPECT().HTTPRequest(http.MethodGet, "unmatched", http.StatusNotFound, gomock.Any())
+
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/unknown", nil))
+	})
+}
+
+func TestPrometheusRecorder(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	recorder := metrics.NewPrometheusRecorder(reg)
+
+	recorder.ElementsProcessed(1, metrics.RESULT_PROCESSED, 3)
+	recorder.ElementsProcessed(1, metrics.RESULT_PROCESSED, 2)
+	recorder.ElementsProcessed(1, metrics.RESULT_FAILED, 1)
+	recorder.Transaction(metrics.TX_COMMIT)
+	recorder.Batch(5, time.Second)
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	values := map[string]float64{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			switch {
+			case metric.GetCounter() != nil:
+				key := family.GetName()
+				for _, label := range metric.GetLabel() {
+					key += "," + label.GetValue()
+				}
+				values[key] = metric.GetCounter().GetValue()
+			case metric.GetHistogram() != nil:
+				values[family.GetName()] = float64(metric.GetHistogram().GetSampleCount())
+			}
+		}
+	}
+
+	require.Equal(t, 5.0, values["square_enix_elements_processed_total,1,processed"])
+	require.Equal(t, 1.0, values["square_enix_elements_processed_total,1,failed"])
+	require.Equal(t, 1.0, values["square_enix_transactions_total,commit"])
+	require.Equal(t, 1.0, values["square_enix_batch_size"])
+	require.Equal(t, 1.0, values["square_enix_batch_duration_seconds"])
+}
+
+func TestRegisterDBStats(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	metrics.RegisterDBStats(reg, func() sql.DBStats {
+		return sql.DBStats{OpenConnections: 3, InUse: 2, Idle: 1}
+	})
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	require.Equal(t, 8, len(families))
+
+	for _, family := range families {
+		if family.GetName() == "square_enix_db_in_use_connections" {
+			require.Equal(t, 2.0, family.GetMetric()[0].GetGauge().GetValue())
+		}
+	}
+}
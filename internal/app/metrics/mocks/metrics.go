@@ -0,0 +1,106 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: metrics.go
+
+// Package metrics is a generated GoMock package.
+package metrics
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+	time "time"
+)
+
+// MockRecorder is a mock of Recorder interface
+type MockRecorder struct {
+	ctrl     *gomock.Controller
+	recorder *MockRecorderMockRecorder
+}
+
+// MockRecorderMockRecorder is the mock recorder for MockRecorder
+type MockRecorderMockRecorder struct {
+	mock *MockRecorder
+}
+
+// NewMockRecorder creates a new mock instance
+func NewMockRecorder(ctrl *gomock.Controller) *MockRecorder {
+	mock := &MockRecorder{ctrl: ctrl}
+	mock.recorder = &MockRecorderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockRecorder) EXPECT() *MockRecorderMockRecorder {
+	return m.recorder
+}
+
+// ElementsProcessed mocks base method
+func (m *MockRecorder) ElementsProcessed(processID int, result string, count int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ElementsProcessed", processID, result, count)
+}
+
+// ElementsProcessed indicates an expected call of ElementsProcessed
+func (mr *MockRecorderMockRecorder) ElementsProcessed(processID, result, count interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ElementsProcessed", reflect.TypeOf((*MockRecorder)(nil).ElementsProcessed), processID, result, count)
+}
+
+// Batch mocks base method
+func (m *MockRecorder) Batch(size int, duration time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Batch", size, duration)
+}
+
+// Batch indicates an expected call of Batch
+func (mr *MockRecorderMockRecorder) Batch(size, duration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Batch", reflect.TypeOf((*MockRecorder)(nil).Batch), size, duration)
+}
+
+// LockWait mocks base method
+func (m *MockRecorder) LockWait(duration time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "LockWait", duration)
+}
+
+// LockWait indicates an expected call of LockWait
+func (mr *MockRecorderMockRecorder) LockWait(duration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockWait", reflect.TypeOf((*MockRecorder)(nil).LockWait), duration)
+}
+
+// Transaction mocks base method
+func (m *MockRecorder) Transaction(outcome string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Transaction", outcome)
+}
+
+// Transaction indicates an expected call of Transaction
+func (mr *MockRecorderMockRecorder) Transaction(outcome interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transaction", reflect.TypeOf((*MockRecorder)(nil).Transaction), outcome)
+}
+
+// PollIteration mocks base method
+func (m *MockRecorder) PollIteration() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "PollIteration")
+}
+
+// PollIteration indicates an expected call of PollIteration
+func (mr *MockRecorderMockRecorder) PollIteration() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PollIteration", reflect.TypeOf((*MockRecorder)(nil).PollIteration))
+}
+
+// HTTPRequest mocks base method
+func (m *MockRecorder) HTTPRequest(method, route string, status int, duration time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "HTTPRequest", method, route, status, duration)
+}
+
+// HTTPRequest indicates an expected call of HTTPRequest
+func (mr *MockRecorderMockRecorder) HTTPRequest(method, route, status, duration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HTTPRequest", reflect.TypeOf((*MockRecorder)(nil).HTTPRequest), method, route, status, duration)
+}
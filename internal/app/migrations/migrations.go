@@ -0,0 +1,368 @@
+//go:generate mockgen -package migrations -source=migrations.go -destination ./mocks/migrations.go
+
+// Package migrations versions the db schema. Migrations are embedded in the binary from a directory of the sql
+// directory per driver, as pairs of <version>_<name>.up.sql and <version>_<name>.down.sql files, and are applied
+// in version order. The versions applied are recorded in the schema_migrations table.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/db"
+)
+
+const (
+	// lockName names the session level lock held while migrating, so concurrent migrators apply each
+	// migration once.
+	lockName    = "square_enix_schema_migrations"
+	lockTimeout = 30 * time.Second
+)
+
+var (
+	ErrSchemaBehind = errors.New("schema is behind")
+	ErrDirty        = errors.New("schema is dirty")
+)
+
+//go:embed sql
+var files embed.FS
+
+var (
+	fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+	// statementTerminator ends each statement of a migration, a semicolon at the end of a line.
+	statementTerminator = regexp.MustCompile(`;[ \t]*(\r?\n|$)`)
+)
+
+// Migration is a versioned change to the schema and the change that undoes it.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Load returns the migrations of a driver embedded in the binary in version order.
+func Load(driver string) ([]Migration, error) {
+	if _, err := db.NewDialect(driver); err != nil {
+		return nil, err
+	}
+
+	return load(files, path.Join("sql", driver))
+}
+
+func load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading migrations")
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, errors.Errorf("invalid migration file name: %s", entry.Name())
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil || version < 1 {
+			return nil, errors.Errorf("invalid migration version: %s", entry.Name())
+		}
+
+		contents, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading migration: %s", entry.Name())
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, errors.Errorf("migration %d has conflicting names: %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(contents)
+		} else {
+			migration.Down = string(contents)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if strings.TrimSpace(migration.Up) == "" || strings.TrimSpace(migration.Down) == "" {
+			return nil, errors.Errorf("migration %d_%s must have an up and a down file", migration.Version, migration.Name)
+		}
+
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// statements splits a migration into the statements to be executed one at a time, dropping comment lines.
+func statements(migration string) []string {
+	lines := []string{}
+	for _, line := range strings.Split(migration, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			lines = append(lines, line)
+		}
+	}
+
+	stmts := []string{}
+	for _, stmt := range statementTerminator.Split(strings.Join(lines, "\n"), -1) {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+
+	return stmts
+}
+
+// Migrator applies and rolls back migrations. MySQL commits DDL implicitly so a migration isn't applied in a
+// transaction, on either driver; a migration that fails part way leaves the schema dirty, and it must be
+// repaired by hand before migrating again.
+type Migrator interface {
+	Up(ctx context.Context) (int, error)
+	Down(ctx context.Context, steps int) (int, error)
+	Version(ctx context.Context) (int, error)
+	Latest() int
+	Check(ctx context.Context) error
+}
+
+// execGetter is satisfied by both a connection pool and a single connection.
+type execGetter interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+}
+
+type migrator struct {
+	conn       *sqlx.DB
+	dialect    db.Dialect
+	migrations []Migration
+}
+
+func NewMigrator(conn *sqlx.DB, migrations []Migration) Migrator {
+	return &migrator{
+		conn:       conn,
+		dialect:    db.DialectOf(conn),
+		migrations: migrations,
+	}
+}
+
+// Up applies every migration newer than the schema's version, returning how many were applied.
+func (m *migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.withLock(ctx, func(conn *sqlx.Conn) error {
+		current, err := version(ctx, conn, m.dialect)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version <= current {
+				continue
+			}
+
+			log.Printf("applying migration: %d_%s\n", migration.Version, migration.Name)
+
+			if _, err := conn.ExecContext(
+				ctx,
+				conn.Rebind("INSERT INTO schema_migrations (version, name, dirty) VALUES (?, ?, TRUE)"),
+				migration.Version,
+				migration.Name,
+			); err != nil {
+				return errors.Wrapf(err, "error recording migration: %d", migration.Version)
+			}
+
+			if err := exec(ctx, conn, migration.Up); err != nil {
+				return errors.Wrapf(err, "error applying migration: %d", migration.Version)
+			}
+
+			if _, err := conn.ExecContext(ctx, conn.Rebind("UPDATE schema_migrations SET dirty = FALSE WHERE version = ?"), migration.Version); err != nil {
+				return errors.Wrapf(err, "error recording migration: %d", migration.Version)
+			}
+
+			applied++
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down rolls back up to steps of the most recently applied migrations, returning how many were rolled back.
+func (m *migrator) Down(ctx context.Context, steps int) (int, error) {
+	rolledBack := 0
+	err := m.withLock(ctx, func(conn *sqlx.Conn) error {
+		for ; rolledBack < steps; rolledBack++ {
+			current, err := version(ctx, conn, m.dialect)
+			if err != nil {
+				return err
+			}
+
+			if current == 0 {
+				return nil
+			}
+
+			migration, ok := m.migration(current)
+			if !ok {
+				return errors.Errorf("schema is at version %d which this binary has no migration for", current)
+			}
+
+			log.Printf("rolling back migration: %d_%s\n", migration.Version, migration.Name)
+
+			if _, err := conn.ExecContext(ctx, conn.Rebind("UPDATE schema_migrations SET dirty = TRUE WHERE version = ?"), migration.Version); err != nil {
+				return errors.Wrapf(err, "error recording migration: %d", migration.Version)
+			}
+
+			if err := exec(ctx, conn, migration.Down); err != nil {
+				return errors.Wrapf(err, "error rolling back migration: %d", migration.Version)
+			}
+
+			if _, err := conn.ExecContext(ctx, conn.Rebind("DELETE FROM schema_migrations WHERE version = ?"), migration.Version); err != nil {
+				return errors.Wrapf(err, "error recording migration: %d", migration.Version)
+			}
+		}
+
+		return nil
+	})
+
+	return rolledBack, err
+}
+
+// Version returns the version of the most recently applied migration, 0 if none have been applied.
+func (m *migrator) Version(ctx context.Context) (int, error) {
+	return version(ctx, m.conn, m.dialect)
+}
+
+// Latest returns the version of the newest migration in the binary.
+func (m *migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Check returns an error wrapping ErrSchemaBehind if migrations in the binary haven't been applied, or ErrDirty
+// if a migration failed part way. A schema ahead of the binary is allowed so that older instances keep
+// running while newer ones are rolled out.
+func (m *migrator) Check(ctx context.Context) error {
+	current, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	if latest := m.Latest(); current < latest {
+		return errors.Wrapf(ErrSchemaBehind, "schema is at version %d, latest is %d", current, latest)
+	}
+
+	return nil
+}
+
+func (m *migrator) migration(version int) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+
+	return Migration{}, false
+}
+
+// withLock runs fn on a single connection holding the migration lock, creating the schema_migrations table
+// if it doesn't exist.
+func (m *migrator) withLock(ctx context.Context, fn func(conn *sqlx.Conn) error) error {
+	conn, err := m.conn.Connx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error opening connection")
+	}
+	defer conn.Close()
+
+	if err := m.dialect.Lock(ctx, conn, lockName, lockTimeout); err != nil {
+		return errors.Wrap(err, "error acquiring migration lock")
+	}
+
+	defer func() {
+		// the lock is released when the connection is closed regardless
+		if err := m.dialect.Unlock(context.Background(), conn, lockName); err != nil {
+			log.Printf("error releasing migration lock: %q", err)
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version     INT PRIMARY KEY,
+			name        VARCHAR(255) NOT NULL,
+			dirty       BOOLEAN NOT NULL DEFAULT FALSE,
+			applied_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`); err != nil {
+		return errors.Wrap(err, "error creating schema_migrations table")
+	}
+
+	return fn(conn)
+}
+
+// version returns the version of the most recently applied migration, or an error wrapping ErrDirty if it
+// didn't complete.
+func version(ctx context.Context, q execGetter, dialect db.Dialect) (int, error) {
+	var tables int
+	if err := q.GetContext(
+		ctx,
+		&tables,
+		dialect.TableExists("schema_migrations"),
+	); err != nil {
+		return 0, errors.Wrap(err, "error checking for schema_migrations table")
+	}
+
+	if tables == 0 {
+		return 0, nil
+	}
+
+	var latest struct {
+		Version int  `db:"version"`
+		Dirty   bool `db:"dirty"`
+	}
+	if err := q.GetContext(ctx, &latest, "SELECT version, dirty FROM schema_migrations ORDER BY version DESC LIMIT 1"); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, errors.Wrap(err, "error retreiving schema version")
+	}
+
+	if latest.Dirty {
+		return latest.Version, errors.Wrapf(ErrDirty, "migration %d failed part way", latest.Version)
+	}
+
+	return latest.Version, nil
+}
+
+func exec(ctx context.Context, q execGetter, migration string) error {
+	for _, stmt := range statements(migration) {
+		if _, err := q.ExecContext(ctx, stmt); err != nil {
+			return errors.Wrapf(err, "error executing statement: %s", stmt)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+// +build integration
+
+package migrations_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/db/dbtest"
+	"github.com/eggsbenjamin/square_enix/internal/app/migrations"
+)
+
+func TestMigrator(t *testing.T) {
+	conn, err := dbtest.Connect()
+	require.NoError(t, err)
+
+	loaded, err := migrations.Load(conn.DriverName())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	migrator := migrations.NewMigrator(conn.DB, loaded)
+	latest := migrator.Latest()
+
+	t.Run("Up and Down", func(t *testing.T) {
+		_, err := migrator.Up(ctx)
+		require.NoError(t, err)
+		require.NoError(t, migrator.Check(ctx))
+
+		rolledBack, err := migrator.Down(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, 1, rolledBack)
+
+		version, err := migrator.Version(ctx)
+		require.NoError(t, err)
+		require.Equal(t, latest-1, version)
+		require.Equal(t, migrations.ErrSchemaBehind, errors.Cause(migrator.Check(ctx)))
+
+		applied, err := migrator.Up(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, applied)
+		require.NoError(t, migrator.Check(ctx))
+	})
+
+	t.Run("Dirty", func(t *testing.T) {
+		defer func() {
+			if _, err := conn.Exec("DELETE FROM schema_migrations WHERE version > ?", latest); err != nil {
+				t.Logf("error resetting schema_migrations table: %q\n", err)
+			}
+		}()
+
+		failing := append(loaded, migrations.Migration{
+			Version: latest + 1,
+			Name:    "failing",
+			Up:      "SELECT * FROM NoSuchTable;",
+			Down:    "SELECT 1;",
+		})
+		migrator := migrations.NewMigrator(conn.DB, failing)
+
+		_, err := migrator.Up(ctx)
+		require.Error(t, err)
+
+		version, err := migrator.Version(ctx)
+		require.Equal(t, latest+1, version)
+		require.Equal(t, migrations.ErrDirty, errors.Cause(err))
+		require.Equal(t, migrations.ErrDirty, errors.Cause(migrator.Check(ctx)))
+	})
+}
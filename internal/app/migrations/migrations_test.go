@@ -0,0 +1,89 @@
+// +build unit
+
+package migrations
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("Embedded", func(t *testing.T) {
+		mysql, err := Load("mysql")
+		require.NoError(t, err)
+		require.True(t, len(mysql) >= 2)
+
+		for _, driver := range []string{"postgres", "sqlite"} {
+			migrations, err := Load(driver)
+			require.NoError(t, err)
+			require.Len(t, migrations, len(mysql), "every driver must have the same migrations")
+
+			for i, migration := range mysql {
+				require.Equal(t, i+1, migration.Version)
+				require.Equal(t, migration.Name, migrations[i].Name)
+
+				for _, migration := range []Migration{migration, migrations[i]} {
+					require.NotEmpty(t, statements(migration.Up))
+					require.NotEmpty(t, statements(migration.Down))
+				}
+			}
+		}
+	})
+
+	t.Run("Unsupported Driver", func(t *testing.T) {
+		_, err := Load("oracle")
+		require.Error(t, err)
+	})
+
+	t.Run("Ordered", func(t *testing.T) {
+		migrations, err := load(fstest.MapFS{
+			"sql/10_later.up.sql":   {Data: []byte("SELECT 10;")},
+			"sql/10_later.down.sql": {Data: []byte("SELECT -10;")},
+			"sql/2_first.up.sql":    {Data: []byte("SELECT 2;")},
+			"sql/2_first.down.sql":  {Data: []byte("SELECT -2;")},
+		}, "sql")
+		require.NoError(t, err)
+		require.Equal(t, []Migration{
+			{Version: 2, Name: "first", Up: "SELECT 2;", Down: "SELECT -2;"},
+			{Version: 10, Name: "later", Up: "SELECT 10;", Down: "SELECT -10;"},
+		}, migrations)
+	})
+
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{"Invalid Name", fstest.MapFS{"sql/first.up.sql": {Data: []byte("SELECT 1;")}}},
+		{"Missing Down", fstest.MapFS{"sql/1_first.up.sql": {Data: []byte("SELECT 1;")}}},
+		{"Conflicting Names", fstest.MapFS{
+			"sql/1_first.up.sql":   {Data: []byte("SELECT 1;")},
+			"sql/1_other.down.sql": {Data: []byte("SELECT -1;")},
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := load(test.files, "sql")
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestStatements(t *testing.T) {
+	migration := `
+-- a comment; with a semicolon
+CREATE TABLE A (
+  id INT
+);
+
+ALTER TABLE A ADD COLUMN data VARCHAR(50) DEFAULT ';'; 
+DROP TABLE B`
+
+	require.Equal(t, []string{
+		"CREATE TABLE A (\n  id INT\n)",
+		"ALTER TABLE A ADD COLUMN data VARCHAR(50) DEFAULT ';'",
+		"DROP TABLE B",
+	}, statements(migration))
+}
@@ -0,0 +1,170 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: migrations.go
+
+// Package migrations is a generated GoMock package.
+package migrations
+
+import (
+	context "context"
+	sql "database/sql"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// MockMigrator is a mock of Migrator interface
+type MockMigrator struct {
+	ctrl     *gomock.Controller
+	recorder *MockMigratorMockRecorder
+}
+
+// MockMigratorMockRecorder is the mock recorder for MockMigrator
+type MockMigratorMockRecorder struct {
+	mock *MockMigrator
+}
+
+// NewMockMigrator creates a new mock instance
+func NewMockMigrator(ctrl *gomock.Controller) *MockMigrator {
+	mock := &MockMigrator{ctrl: ctrl}
+	mock.recorder = &MockMigratorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockMigrator) EXPECT() *MockMigratorMockRecorder {
+	return m.recorder
+}
+
+// Up mocks base method
+func (m *MockMigrator) Up(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Up", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Up indicates an expected call of Up
+func (mr *MockMigratorMockRecorder) Up(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Up", reflect.TypeOf((*MockMigrator)(nil).Up), ctx)
+}
+
+// Down mocks base method
+func (m *MockMigrator) Down(ctx context.Context, steps int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Down", ctx, steps)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Down indicates an expected call of Down
+func (mr *MockMigratorMockRecorder) Down(ctx, steps interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Down", reflect.TypeOf((*MockMigrator)(nil).Down), ctx, steps)
+}
+
+// Version mocks base method
+func (m *MockMigrator) Version(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Version", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Version indicates an expected call of Version
+func (mr *MockMigratorMockRecorder) Version(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Version", reflect.TypeOf((*MockMigrator)(nil).Version), ctx)
+}
+
+// Latest mocks base method
+func (m *MockMigrator) Latest() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Latest")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// Latest indicates an expected call of Latest
+func (mr *MockMigratorMockRecorder) Latest() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Latest", reflect.TypeOf((*MockMigrator)(nil).Latest))
+}
+
+// Check mocks base method
+func (m *MockMigrator) Check(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Check indicates an expected call of Check
+func (mr *MockMigratorMockRecorder) Check(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockMigrator)(nil).Check), ctx)
+}
+
+// MockexecGetter is a mock of execGetter interface
+type MockexecGetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockexecGetterMockRecorder
+}
+
+// MockexecGetterMockRecorder is the mock recorder for MockexecGetter
+type MockexecGetterMockRecorder struct {
+	mock *MockexecGetter
+}
+
+// NewMockexecGetter creates a new mock instance
+func NewMockexecGetter(ctrl *gomock.Controller) *MockexecGetter {
+	mock := &MockexecGetter{ctrl: ctrl}
+	mock.recorder = &MockexecGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockexecGetter) EXPECT() *MockexecGetterMockRecorder {
+	return m.recorder
+}
+
+// ExecContext mocks base method
+func (m *MockexecGetter) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ExecContext", varargs...)
+	ret0, _ := ret[0].(sql.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecContext indicates an expected call of ExecContext
+func (mr *MockexecGetterMockRecorder) ExecContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecContext", reflect.TypeOf((*MockexecGetter)(nil).ExecContext), varargs...)
+}
+
+// GetContext mocks base method
+func (m *MockexecGetter) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, dest, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetContext", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetContext indicates an expected call of GetContext
+func (mr *MockexecGetterMockRecorder) GetContext(ctx, dest, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, dest, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContext", reflect.TypeOf((*MockexecGetter)(nil).GetContext), varargs...)
+}
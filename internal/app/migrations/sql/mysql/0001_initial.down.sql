@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS ProcessTransition;
+DROP TABLE IF EXISTS ProcessElementError;
+DROP TABLE IF EXISTS ProcessElement;
+DROP TABLE IF EXISTS ElementTag;
+DROP TABLE IF EXISTS Element;
+DROP TABLE IF EXISTS Process;
@@ -0,0 +1,71 @@
+CREATE TABLE IF NOT EXISTS Process (
+  id                  INT PRIMARY KEY AUTO_INCREMENT,
+  name                VARCHAR(100) NOT NULL,
+  status              VARCHAR(50) NOT NULL,
+  selector            VARCHAR(2048) NOT NULL DEFAULT '{}',
+  transformer         VARCHAR(50) NOT NULL DEFAULT 'upper',
+  transformer_config  VARCHAR(2048) NOT NULL DEFAULT '',
+  created_at          TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  started_at          TIMESTAMP NULL,
+  paused_at           TIMESTAMP NULL,
+  completed_at        TIMESTAMP NULL,
+  cancelled_at        TIMESTAMP NULL,
+  paused_seconds      INT NOT NULL DEFAULT 0,
+
+  INDEX(name),
+  INDEX(status)
+);
+
+CREATE TABLE IF NOT EXISTS Element (
+  id          INT PRIMARY KEY AUTO_INCREMENT,
+  data        VARCHAR(50),
+  created_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ElementTag (
+  element_id  INT NOT NULL,
+  tag         VARCHAR(50) NOT NULL,
+
+  PRIMARY KEY(element_id, tag),
+  INDEX(tag),
+  FOREIGN KEY(element_id) REFERENCES Element(id)
+);
+
+CREATE TABLE IF NOT EXISTS ProcessElement (
+  process_id     INT,
+  element_id     INT,
+  previous_data  VARCHAR(50) NOT NULL DEFAULT '',
+  new_data       VARCHAR(50) NOT NULL DEFAULT '',
+  processed_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  reverted_at    TIMESTAMP NULL,
+
+  FOREIGN KEY(process_id) REFERENCES Process(id),
+  FOREIGN KEY(element_id) REFERENCES Element(id)
+);
+
+CREATE TABLE IF NOT EXISTS ProcessElementError (
+  process_id       INT NOT NULL,
+  element_id       INT NOT NULL,
+  attempts         INT NOT NULL DEFAULT 0,
+  last_error       VARCHAR(1024) NOT NULL DEFAULT '',
+  next_attempt_at  TIMESTAMP NULL,
+  dead_lettered    BOOLEAN NOT NULL DEFAULT FALSE,
+  updated_at       TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+  PRIMARY KEY(process_id, element_id),
+  FOREIGN KEY(process_id) REFERENCES Process(id),
+  FOREIGN KEY(element_id) REFERENCES Element(id)
+);
+
+CREATE TABLE IF NOT EXISTS ProcessTransition (
+  id          INT PRIMARY KEY AUTO_INCREMENT,
+  process_id  INT NOT NULL,
+  from_state  VARCHAR(50) NOT NULL DEFAULT '',
+  to_state    VARCHAR(50) NOT NULL,
+  actor       VARCHAR(100) NOT NULL DEFAULT '',
+  reason      VARCHAR(1024) NOT NULL DEFAULT '',
+  created_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  INDEX(process_id),
+  FOREIGN KEY(process_id) REFERENCES Process(id)
+);
@@ -0,0 +1,6 @@
+-- the process_id foreign key needs an index of its own once the primary key is dropped
+ALTER TABLE ProcessElement
+  ADD INDEX(process_id),
+  DROP PRIMARY KEY,
+  MODIFY process_id INT NULL,
+  MODIFY element_id INT NULL;
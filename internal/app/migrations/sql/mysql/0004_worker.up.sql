@@ -0,0 +1,17 @@
+-- a worker renews expires_at with each heartbeat, it's dead once expires_at passes without it having stopped
+CREATE TABLE IF NOT EXISTS Worker (
+  id                INT PRIMARY KEY AUTO_INCREMENT,
+  hostname          VARCHAR(255) NOT NULL,
+  pid               INT NOT NULL,
+  worker            INT NOT NULL,
+  version           VARCHAR(100) NOT NULL DEFAULT '',
+  started_at        TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  heartbeat_at      TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  expires_at        TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  stopped_at        TIMESTAMP(3) NULL,
+  process_id        INT NULL,
+  batch_size        INT NOT NULL DEFAULT 0,
+  batch_started_at  TIMESTAMP(3) NULL,
+
+  INDEX(expires_at)
+);
@@ -0,0 +1,12 @@
+-- a worker claims the elements of a batch in the session its transaction runs in, until expires_at. There are
+-- no foreign keys, checking them would wait on the locks the worker's transaction holds on the elements
+CREATE TABLE IF NOT EXISTS ElementClaim (
+  element_id  INT PRIMARY KEY,
+  process_id  INT NOT NULL,
+  session_id  BIGINT NOT NULL,
+  claimed_at  TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  expires_at  TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+
+  INDEX(session_id),
+  INDEX(expires_at)
+);
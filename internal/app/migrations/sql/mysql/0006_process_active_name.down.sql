@@ -0,0 +1 @@
+ALTER TABLE Process DROP COLUMN active_name;
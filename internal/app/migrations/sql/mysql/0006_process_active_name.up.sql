@@ -0,0 +1,5 @@
+-- active_name is the name of a RUNNING or PAUSED process and NULL otherwise, its unique key allows one active
+-- process per name without locking the table. It must be as wide as name, so changing name's width changes it too
+ALTER TABLE Process
+  ADD COLUMN active_name VARCHAR(100) AS (IF(status IN ('RUNNING', 'PAUSED'), name, NULL)) STORED,
+  ADD UNIQUE INDEX process_active_name (active_name);
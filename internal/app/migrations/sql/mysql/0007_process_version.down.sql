@@ -0,0 +1 @@
+ALTER TABLE Process DROP COLUMN version;
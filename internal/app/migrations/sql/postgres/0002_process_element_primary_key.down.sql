@@ -0,0 +1,4 @@
+ALTER TABLE ProcessElement
+  DROP CONSTRAINT processelement_pkey,
+  ALTER COLUMN process_id DROP NOT NULL,
+  ALTER COLUMN element_id DROP NOT NULL;
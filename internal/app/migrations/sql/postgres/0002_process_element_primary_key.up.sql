@@ -0,0 +1,6 @@
+-- an element is processed at most once by a process, the key also serves the NOT EXISTS lookups made when
+-- locking a batch of elements
+ALTER TABLE ProcessElement
+  ALTER COLUMN process_id SET NOT NULL,
+  ALTER COLUMN element_id SET NOT NULL,
+  ADD PRIMARY KEY(process_id, element_id);
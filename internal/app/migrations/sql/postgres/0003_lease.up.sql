@@ -0,0 +1,6 @@
+-- a lease is held by one instance until it expires, the leader of the workers is the holder of the leader lease
+CREATE TABLE IF NOT EXISTS Lease (
+  name        VARCHAR(100) PRIMARY KEY,
+  holder      VARCHAR(255) NOT NULL,
+  expires_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
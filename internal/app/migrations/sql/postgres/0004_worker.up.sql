@@ -0,0 +1,17 @@
+-- a worker renews expires_at with each heartbeat, it's dead once expires_at passes without it having stopped
+CREATE TABLE IF NOT EXISTS Worker (
+  id                SERIAL PRIMARY KEY,
+  hostname          VARCHAR(255) NOT NULL,
+  pid               INT NOT NULL,
+  worker            INT NOT NULL,
+  version           VARCHAR(100) NOT NULL DEFAULT '',
+  started_at        TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  heartbeat_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  expires_at        TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  stopped_at        TIMESTAMP WITH TIME ZONE NULL,
+  process_id        INT NULL,
+  batch_size        INT NOT NULL DEFAULT 0,
+  batch_started_at  TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE INDEX IF NOT EXISTS worker_expires_at ON Worker (expires_at);
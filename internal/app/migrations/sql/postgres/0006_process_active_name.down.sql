@@ -0,0 +1 @@
+DROP INDEX IF EXISTS process_active_name;
@@ -0,0 +1,66 @@
+-- SQLite doesn't enforce the length of a VARCHAR, the checks reject data too long for the other databases
+CREATE TABLE IF NOT EXISTS Process (
+  id                  INTEGER PRIMARY KEY AUTOINCREMENT,
+  name                VARCHAR(100) NOT NULL,
+  status              VARCHAR(50) NOT NULL,
+  selector            VARCHAR(2048) NOT NULL DEFAULT '{}',
+  transformer         VARCHAR(50) NOT NULL DEFAULT 'upper',
+  transformer_config  VARCHAR(2048) NOT NULL DEFAULT '',
+  created_at          TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  started_at          TIMESTAMP NULL,
+  paused_at           TIMESTAMP NULL,
+  completed_at        TIMESTAMP NULL,
+  cancelled_at        TIMESTAMP NULL,
+  paused_seconds      INT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS process_name ON Process (name);
+CREATE INDEX IF NOT EXISTS process_status ON Process (status);
+
+CREATE TABLE IF NOT EXISTS Element (
+  id          INTEGER PRIMARY KEY AUTOINCREMENT,
+  data        VARCHAR(50) CHECK(length(data) <= 50),
+  created_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ElementTag (
+  element_id  INT NOT NULL REFERENCES Element(id),
+  tag         VARCHAR(50) NOT NULL CHECK(length(tag) <= 50),
+
+  PRIMARY KEY(element_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS element_tag_tag ON ElementTag (tag);
+
+CREATE TABLE IF NOT EXISTS ProcessElement (
+  process_id     INT REFERENCES Process(id),
+  element_id     INT REFERENCES Element(id),
+  previous_data  VARCHAR(50) NOT NULL DEFAULT '' CHECK(length(previous_data) <= 50),
+  new_data       VARCHAR(50) NOT NULL DEFAULT '' CHECK(length(new_data) <= 50),
+  processed_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  reverted_at    TIMESTAMP NULL
+);
+
+CREATE TABLE IF NOT EXISTS ProcessElementError (
+  process_id       INT NOT NULL REFERENCES Process(id),
+  element_id       INT NOT NULL REFERENCES Element(id),
+  attempts         INT NOT NULL DEFAULT 0,
+  last_error       VARCHAR(1024) NOT NULL DEFAULT '',
+  next_attempt_at  TIMESTAMP NULL,
+  dead_lettered    BOOLEAN NOT NULL DEFAULT FALSE,
+  updated_at       TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY(process_id, element_id)
+);
+
+CREATE TABLE IF NOT EXISTS ProcessTransition (
+  id          INTEGER PRIMARY KEY AUTOINCREMENT,
+  process_id  INT NOT NULL REFERENCES Process(id),
+  from_state  VARCHAR(50) NOT NULL DEFAULT '',
+  to_state    VARCHAR(50) NOT NULL,
+  actor       VARCHAR(100) NOT NULL DEFAULT '',
+  reason      VARCHAR(1024) NOT NULL DEFAULT '',
+  created_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS process_transition_process_id ON ProcessTransition (process_id);
@@ -0,0 +1,12 @@
+CREATE TABLE ProcessElement_old (
+  process_id     INT REFERENCES Process(id),
+  element_id     INT REFERENCES Element(id),
+  previous_data  VARCHAR(50) NOT NULL DEFAULT '' CHECK(length(previous_data) <= 50),
+  new_data       VARCHAR(50) NOT NULL DEFAULT '' CHECK(length(new_data) <= 50),
+  processed_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  reverted_at    TIMESTAMP NULL
+);
+
+INSERT INTO ProcessElement_old SELECT * FROM ProcessElement;
+DROP TABLE ProcessElement;
+ALTER TABLE ProcessElement_old RENAME TO ProcessElement;
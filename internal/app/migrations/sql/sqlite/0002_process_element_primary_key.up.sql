@@ -0,0 +1,16 @@
+-- an element is processed at most once by a process, the key also serves the NOT EXISTS lookups made when
+-- locking a batch of elements. SQLite can't add a primary key to a table, so the table is rebuilt with one
+CREATE TABLE ProcessElement_new (
+  process_id     INT NOT NULL REFERENCES Process(id),
+  element_id     INT NOT NULL REFERENCES Element(id),
+  previous_data  VARCHAR(50) NOT NULL DEFAULT '' CHECK(length(previous_data) <= 50),
+  new_data       VARCHAR(50) NOT NULL DEFAULT '' CHECK(length(new_data) <= 50),
+  processed_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  reverted_at    TIMESTAMP NULL,
+
+  PRIMARY KEY(process_id, element_id)
+);
+
+INSERT INTO ProcessElement_new SELECT * FROM ProcessElement;
+DROP TABLE ProcessElement;
+ALTER TABLE ProcessElement_new RENAME TO ProcessElement;
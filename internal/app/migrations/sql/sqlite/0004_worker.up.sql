@@ -0,0 +1,17 @@
+-- a worker renews expires_at with each heartbeat, it's dead once expires_at passes without it having stopped
+CREATE TABLE IF NOT EXISTS Worker (
+  id                INTEGER PRIMARY KEY AUTOINCREMENT,
+  hostname          VARCHAR(255) NOT NULL CHECK(length(hostname) <= 255),
+  pid               INT NOT NULL,
+  worker            INT NOT NULL,
+  version           VARCHAR(100) NOT NULL DEFAULT '' CHECK(length(version) <= 100),
+  started_at        TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  heartbeat_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  expires_at        TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  stopped_at        TIMESTAMP NULL,
+  process_id        INT NULL,
+  batch_size        INT NOT NULL DEFAULT 0,
+  batch_started_at  TIMESTAMP NULL
+);
+
+CREATE INDEX IF NOT EXISTS worker_expires_at ON Worker (expires_at);
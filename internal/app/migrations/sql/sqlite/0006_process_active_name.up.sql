@@ -0,0 +1,2 @@
+-- one RUNNING or PAUSED process per name, enforced by the index without locking the table
+CREATE UNIQUE INDEX IF NOT EXISTS process_active_name ON Process (name) WHERE status IN ('RUNNING', 'PAUSED');
@@ -0,0 +1,32 @@
+-- SQLite can't drop a column, so the table is rebuilt without it. The tables referencing Process would stop
+-- the old table being dropped, so foreign keys are off while it's replaced
+PRAGMA foreign_keys = OFF;
+
+CREATE TABLE Process_old (
+  id                  INTEGER PRIMARY KEY AUTOINCREMENT,
+  name                VARCHAR(100) NOT NULL,
+  status              VARCHAR(50) NOT NULL,
+  selector            VARCHAR(2048) NOT NULL DEFAULT '{}',
+  transformer         VARCHAR(50) NOT NULL DEFAULT 'upper',
+  transformer_config  VARCHAR(2048) NOT NULL DEFAULT '',
+  created_at          TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  started_at          TIMESTAMP NULL,
+  paused_at           TIMESTAMP NULL,
+  completed_at        TIMESTAMP NULL,
+  cancelled_at        TIMESTAMP NULL,
+  paused_seconds      INT NOT NULL DEFAULT 0
+);
+
+INSERT INTO Process_old
+SELECT id, name, status, selector, transformer, transformer_config, created_at, started_at, paused_at, completed_at,
+  cancelled_at, paused_seconds
+FROM Process;
+
+DROP TABLE Process;
+ALTER TABLE Process_old RENAME TO Process;
+
+CREATE INDEX IF NOT EXISTS process_name ON Process (name);
+CREATE INDEX IF NOT EXISTS process_status ON Process (status);
+CREATE UNIQUE INDEX IF NOT EXISTS process_active_name ON Process (name) WHERE status IN ('RUNNING', 'PAUSED');
+
+PRAGMA foreign_keys = ON;
@@ -0,0 +1,2 @@
+-- version is incremented by every update of a process, which only applies to the version it was read at
+ALTER TABLE Process ADD COLUMN version INT NOT NULL DEFAULT 0;
@@ -1,23 +1,351 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
 	"time"
 )
 
+// ProcessState is the status of a process. A process moves between states according to processTransitions.
+type ProcessState string
+
 const (
-	PROCESS_STATUS_RUNNING  = "RUNNING"
-	PROCESS_STATUS_COMPLETE = "COMPLETE"
-	PROCESS_STATUS_PAUSED   = "PAUSED"
+	PROCESS_STATUS_RUNNING   ProcessState = "RUNNING"
+	PROCESS_STATUS_COMPLETE  ProcessState = "COMPLETE"
+	PROCESS_STATUS_PAUSED    ProcessState = "PAUSED"
+	PROCESS_STATUS_CANCELLED ProcessState = "CANCELLED"
+	PROCESS_STATUS_REVERTING ProcessState = "REVERTING"
+	PROCESS_STATUS_REVERTED  ProcessState = "REVERTED"
 )
 
+// processTransitions lists the states each state may move to. A COMPLETE process is set back to RUNNING
+// when its dead lettered elements are redriven. A COMPLETE or CANCELLED process may be reverted, restoring
+// the data of the elements it changed, after which it never moves again.
+var processTransitions = map[ProcessState][]ProcessState{
+	PROCESS_STATUS_RUNNING:   {PROCESS_STATUS_PAUSED, PROCESS_STATUS_COMPLETE, PROCESS_STATUS_CANCELLED},
+	PROCESS_STATUS_PAUSED:    {PROCESS_STATUS_RUNNING, PROCESS_STATUS_CANCELLED},
+	PROCESS_STATUS_COMPLETE:  {PROCESS_STATUS_RUNNING, PROCESS_STATUS_REVERTING},
+	PROCESS_STATUS_CANCELLED: {PROCESS_STATUS_REVERTING},
+	PROCESS_STATUS_REVERTING: {PROCESS_STATUS_REVERTED},
+	PROCESS_STATUS_REVERTED:  {},
+}
+
+// TransitionError is returned when a process is asked to make a move the transition table doesn't allow.
+type TransitionError struct {
+	From ProcessState
+	To   ProcessState
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("process can't move from %s to %s", e.From, e.To)
+}
+
+// CanTransitionTo reports whether a process in state s may move to state to.
+func (s ProcessState) CanTransitionTo(to ProcessState) bool {
+	for _, allowed := range processTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Active reports whether a process in state s can still change elements, i.e. it's RUNNING, PAUSED or REVERTING.
+func (s ProcessState) Active() bool {
+	return s == PROCESS_STATUS_RUNNING || s == PROCESS_STATUS_PAUSED || s == PROCESS_STATUS_REVERTING
+}
+
+// ReservesName reports whether a process in state s reserves its name, i.e. it's RUNNING or PAUSED. Names are
+// unique amongst the processes reserving them.
+func (s ProcessState) ReservesName() bool {
+	return s == PROCESS_STATUS_RUNNING || s == PROCESS_STATUS_PAUSED
+}
+
+// maxSelectorLength is the length of the Process.selector VARCHAR column.
+const maxSelectorLength = 2048
+
+// ElementSelector restricts the elements a process operates on to those matching all of its criteria.
+// Zero values are unbounded.
+//   - MinID and MaxID bound the element id, inclusive
+//   - CreatedAfter and CreatedBefore bound the element's created_at, inclusive and exclusive respectively
+//   - DataPrefix matches data starting with the prefix, DataRegex data matching the regular expression, which
+//     is evaluated by the db
+//   - Tags matches elements with at least one of the tags
+type ElementSelector struct {
+	MinID         int        `json:"min_id,omitempty"`
+	MaxID         int        `json:"max_id,omitempty"`
+	CreatedAfter  *time.Time `json:"created_after,omitempty"`
+	CreatedBefore *time.Time `json:"created_before,omitempty"`
+	DataPrefix    string     `json:"data_prefix,omitempty"`
+	DataRegex     string     `json:"data_regex,omitempty"`
+	Tags          []string   `json:"tags,omitempty"`
+}
+
+// Validate reports whether the selector can match any elements and fits in the selector column.
+func (s ElementSelector) Validate() error {
+	if s.MinID < 0 || s.MaxID < 0 {
+		return errors.New("ids must be positive")
+	}
+
+	if s.MinID > 0 && s.MaxID > 0 && s.MinID > s.MaxID {
+		return errors.New("min_id is greater than max_id")
+	}
+
+	if s.CreatedAfter != nil && s.CreatedBefore != nil && !s.CreatedAfter.Before(*s.CreatedBefore) {
+		return errors.New("created_after is not before created_before")
+	}
+
+	if s.DataRegex != "" {
+		if _, err := regexp.Compile(s.DataRegex); err != nil {
+			return fmt.Errorf("invalid data_regex: %s", err)
+		}
+	}
+
+	for _, tag := range s.Tags {
+		if tag == "" {
+			return errors.New("tags must not be empty")
+		}
+	}
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	if len(b) > maxSelectorLength {
+		return fmt.Errorf("selector is %d bytes, maximum is %d", len(b), maxSelectorLength)
+	}
+
+	return nil
+}
+
+// Value persists the selector as JSON.
+func (s ElementSelector) Value() (driver.Value, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+// Scan reads a selector persisted as JSON.
+func (s *ElementSelector) Scan(src interface{}) error {
+	var b []byte
+	switch v := src.(type) {
+	case nil:
+		*s = ElementSelector{}
+		return nil
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("unsupported selector type: %T", src)
+	}
+
+	if len(b) == 0 {
+		*s = ElementSelector{}
+		return nil
+	}
+
+	return json.Unmarshal(b, s)
+}
+
+// Process is a named run of a transformer over a selection of elements. Version is incremented by every update,
+// which only applies to the version the process was read at.
 type Process struct {
-	ID        int       `db:"id"`
-	Status    string    `db:"status"`
-	CreatedAt time.Time `db:"created_at"`
+	ID                int             `db:"id" json:"id"`
+	Name              string          `db:"name" json:"name"`
+	Status            ProcessState    `db:"status" json:"status"`
+	Selector          ElementSelector `db:"selector" json:"selector"`
+	Transformer       string          `db:"transformer" json:"transformer"`
+	TransformerConfig string          `db:"transformer_config" json:"transformer_config"`
+	CreatedAt         time.Time       `db:"created_at" json:"created_at"`
+	StartedAt         *time.Time      `db:"started_at" json:"started_at"`
+	PausedAt          *time.Time      `db:"paused_at" json:"paused_at"`
+	CompletedAt       *time.Time      `db:"completed_at" json:"completed_at"`
+	CancelledAt       *time.Time      `db:"cancelled_at" json:"cancelled_at"`
+	PausedSeconds     int             `db:"paused_seconds" json:"paused_seconds"`
+	Version           int             `db:"version" json:"version"`
+}
+
+// Transition returns the process moved to state to at now, with its timestamps updated. Time spent paused is
+// added to PausedSeconds when the process leaves PAUSED. Illegal moves return a *TransitionError.
+func (p Process) Transition(to ProcessState, now time.Time) (Process, error) {
+	if !p.Status.CanTransitionTo(to) {
+		return p, &TransitionError{From: p.Status, To: to}
+	}
+
+	if p.PausedAt != nil {
+		p.PausedSeconds += int(now.Sub(*p.PausedAt).Seconds())
+		p.PausedAt = nil
+	}
+
+	switch to {
+	case PROCESS_STATUS_RUNNING:
+		p.CompletedAt = nil
+	case PROCESS_STATUS_PAUSED:
+		p.PausedAt = &now
+	case PROCESS_STATUS_COMPLETE:
+		p.CompletedAt = &now
+	case PROCESS_STATUS_CANCELLED:
+		p.CancelledAt = &now
+	}
+
+	p.Status = to
+	return p, nil
+}
+
+// ProcessTransition is an entry in a process's audit history. From is empty for the transition that
+// created the process.
+type ProcessTransition struct {
+	ID        int          `db:"id" json:"id"`
+	ProcessID int          `db:"process_id" json:"process_id"`
+	From      ProcessState `db:"from_state" json:"from"`
+	To        ProcessState `db:"to_state" json:"to"`
+	Actor     string       `db:"actor" json:"actor"`
+	Reason    string       `db:"reason" json:"reason"`
+	CreatedAt time.Time    `db:"created_at" json:"at"`
+}
+
+// ProcessPreview reports what a process would do without it being started: the number of elements it would
+// operate on and a sample of their data before and after being transformed.
+type ProcessPreview struct {
+	Affected int             `json:"affected"`
+	Samples  []PreviewSample `json:"samples"`
+}
+
+// PreviewSample is an element's data before and after being transformed. Error is set, and After is empty,
+// when the element would fail to be processed.
+type PreviewSample struct {
+	ElementID int    `json:"element_id"`
+	Before    string `json:"before"`
+	After     string `json:"after"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ProcessStatus is a point in time report of a process's progress. LastProgressAt is when an element was last
+// processed, reverted or failed, or the process last transitioned if it was later, and a RUNNING or REVERTING
+// process is Stuck once it has made no progress for too long with elements left. Version is the process's.
+type ProcessStatus struct {
+	ProcessID       int          `json:"process_id"`
+	Name            string       `json:"name"`
+	Status          ProcessState `json:"status"`
+	CreatedAt       time.Time    `json:"created_at"`
+	StartedAt       *time.Time   `json:"started_at"`
+	PausedAt        *time.Time   `json:"paused_at"`
+	CompletedAt     *time.Time   `json:"completed_at"`
+	CancelledAt     *time.Time   `json:"cancelled_at"`
+	TotalEligible   int          `json:"total_eligible"`
+	Processed       int          `json:"processed"`
+	DeadLettered    int          `json:"dead_lettered"`
+	Remaining       int          `json:"remaining"`
+	PercentComplete float64      `json:"percent_complete"`
+	Throughput      float64      `json:"throughput"`
+	ETASeconds      *float64     `json:"eta_seconds"`
+	ETA             *time.Time   `json:"eta"`
+	LastProgressAt  *time.Time   `json:"last_progress_at"`
+	Stuck           bool         `json:"stuck"`
+	Version         int          `json:"version"`
+}
+
+// ProcessElementError records the failures of an element during a process. Once Attempts reaches
+// the configured limit the element is dead lettered and no longer retried until it's redriven.
+type ProcessElementError struct {
+	ProcessID     int        `db:"process_id" json:"process_id"`
+	ElementID     int        `db:"element_id" json:"element_id"`
+	Attempts      int        `db:"attempts" json:"attempts"`
+	LastError     string     `db:"last_error" json:"last_error"`
+	NextAttemptAt *time.Time `db:"next_attempt_at" json:"next_attempt_at"`
+	DeadLettered  bool       `db:"dead_lettered" json:"dead_lettered"`
+	UpdatedAt     time.Time  `db:"updated_at" json:"updated_at"`
 }
 
+// Element is a row of data to be processed. Tags are stored in the ElementTag table; they're written when the
+// element is inserted and aren't read back with it.
 type Element struct {
-	ID        int       `db:"id"`
-	Data      string    `db:"data"`
-	CreatedAt time.Time `db:"created_at"`
+	ID        int       `db:"id" json:"id"`
+	Data      string    `db:"data" json:"data"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	Tags      []string  `db:"-" json:"tags,omitempty"`
+}
+
+// ProcessElement records a process's change to an element: its data before and after being transformed, and
+// when its previous data was restored if the process has been reverted. ProcessName and Transformer are only
+// populated in an element's history.
+type ProcessElement struct {
+	ProcessID    int        `db:"process_id" json:"process_id"`
+	ProcessName  string     `db:"process_name" json:"process_name,omitempty"`
+	Transformer  string     `db:"transformer" json:"transformer,omitempty"`
+	ElementID    int        `db:"element_id" json:"element_id"`
+	PreviousData string     `db:"previous_data" json:"previous_data"`
+	NewData      string     `db:"new_data" json:"new_data"`
+	ProcessedAt  time.Time  `db:"processed_at" json:"processed_at"`
+	RevertedAt   *time.Time `db:"reverted_at" json:"reverted_at"`
+}
+
+// Lease is a named lease held by one instance until it expires, unless the instance renews it first.
+type Lease struct {
+	Name      string    `db:"name" json:"name"`
+	Holder    string    `db:"holder" json:"holder"`
+	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
+}
+
+// WorkerStatus is the status of a worker, derived from its heartbeats.
+type WorkerStatus string
+
+const (
+	WORKER_STATUS_LIVE    WorkerStatus = "LIVE"
+	WORKER_STATUS_DEAD    WorkerStatus = "DEAD"
+	WORKER_STATUS_STOPPED WorkerStatus = "STOPPED"
+)
+
+// Worker is a batch worker of an instance, identified within it by its number. The worker renews ExpiresAt with
+// each heartbeat, along with the batch it's processing, if any, as of the heartbeat. Status is derived by
+// WithStatus, it isn't stored.
+type Worker struct {
+	ID             int          `db:"id" json:"id"`
+	Hostname       string       `db:"hostname" json:"hostname"`
+	PID            int          `db:"pid" json:"pid"`
+	Worker         int          `db:"worker" json:"worker"`
+	Version        string       `db:"version" json:"version"`
+	StartedAt      time.Time    `db:"started_at" json:"started_at"`
+	HeartbeatAt    time.Time    `db:"heartbeat_at" json:"heartbeat_at"`
+	ExpiresAt      time.Time    `db:"expires_at" json:"expires_at"`
+	StoppedAt      *time.Time   `db:"stopped_at" json:"stopped_at"`
+	ProcessID      *int         `db:"process_id" json:"process_id"`
+	BatchSize      int          `db:"batch_size" json:"batch_size"`
+	BatchStartedAt *time.Time   `db:"batch_started_at" json:"batch_started_at"`
+	Status         WorkerStatus `db:"-" json:"status"`
+}
+
+// WithStatus returns the worker with its status at now: STOPPED once it has stopped, DEAD once its heartbeat
+// has expired without it stopping, e.g. because its instance crashed, and LIVE otherwise.
+func (w Worker) WithStatus(now time.Time) Worker {
+	switch {
+	case w.StoppedAt != nil:
+		w.Status = WORKER_STATUS_STOPPED
+	case !now.Before(w.ExpiresAt):
+		w.Status = WORKER_STATUS_DEAD
+	default:
+		w.Status = WORKER_STATUS_LIVE
+	}
+
+	return w
+}
+
+// ElementClaim records that the transaction of a database session has locked an element for a process's batch,
+// until ExpiresAt. A claim outliving its expiry belongs to a batch that's stuck, or whose worker has died
+// without the database noticing, and the session is killed to release the element.
+type ElementClaim struct {
+	ElementID int       `db:"element_id" json:"element_id"`
+	ProcessID int       `db:"process_id" json:"process_id"`
+	SessionID int64     `db:"session_id" json:"session_id"`
+	ClaimedAt time.Time `db:"claimed_at" json:"claimed_at"`
+	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
 }
@@ -0,0 +1,153 @@
+// +build unit
+
+package models_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessTransition(t *testing.T) {
+	now := time.Date(2019, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Illegal", func(t *testing.T) {
+		tests := []struct {
+			from models.ProcessState
+			to   models.ProcessState
+		}{
+			{models.PROCESS_STATUS_COMPLETE, models.PROCESS_STATUS_PAUSED},
+			{models.PROCESS_STATUS_PAUSED, models.PROCESS_STATUS_COMPLETE},
+			{models.PROCESS_STATUS_RUNNING, models.PROCESS_STATUS_RUNNING},
+			{models.PROCESS_STATUS_CANCELLED, models.PROCESS_STATUS_RUNNING},
+			{models.PROCESS_STATUS_RUNNING, models.PROCESS_STATUS_REVERTING},
+			{models.PROCESS_STATUS_REVERTING, models.PROCESS_STATUS_CANCELLED},
+			{models.PROCESS_STATUS_REVERTED, models.PROCESS_STATUS_RUNNING},
+		}
+
+		for _, test := range tests {
+			process := models.Process{ID: 1, Status: test.from}
+
+			transitioned, err := process.Transition(test.to, now)
+			require.Equal(t, &models.TransitionError{From: test.from, To: test.to}, err)
+			require.Equal(t, process, transitioned)
+		}
+	})
+
+	t.Run("Pause and Resume", func(t *testing.T) {
+		process, err := models.Process{ID: 1, Status: models.PROCESS_STATUS_RUNNING}.Transition(models.PROCESS_STATUS_PAUSED, now)
+		require.NoError(t, err)
+		require.Equal(t, models.PROCESS_STATUS_PAUSED, process.Status)
+		require.Equal(t, now, *process.PausedAt)
+
+		process, err = process.Transition(models.PROCESS_STATUS_RUNNING, now.Add(30*time.Second))
+		require.NoError(t, err)
+		require.Equal(t, models.PROCESS_STATUS_RUNNING, process.Status)
+		require.Nil(t, process.PausedAt)
+		require.Equal(t, 30, process.PausedSeconds)
+	})
+
+	t.Run("Cancel Paused", func(t *testing.T) {
+		pausedAt := now.Add(-30 * time.Second)
+		process := models.Process{
+			ID:            1,
+			Status:        models.PROCESS_STATUS_PAUSED,
+			PausedAt:      &pausedAt,
+			PausedSeconds: 10,
+		}
+
+		process, err := process.Transition(models.PROCESS_STATUS_CANCELLED, now)
+		require.NoError(t, err)
+		require.Equal(t, models.PROCESS_STATUS_CANCELLED, process.Status)
+		require.Equal(t, now, *process.CancelledAt)
+		require.Nil(t, process.PausedAt)
+		require.Equal(t, 40, process.PausedSeconds)
+	})
+
+	t.Run("Complete and Reopen", func(t *testing.T) {
+		process, err := models.Process{ID: 1, Status: models.PROCESS_STATUS_RUNNING}.Transition(models.PROCESS_STATUS_COMPLETE, now)
+		require.NoError(t, err)
+		require.Equal(t, now, *process.CompletedAt)
+
+		process, err = process.Transition(models.PROCESS_STATUS_RUNNING, now)
+		require.NoError(t, err)
+		require.Equal(t, models.PROCESS_STATUS_RUNNING, process.Status)
+		require.Nil(t, process.CompletedAt)
+	})
+
+	t.Run("Revert", func(t *testing.T) {
+		for _, from := range []models.ProcessState{models.PROCESS_STATUS_COMPLETE, models.PROCESS_STATUS_CANCELLED} {
+			process, err := models.Process{ID: 1, Status: from}.Transition(models.PROCESS_STATUS_REVERTING, now)
+			require.NoError(t, err)
+			require.True(t, process.Status.Active())
+
+			process, err = process.Transition(models.PROCESS_STATUS_REVERTED, now)
+			require.NoError(t, err)
+			require.Equal(t, models.PROCESS_STATUS_REVERTED, process.Status)
+			require.False(t, process.Status.Active())
+		}
+	})
+}
+
+func TestElementSelectorValidate(t *testing.T) {
+	now := time.Date(2019, 1, 1, 12, 0, 0, 0, time.UTC)
+	earlier := now.Add(-time.Hour)
+
+	tests := []struct {
+		name     string
+		selector models.ElementSelector
+		valid    bool
+	}{
+		{"Unbounded", models.ElementSelector{}, true},
+		{"All Criteria", models.ElementSelector{
+			MinID:         1,
+			MaxID:         10,
+			CreatedAfter:  &earlier,
+			CreatedBefore: &now,
+			DataPrefix:    "a",
+			DataRegex:     "^a.*z$",
+			Tags:          []string{"one", "two"},
+		}, true},
+		{"Negative ID", models.ElementSelector{MinID: -1}, false},
+		{"Inverted ID Range", models.ElementSelector{MinID: 10, MaxID: 1}, false},
+		{"Inverted Created Window", models.ElementSelector{CreatedAfter: &now, CreatedBefore: &earlier}, false},
+		{"Invalid Regex", models.ElementSelector{DataRegex: "("}, false},
+		{"Empty Tag", models.ElementSelector{Tags: []string{""}}, false},
+		{"Too Long", models.ElementSelector{DataPrefix: strings.Repeat("x", 2048)}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.selector.Validate()
+			if test.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestWorkerWithStatus(t *testing.T) {
+	now := time.Date(2019, 1, 1, 12, 0, 0, 0, time.UTC)
+	stoppedAt := now.Add(-time.Minute)
+
+	tests := []struct {
+		name   string
+		worker models.Worker
+		status models.WorkerStatus
+	}{
+		{"Live", models.Worker{ExpiresAt: now.Add(time.Second)}, models.WORKER_STATUS_LIVE},
+		{"Dead", models.Worker{ExpiresAt: now}, models.WORKER_STATUS_DEAD},
+		{"Stopped", models.Worker{ExpiresAt: now.Add(time.Second), StoppedAt: &stoppedAt}, models.WORKER_STATUS_STOPPED},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.status, test.worker.WithStatus(now).Status)
+		})
+	}
+}
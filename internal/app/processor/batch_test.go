@@ -0,0 +1,504 @@
+// +build unit
+
+package processor_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	"github.com/eggsbenjamin/square_enix/internal/app/leader"
+	"github.com/eggsbenjamin/square_enix/internal/app/metrics"
+	"github.com/eggsbenjamin/square_enix/internal/app/models"
+	"github.com/eggsbenjamin/square_enix/internal/app/processor"
+	"github.com/eggsbenjamin/square_enix/internal/app/repository/memory"
+	"github.com/eggsbenjamin/square_enix/internal/app/transformer"
+)
+
+// newMemoryDB returns an in-memory db seeded with elements created a minute ago.
+func newMemoryDB(t *testing.T, data ...string) db.DB {
+	database := memory.NewDB()
+
+	elements := make([]models.Element, 0, len(data))
+	for _, d := range data {
+		elements = append(elements, models.Element{Data: d, CreatedAt: time.Now().Add(-time.Minute)})
+	}
+	_, err := memory.NewElementRepository(database).InsertElements(context.Background(), elements)
+	require.NoError(t, err)
+
+	return database
+}
+
+// newMemoryProcessor returns a processor over an in-memory db. Its "exclaim" transformer appends "!" to an
+// element's data, failing elements whose data is "bad".
+func newMemoryProcessor(database db.DB, cfg processor.Config, elector leader.Elector) processor.Processor {
+	transformers := transformer.NewRegistry()
+	transformers.Register("exclaim", func(config string) (transformer.Transformer, error) {
+		return transformer.Func(func(data string) (string, error) {
+			if data == "bad" {
+				return "", errors.New("bad element")
+			}
+			return data + "!", nil
+		}), nil
+	})
+
+	return processor.NewProcessor(
+		database,
+		memory.NewProcessRepositoryFactory(),
+		memory.NewElementRepositoryFactory(),
+		memory.NewElementErrorRepositoryFactory(),
+		memory.NewTransitionRepositoryFactory(),
+		memory.NewClaimRepositoryFactory(),
+		transformers,
+		cfg,
+		metrics.NewNopRecorder(),
+		elector,
+	)
+}
+
+// newBlockingProcessor returns a processor over an in-memory db whose "block" transformer signals started and
+// waits for unblock to be closed before transforming an element.
+func newBlockingProcessor(database db.DB, cfg processor.Config, started chan<- struct{}, unblock <-chan struct{}) processor.Processor {
+	transformers := transformer.NewRegistry()
+	transformers.Register("block", func(config string) (transformer.Transformer, error) {
+		return transformer.Func(func(data string) (string, error) {
+			started <- struct{}{}
+			<-unblock
+			return data, nil
+		}), nil
+	})
+
+	return processor.NewProcessor(
+		database,
+		memory.NewProcessRepositoryFactory(),
+		memory.NewElementRepositoryFactory(),
+		memory.NewElementErrorRepositoryFactory(),
+		memory.NewTransitionRepositoryFactory(),
+		memory.NewClaimRepositoryFactory(),
+		transformers,
+		cfg,
+		metrics.NewNopRecorder(),
+		leader.NewSoleElector(),
+	)
+}
+
+type batchResult struct {
+	processed int
+	err       error
+}
+
+func TestProcessBatchInMemory(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Completes", func(t *testing.T) {
+		database := newMemoryDB(t, "a", "b", "c")
+		proc := newMemoryProcessor(database, processor.Config{MaxAttempts: 1}, leader.NewSoleElector())
+
+		process, err := proc.Start(processor.StartOptions{Name: "test", Transformer: "exclaim"})
+		require.NoError(t, err)
+
+		processed, err := proc.ProcessBatch(2)
+		require.NoError(t, err)
+		require.Equal(t, 2, processed)
+
+		processed, err = proc.ProcessBatch(2)
+		require.NoError(t, err)
+		require.Equal(t, 1, processed)
+
+		processed, err = proc.ProcessBatch(2)
+		require.NoError(t, err)
+		require.Zero(t, processed)
+
+		process, err = memory.NewProcessRepository(database).GetByID(ctx, process.ID)
+		require.NoError(t, err)
+		require.Equal(t, models.PROCESS_STATUS_COMPLETE, process.Status)
+
+		for id, data := range map[int]string{1: "a!", 2: "b!", 3: "c!"} {
+			element, err := memory.NewElementRepository(database).GetElementByID(ctx, id)
+			require.NoError(t, err)
+			require.Equal(t, data, element.Data)
+		}
+
+		_, err = proc.ProcessBatch(2)
+		require.Equal(t, processor.ErrNoRunningProcessExists, err)
+	})
+
+	t.Run("Concurrent Workers", func(t *testing.T) {
+		data := make([]string, 100)
+		for i := range data {
+			data[i] = "element"
+		}
+
+		database := newMemoryDB(t, data...)
+		proc := newMemoryProcessor(database, processor.Config{MaxAttempts: 1}, leader.NewSoleElector())
+
+		process, err := proc.Start(processor.StartOptions{Name: "test", Transformer: "exclaim"})
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					if _, err := proc.ProcessBatch(3); err != nil {
+						require.Equal(t, processor.ErrNoRunningProcessExists, err)
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		elementRepo := memory.NewElementRepository(database)
+		for id := 1; id <= len(data); id++ {
+			element, err := elementRepo.GetElementByID(ctx, id)
+			require.NoError(t, err)
+			require.Equal(t, "element!", element.Data)
+		}
+
+		processed, err := elementRepo.CountElementsByProcessID(ctx, process.ID)
+		require.NoError(t, err)
+		require.Equal(t, len(data), processed)
+
+		process, err = memory.NewProcessRepository(database).GetByID(ctx, process.ID)
+		require.NoError(t, err)
+		require.Equal(t, models.PROCESS_STATUS_COMPLETE, process.Status)
+
+		// the workers completing it at the same time did so one after the other, only the first completed it
+		history, err := proc.GetHistory(process.ID)
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		require.Equal(t, models.PROCESS_STATUS_COMPLETE, history[1].To)
+	})
+
+	t.Run("Not Completed While Elements Are Locked", func(t *testing.T) {
+		database := newMemoryDB(t, "a", "b")
+		proc := newMemoryProcessor(database, processor.Config{MaxAttempts: 1}, leader.NewSoleElector())
+
+		process, err := proc.Start(processor.StartOptions{Name: "test", Transformer: "exclaim"})
+		require.NoError(t, err)
+
+		// another worker holds an element
+		tx, err := database.Beginx()
+		require.NoError(t, err)
+
+		locked, err := memory.NewElementRepository(tx).LockElementsForUpdate(ctx, process, 1)
+		require.NoError(t, err)
+		require.Len(t, locked, 1)
+
+		processed, err := proc.ProcessBatch(2)
+		require.NoError(t, err)
+		require.Equal(t, 1, processed)
+
+		processed, err = proc.ProcessBatch(2)
+		require.NoError(t, err)
+		require.Zero(t, processed)
+
+		process, err = memory.NewProcessRepository(database).GetByID(ctx, process.ID)
+		require.NoError(t, err)
+		require.Equal(t, models.PROCESS_STATUS_RUNNING, process.Status)
+
+		require.NoError(t, tx.Rollback())
+
+		processed, err = proc.ProcessBatch(2)
+		require.NoError(t, err)
+		require.Equal(t, 1, processed)
+
+		processed, err = proc.ProcessBatch(2)
+		require.NoError(t, err)
+		require.Zero(t, processed)
+
+		process, err = memory.NewProcessRepository(database).GetByID(ctx, process.ID)
+		require.NoError(t, err)
+		require.Equal(t, models.PROCESS_STATUS_COMPLETE, process.Status)
+	})
+
+	t.Run("Completed By The Leader", func(t *testing.T) {
+		database := newMemoryDB(t, "a", "b")
+
+		// an elector that hasn't campaigned doesn't lead
+		follower := newMemoryProcessor(
+			database,
+			processor.Config{MaxAttempts: 1},
+			leader.NewElector(database, memory.NewLeaseRepositoryFactory(), leader.WORKER_LEASE, "follower", time.Minute),
+		)
+
+		process, err := follower.Start(processor.StartOptions{Name: "test", Transformer: "exclaim"})
+		require.NoError(t, err)
+
+		processed, err := follower.ProcessBatch(10)
+		require.NoError(t, err)
+		require.Equal(t, 2, processed)
+
+		processed, err = follower.ProcessBatch(10)
+		require.NoError(t, err)
+		require.Zero(t, processed)
+
+		process, err = memory.NewProcessRepository(database).GetByID(ctx, process.ID)
+		require.NoError(t, err)
+		require.Equal(t, models.PROCESS_STATUS_RUNNING, process.Status)
+
+		processed, err = newMemoryProcessor(database, processor.Config{MaxAttempts: 1}, leader.NewSoleElector()).ProcessBatch(10)
+		require.NoError(t, err)
+		require.Zero(t, processed)
+
+		process, err = memory.NewProcessRepository(database).GetByID(ctx, process.ID)
+		require.NoError(t, err)
+		require.Equal(t, models.PROCESS_STATUS_COMPLETE, process.Status)
+	})
+
+	t.Run("Dead Letters Failing Elements", func(t *testing.T) {
+		database := newMemoryDB(t, "good", "bad")
+		proc := newMemoryProcessor(database, processor.Config{MaxAttempts: 2}, leader.NewSoleElector())
+
+		process, err := proc.Start(processor.StartOptions{Name: "test", Transformer: "exclaim"})
+		require.NoError(t, err)
+
+		processed, err := proc.ProcessBatch(2)
+		require.NoError(t, err)
+		require.Equal(t, 2, processed)
+
+		failures, err := proc.GetFailures(process.ID, false)
+		require.NoError(t, err)
+		require.Len(t, failures, 1)
+		require.Equal(t, 2, failures[0].ElementID)
+		require.Equal(t, 1, failures[0].Attempts)
+		require.False(t, failures[0].DeadLettered)
+
+		// the failed element's change was rolled back to its savepoint
+		element, err := memory.NewElementRepository(database).GetElementByID(ctx, 2)
+		require.NoError(t, err)
+		require.Equal(t, "bad", element.Data)
+
+		// wait out the retry backoff
+		time.Sleep(time.Until(*failures[0].NextAttemptAt))
+
+		processed, err = proc.ProcessBatch(2)
+		require.NoError(t, err)
+		require.Equal(t, 1, processed)
+
+		failures, err = proc.GetFailures(process.ID, true)
+		require.NoError(t, err)
+		require.Len(t, failures, 1)
+		require.Equal(t, 2, failures[0].Attempts)
+
+		processed, err = proc.ProcessBatch(2)
+		require.NoError(t, err)
+		require.Zero(t, processed)
+
+		status, err := proc.GetStatus(process.ID)
+		require.NoError(t, err)
+		require.Equal(t, models.PROCESS_STATUS_COMPLETE, status.Status)
+		require.Equal(t, 1, status.DeadLettered)
+	})
+
+	t.Run("Reverts", func(t *testing.T) {
+		database := newMemoryDB(t, "a", "b")
+		proc := newMemoryProcessor(database, processor.Config{MaxAttempts: 1}, leader.NewSoleElector())
+
+		process, err := proc.Start(processor.StartOptions{Name: "test", Transformer: "exclaim"})
+		require.NoError(t, err)
+
+		for {
+			_, err := proc.ProcessBatch(10)
+			if err == processor.ErrNoRunningProcessExists {
+				break
+			}
+			require.NoError(t, err)
+		}
+
+		_, err = proc.Revert(process.ID)
+		require.NoError(t, err)
+
+		processed, err := proc.ProcessBatch(10)
+		require.NoError(t, err)
+		require.Equal(t, 2, processed)
+
+		processed, err = proc.ProcessBatch(10)
+		require.NoError(t, err)
+		require.Zero(t, processed)
+
+		process, err = memory.NewProcessRepository(database).GetByID(ctx, process.ID)
+		require.NoError(t, err)
+		require.Equal(t, models.PROCESS_STATUS_REVERTED, process.Status)
+
+		for id, data := range map[int]string{1: "a", 2: "b"} {
+			element, err := memory.NewElementRepository(database).GetElementByID(ctx, id)
+			require.NoError(t, err)
+			require.Equal(t, data, element.Data)
+		}
+	})
+
+	t.Run("Reaps Expired Claims", func(t *testing.T) {
+		database := newMemoryDB(t, "a", "b")
+		proc := newMemoryProcessor(database, processor.Config{MaxAttempts: 1, ClaimTTL: time.Millisecond}, leader.NewSoleElector())
+
+		process, err := proc.Start(processor.StartOptions{Name: "test", Transformer: "exclaim"})
+		require.NoError(t, err)
+
+		// a worker dies holding an element it claimed, without the database noticing
+		tx, err := database.Beginx()
+		require.NoError(t, err)
+
+		locked, err := memory.NewElementRepository(tx).LockElementsForUpdate(ctx, process, 1)
+		require.NoError(t, err)
+		require.Len(t, locked, 1)
+
+		session, err := memory.NewClaimRepository(tx).SessionID(ctx)
+		require.NoError(t, err)
+		require.NoError(t, memory.NewClaimRepository(database).ClaimElements(ctx, session, process.ID, []int{locked[0].ID}, time.Millisecond))
+
+		processed, err := proc.ProcessBatch(2)
+		require.NoError(t, err)
+		require.Equal(t, 1, processed)
+
+		processed, err = proc.ProcessBatch(2)
+		require.NoError(t, err)
+		require.Zero(t, processed)
+
+		time.Sleep(2 * time.Millisecond)
+
+		// the batch's own claim was released once it committed
+		reaped, err := proc.ReapClaims()
+		require.NoError(t, err)
+		require.Equal(t, 1, reaped)
+
+		// the session was killed, rolling back its transaction
+		require.Equal(t, sql.ErrTxDone, tx.Commit())
+
+		processed, err = proc.ProcessBatch(2)
+		require.NoError(t, err)
+		require.Equal(t, 1, processed)
+
+		processed, err = proc.ProcessBatch(2)
+		require.NoError(t, err)
+		require.Zero(t, processed)
+
+		process, err = memory.NewProcessRepository(database).GetByID(ctx, process.ID)
+		require.NoError(t, err)
+		require.Equal(t, models.PROCESS_STATUS_COMPLETE, process.Status)
+
+		reaped, err = proc.ReapClaims()
+		require.NoError(t, err)
+		require.Zero(t, reaped)
+	})
+
+	t.Run("Renews Claims", func(t *testing.T) {
+		database := newMemoryDB(t, "a")
+		started, unblock := make(chan struct{}), make(chan struct{})
+		proc := newBlockingProcessor(database, processor.Config{MaxAttempts: 1, ClaimTTL: 30 * time.Millisecond}, started, unblock)
+
+		_, err := proc.Start(processor.StartOptions{Name: "test", Transformer: "block"})
+		require.NoError(t, err)
+
+		results := make(chan batchResult, 1)
+		go func() {
+			processed, err := proc.ProcessBatch(1)
+			results <- batchResult{processed, err}
+		}()
+		<-started
+
+		// the batch outlasts its claim's ttl, renewing the claim so it isn't reaped
+		time.Sleep(100 * time.Millisecond)
+
+		reaped, err := proc.ReapClaims()
+		require.NoError(t, err)
+		require.Zero(t, reaped)
+
+		close(unblock)
+
+		result := <-results
+		require.NoError(t, result.err)
+		require.Equal(t, 1, result.processed)
+
+		claims, err := memory.NewClaimRepository(database).GetExpired(ctx, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.Empty(t, claims)
+	})
+
+	t.Run("Survives Killed Sessions", func(t *testing.T) {
+		database := newMemoryDB(t, "a")
+		started, unblock := make(chan struct{}, 2), make(chan struct{})
+		proc := newBlockingProcessor(database, processor.Config{MaxAttempts: 1}, started, unblock)
+
+		_, err := proc.Start(processor.StartOptions{Name: "test", Transformer: "block"})
+		require.NoError(t, err)
+
+		results := make(chan batchResult, 1)
+		go func() {
+			processed, err := proc.ProcessBatch(1)
+			results <- batchResult{processed, err}
+		}()
+		<-started
+
+		claims, err := memory.NewClaimRepository(database).GetExpired(ctx, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.Len(t, claims, 1)
+		require.NotZero(t, claims[0].SessionID)
+
+		require.NoError(t, memory.NewClaimRepository(database).KillSession(ctx, claims[0].SessionID))
+		close(unblock)
+
+		// the killed batch fails, and its element is processed by the next
+		result := <-results
+		require.Error(t, result.err)
+
+		processed, err := proc.ProcessBatch(1)
+		require.NoError(t, err)
+		require.Equal(t, 1, processed)
+	})
+
+	t.Run("Reports Stuck Processes", func(t *testing.T) {
+		database := newMemoryDB(t, "a", "b")
+		proc := newMemoryProcessor(database, processor.Config{MaxAttempts: 1, StuckAfter: 10 * time.Millisecond}, leader.NewSoleElector())
+
+		process, err := proc.Start(processor.StartOptions{Name: "test", Transformer: "exclaim"})
+		require.NoError(t, err)
+
+		processed, err := proc.ProcessBatch(1)
+		require.NoError(t, err)
+		require.Equal(t, 1, processed)
+
+		status, err := proc.GetStatus(process.ID)
+		require.NoError(t, err)
+		require.NotNil(t, status.LastProgressAt)
+		require.False(t, status.Stuck)
+
+		time.Sleep(10 * time.Millisecond)
+
+		status, err = proc.GetStatus(process.ID)
+		require.NoError(t, err)
+		require.True(t, status.Stuck)
+
+		processed, err = proc.ProcessBatch(1)
+		require.NoError(t, err)
+		require.Equal(t, 1, processed)
+
+		status, err = proc.GetStatus(process.ID)
+		require.NoError(t, err)
+		require.False(t, status.Stuck)
+
+		// a paused process isn't stuck, nor is one resumed until it has had time to progress
+		_, err = proc.Pause(process.ID)
+		require.NoError(t, err)
+		time.Sleep(10 * time.Millisecond)
+
+		status, err = proc.GetStatus(process.ID)
+		require.NoError(t, err)
+		require.False(t, status.Stuck)
+
+		_, err = proc.Resume(process.ID)
+		require.NoError(t, err)
+
+		status, err = proc.GetStatus(process.ID)
+		require.NoError(t, err)
+		require.False(t, status.Stuck)
+	})
+}
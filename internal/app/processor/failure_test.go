@@ -0,0 +1,40 @@
+// +build unit
+
+package processor
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextFailure(t *testing.T) {
+	now := time.Date(2019, 1, 1, 12, 0, 0, 0, time.UTC)
+	p := &processor{
+		cfg: Config{
+			MaxAttempts:  4,
+			RetryBackoff: time.Second,
+		},
+	}
+
+	failure := models.ProcessElementError{}
+	for attempt, backoff := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		failure = p.nextFailure(failure, errors.New("test"), now)
+		require.Equal(t, attempt+1, failure.Attempts)
+		require.Equal(t, "test", failure.LastError)
+		require.False(t, failure.DeadLettered)
+		require.Equal(t, now.Add(backoff), *failure.NextAttemptAt)
+	}
+
+	failure = p.nextFailure(failure, errors.New("test"), now)
+	require.Equal(t, 4, failure.Attempts)
+	require.True(t, failure.DeadLettered)
+	require.Nil(t, failure.NextAttemptAt)
+
+	p.cfg.MaxAttempts = 100
+	failure = p.nextFailure(models.ProcessElementError{Attempts: 50}, errors.New("test"), now)
+	require.Equal(t, now.Add(maxRetryBackoff), *failure.NextAttemptAt)
+}
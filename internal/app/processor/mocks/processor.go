@@ -5,6 +5,9 @@
 package processor
 
 import (
+	context "context"
+	models "github.com/eggsbenjamin/square_enix/internal/app/models"
+	processor "github.com/eggsbenjamin/square_enix/internal/app/processor"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
 )
@@ -33,31 +36,303 @@ func (m *MockProcessor) EXPECT() *MockProcessorMockRecorder {
 }
 
 // Start mocks base method
-func (m *MockProcessor) Start() error {
+func (m *MockProcessor) Start(opts processor.StartOptions) (models.Process, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Start")
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "Start", opts)
+	ret0, _ := ret[0].(models.Process)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Start indicates an expected call of Start
-func (mr *MockProcessorMockRecorder) Start() *gomock.Call {
+func (mr *MockProcessorMockRecorder) Start(opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockProcessor)(nil).Start), opts)
+}
+
+// StartContext mocks base method
+func (m *MockProcessor) StartContext(ctx context.Context, opts processor.StartOptions) (models.Process, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartContext", ctx, opts)
+	ret0, _ := ret[0].(models.Process)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartContext indicates an expected call of StartContext
+func (mr *MockProcessorMockRecorder) StartContext(ctx, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartContext", reflect.TypeOf((*MockProcessor)(nil).StartContext), ctx, opts)
+}
+
+// Resume mocks base method
+func (m *MockProcessor) Resume(processID int) (models.Process, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resume", processID)
+	ret0, _ := ret[0].(models.Process)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Resume indicates an expected call of Resume
+func (mr *MockProcessorMockRecorder) Resume(processID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resume", reflect.TypeOf((*MockProcessor)(nil).Resume), processID)
+}
+
+// ResumeContext mocks base method
+func (m *MockProcessor) ResumeContext(ctx context.Context, processID int) (models.Process, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResumeContext", ctx, processID)
+	ret0, _ := ret[0].(models.Process)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResumeContext indicates an expected call of ResumeContext
+func (mr *MockProcessorMockRecorder) ResumeContext(ctx, processID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockProcessor)(nil).Start))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResumeContext", reflect.TypeOf((*MockProcessor)(nil).ResumeContext), ctx, processID)
 }
 
 // Pause mocks base method
-func (m *MockProcessor) Pause() error {
+func (m *MockProcessor) Pause(processID int) (models.Process, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Pause")
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "Pause", processID)
+	ret0, _ := ret[0].(models.Process)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Pause indicates an expected call of Pause
-func (mr *MockProcessorMockRecorder) Pause() *gomock.Call {
+func (mr *MockProcessorMockRecorder) Pause(processID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pause", reflect.TypeOf((*MockProcessor)(nil).Pause), processID)
+}
+
+// PauseContext mocks base method
+func (m *MockProcessor) PauseContext(ctx context.Context, processID int) (models.Process, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PauseContext", ctx, processID)
+	ret0, _ := ret[0].(models.Process)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PauseContext indicates an expected call of PauseContext
+func (mr *MockProcessorMockRecorder) PauseContext(ctx, processID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PauseContext", reflect.TypeOf((*MockProcessor)(nil).PauseContext), ctx, processID)
+}
+
+// Cancel mocks base method
+func (m *MockProcessor) Cancel(processID int) (models.Process, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Cancel", processID)
+	ret0, _ := ret[0].(models.Process)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Cancel indicates an expected call of Cancel
+func (mr *MockProcessorMockRecorder) Cancel(processID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockProcessor)(nil).Cancel), processID)
+}
+
+// CancelContext mocks base method
+func (m *MockProcessor) CancelContext(ctx context.Context, processID int) (models.Process, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelContext", ctx, processID)
+	ret0, _ := ret[0].(models.Process)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelContext indicates an expected call of CancelContext
+func (mr *MockProcessorMockRecorder) CancelContext(ctx, processID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelContext", reflect.TypeOf((*MockProcessor)(nil).CancelContext), ctx, processID)
+}
+
+// Restart mocks base method
+func (m *MockProcessor) Restart(processID int) (models.Process, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restart", processID)
+	ret0, _ := ret[0].(models.Process)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Restart indicates an expected call of Restart
+func (mr *MockProcessorMockRecorder) Restart(processID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restart", reflect.TypeOf((*MockProcessor)(nil).Restart), processID)
+}
+
+// RestartContext mocks base method
+func (m *MockProcessor) RestartContext(ctx context.Context, processID int) (models.Process, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestartContext", ctx, processID)
+	ret0, _ := ret[0].(models.Process)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestartContext indicates an expected call of RestartContext
+func (mr *MockProcessorMockRecorder) RestartContext(ctx, processID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestartContext", reflect.TypeOf((*MockProcessor)(nil).RestartContext), ctx, processID)
+}
+
+// Revert mocks base method
+func (m *MockProcessor) Revert(processID int) (models.Process, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revert", processID)
+	ret0, _ := ret[0].(models.Process)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Revert indicates an expected call of Revert
+func (mr *MockProcessorMockRecorder) Revert(processID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revert", reflect.TypeOf((*MockProcessor)(nil).Revert), processID)
+}
+
+// RevertContext mocks base method
+func (m *MockProcessor) RevertContext(ctx context.Context, processID int) (models.Process, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevertContext", ctx, processID)
+	ret0, _ := ret[0].(models.Process)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevertContext indicates an expected call of RevertContext
+func (mr *MockProcessorMockRecorder) RevertContext(ctx, processID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevertContext", reflect.TypeOf((*MockProcessor)(nil).RevertContext), ctx, processID)
+}
+
+// Preview mocks base method
+func (m *MockProcessor) Preview(opts processor.PreviewOptions) (models.ProcessPreview, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Preview", opts)
+	ret0, _ := ret[0].(models.ProcessPreview)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Preview indicates an expected call of Preview
+func (mr *MockProcessorMockRecorder) Preview(opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Preview", reflect.TypeOf((*MockProcessor)(nil).Preview), opts)
+}
+
+// PreviewContext mocks base method
+func (m *MockProcessor) PreviewContext(ctx context.Context, opts processor.PreviewOptions) (models.ProcessPreview, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PreviewContext", ctx, opts)
+	ret0, _ := ret[0].(models.ProcessPreview)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PreviewContext indicates an expected call of PreviewContext
+func (mr *MockProcessorMockRecorder) PreviewContext(ctx, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PreviewContext", reflect.TypeOf((*MockProcessor)(nil).PreviewContext), ctx, opts)
+}
+
+// GetHistory mocks base method
+func (m *MockProcessor) GetHistory(processID int) ([]models.ProcessTransition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHistory", processID)
+	ret0, _ := ret[0].([]models.ProcessTransition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHistory indicates an expected call of GetHistory
+func (mr *MockProcessorMockRecorder) GetHistory(processID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistory", reflect.TypeOf((*MockProcessor)(nil).GetHistory), processID)
+}
+
+// GetHistoryContext mocks base method
+func (m *MockProcessor) GetHistoryContext(ctx context.Context, processID int) ([]models.ProcessTransition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHistoryContext", ctx, processID)
+	ret0, _ := ret[0].([]models.ProcessTransition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHistoryContext indicates an expected call of GetHistoryContext
+func (mr *MockProcessorMockRecorder) GetHistoryContext(ctx, processID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistoryContext", reflect.TypeOf((*MockProcessor)(nil).GetHistoryContext), ctx, processID)
+}
+
+// GetElementHistory mocks base method
+func (m *MockProcessor) GetElementHistory(elementID int) ([]models.ProcessElement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetElementHistory", elementID)
+	ret0, _ := ret[0].([]models.ProcessElement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetElementHistory indicates an expected call of GetElementHistory
+func (mr *MockProcessorMockRecorder) GetElementHistory(elementID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetElementHistory", reflect.TypeOf((*MockProcessor)(nil).GetElementHistory), elementID)
+}
+
+// GetElementHistoryContext mocks base method
+func (m *MockProcessor) GetElementHistoryContext(ctx context.Context, elementID int) ([]models.ProcessElement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetElementHistoryContext", ctx, elementID)
+	ret0, _ := ret[0].([]models.ProcessElement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetElementHistoryContext indicates an expected call of GetElementHistoryContext
+func (mr *MockProcessorMockRecorder) GetElementHistoryContext(ctx, elementID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetElementHistoryContext", reflect.TypeOf((*MockProcessor)(nil).GetElementHistoryContext), ctx, elementID)
+}
+
+// GetProcesses mocks base method
+func (m *MockProcessor) GetProcesses() ([]models.Process, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProcesses")
+	ret0, _ := ret[0].([]models.Process)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProcesses indicates an expected call of GetProcesses
+func (mr *MockProcessorMockRecorder) GetProcesses() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pause", reflect.TypeOf((*MockProcessor)(nil).Pause))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProcesses", reflect.TypeOf((*MockProcessor)(nil).GetProcesses))
+}
+
+// GetProcessesContext mocks base method
+func (m *MockProcessor) GetProcessesContext(ctx context.Context) ([]models.Process, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProcessesContext", ctx)
+	ret0, _ := ret[0].([]models.Process)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProcessesContext indicates an expected call of GetProcessesContext
+func (mr *MockProcessorMockRecorder) GetProcessesContext(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProcessesContext", reflect.TypeOf((*MockProcessor)(nil).GetProcessesContext), ctx)
 }
 
 // RunningProcessExists mocks base method
@@ -75,12 +350,28 @@ func (mr *MockProcessorMockRecorder) RunningProcessExists() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunningProcessExists", reflect.TypeOf((*MockProcessor)(nil).RunningProcessExists))
 }
 
+// RunningProcessExistsContext mocks base method
+func (m *MockProcessor) RunningProcessExistsContext(ctx context.Context) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunningProcessExistsContext", ctx)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunningProcessExistsContext indicates an expected call of RunningProcessExistsContext
+func (mr *MockProcessorMockRecorder) RunningProcessExistsContext(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunningProcessExistsContext", reflect.TypeOf((*MockProcessor)(nil).RunningProcessExistsContext), ctx)
+}
+
 // ProcessBatch mocks base method
-func (m *MockProcessor) ProcessBatch(batchSize int) error {
+func (m *MockProcessor) ProcessBatch(batchSize int) (int, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ProcessBatch", batchSize)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // ProcessBatch indicates an expected call of ProcessBatch
@@ -89,17 +380,139 @@ func (mr *MockProcessorMockRecorder) ProcessBatch(batchSize interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessBatch", reflect.TypeOf((*MockProcessor)(nil).ProcessBatch), batchSize)
 }
 
-// GetLatestsStat mocks base method
-func (m *MockProcessor) GetLatestsStat() (int, error) {
+// ProcessBatchContext mocks base method
+func (m *MockProcessor) ProcessBatchContext(ctx context.Context, batchSize int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessBatchContext", ctx, batchSize)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProcessBatchContext indicates an expected call of ProcessBatchContext
+func (mr *MockProcessorMockRecorder) ProcessBatchContext(ctx, batchSize interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessBatchContext", reflect.TypeOf((*MockProcessor)(nil).ProcessBatchContext), ctx, batchSize)
+}
+
+// ReapClaims mocks base method
+func (m *MockProcessor) ReapClaims() (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReapClaims")
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReapClaims indicates an expected call of ReapClaims
+func (mr *MockProcessorMockRecorder) ReapClaims() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReapClaims", reflect.TypeOf((*MockProcessor)(nil).ReapClaims))
+}
+
+// ReapClaimsContext mocks base method
+func (m *MockProcessor) ReapClaimsContext(ctx context.Context) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetLatestsStat")
+	ret := m.ctrl.Call(m, "ReapClaimsContext", ctx)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetLatestsStat indicates an expected call of GetLatestsStat
-func (mr *MockProcessorMockRecorder) GetLatestsStat() *gomock.Call {
+// ReapClaimsContext indicates an expected call of ReapClaimsContext
+func (mr *MockProcessorMockRecorder) ReapClaimsContext(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReapClaimsContext", reflect.TypeOf((*MockProcessor)(nil).ReapClaimsContext), ctx)
+}
+
+// GetStatus mocks base method
+func (m *MockProcessor) GetStatus(processID int) (models.ProcessStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStatus", processID)
+	ret0, _ := ret[0].(models.ProcessStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStatus indicates an expected call of GetStatus
+func (mr *MockProcessorMockRecorder) GetStatus(processID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatus", reflect.TypeOf((*MockProcessor)(nil).GetStatus), processID)
+}
+
+// GetStatusContext mocks base method
+func (m *MockProcessor) GetStatusContext(ctx context.Context, processID int) (models.ProcessStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStatusContext", ctx, processID)
+	ret0, _ := ret[0].(models.ProcessStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStatusContext indicates an expected call of GetStatusContext
+func (mr *MockProcessorMockRecorder) GetStatusContext(ctx, processID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatusContext", reflect.TypeOf((*MockProcessor)(nil).GetStatusContext), ctx, processID)
+}
+
+// GetFailures mocks base method
+func (m *MockProcessor) GetFailures(processID int, deadLetteredOnly bool) ([]models.ProcessElementError, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFailures", processID, deadLetteredOnly)
+	ret0, _ := ret[0].([]models.ProcessElementError)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFailures indicates an expected call of GetFailures
+func (mr *MockProcessorMockRecorder) GetFailures(processID, deadLetteredOnly interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFailures", reflect.TypeOf((*MockProcessor)(nil).GetFailures), processID, deadLetteredOnly)
+}
+
+// GetFailuresContext mocks base method
+func (m *MockProcessor) GetFailuresContext(ctx context.Context, processID int, deadLetteredOnly bool) ([]models.ProcessElementError, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFailuresContext", ctx, processID, deadLetteredOnly)
+	ret0, _ := ret[0].([]models.ProcessElementError)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFailuresContext indicates an expected call of GetFailuresContext
+func (mr *MockProcessorMockRecorder) GetFailuresContext(ctx, processID, deadLetteredOnly interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFailuresContext", reflect.TypeOf((*MockProcessor)(nil).GetFailuresContext), ctx, processID, deadLetteredOnly)
+}
+
+// Redrive mocks base method
+func (m *MockProcessor) Redrive(processID int) (int, models.Process, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Redrive", processID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(models.Process)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Redrive indicates an expected call of Redrive
+func (mr *MockProcessorMockRecorder) Redrive(processID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Redrive", reflect.TypeOf((*MockProcessor)(nil).Redrive), processID)
+}
+
+// RedriveContext mocks base method
+func (m *MockProcessor) RedriveContext(ctx context.Context, processID int) (int, models.Process, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedriveContext", ctx, processID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(models.Process)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RedriveContext indicates an expected call of RedriveContext
+func (mr *MockProcessorMockRecorder) RedriveContext(ctx, processID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestsStat", reflect.TypeOf((*MockProcessor)(nil).GetLatestsStat))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedriveContext", reflect.TypeOf((*MockProcessor)(nil).RedriveContext), ctx, processID)
 }
@@ -3,98 +3,589 @@
 package processor
 
 import (
+	"context"
+	"fmt"
 	"log"
-	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
 	"github.com/pkg/errors"
 
 	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	"github.com/eggsbenjamin/square_enix/internal/app/leader"
+	"github.com/eggsbenjamin/square_enix/internal/app/metrics"
 	"github.com/eggsbenjamin/square_enix/internal/app/models"
 	"github.com/eggsbenjamin/square_enix/internal/app/repository"
+	"github.com/eggsbenjamin/square_enix/internal/app/transformer"
+)
+
+const (
+	// maxProcessNameLength is the length, in characters, of the Process.name VARCHAR column.
+	maxProcessNameLength = 100
+
+	// maxRetryBackoff caps the exponential backoff between attempts of a failing element.
+	maxRetryBackoff = time.Hour
+
+	defaultPreviewSampleSize = 10
+	maxPreviewSampleSize     = 100
+
+	defaultClaimTTL   = 5 * time.Minute
+	defaultStuckAfter = 10 * time.Minute
 )
 
 var (
 	ErrNoProcessExists        = errors.New("no process exists")
+	ErrNoElementExists        = errors.New("no element exists")
 	ErrNoRunningProcessExists = errors.New("no running process")
-	ErrRunningProcessExists   = errors.New("running process exists")
+	ErrProcessNameExists      = errors.New("active process with name exists")
+	ErrInvalidProcessName     = errors.New("invalid process name")
+	ErrInvalidTransformer     = errors.New("invalid transformer")
+	ErrInvalidSelector        = errors.New("invalid selector")
+	ErrConcurrentModification = errors.New("process modified concurrently")
+	ErrVersionMismatch        = errors.New("process not at given version")
+
+	// errProcessChanged is returned by lockProcess when the locked process's status differs from that read.
+	errProcessChanged = errors.New("process changed")
 )
 
+// StartOptions describes a new process: its unique name, the elements it selects and the
+// transformer it applies to them. An empty Transformer defaults to transformer.DEFAULT.
+type StartOptions struct {
+	Name              string
+	Selector          models.ElementSelector
+	Transformer       string
+	TransformerConfig string
+}
+
+// PreviewOptions describes a process to be previewed: the elements it would select and the transformer it
+// would apply to them, and how many of them to sample. SampleSize defaults to 10 and is capped at 100.
+type PreviewOptions struct {
+	Selector          models.ElementSelector
+	Transformer       string
+	TransformerConfig string
+	SampleSize        int
+}
+
+// Config controls how failing elements are retried, how long a batch's claims last and when a running process
+// with no progress is reported stuck. ClaimTTL defaults to 5 minutes and StuckAfter to 10.
+type Config struct {
+	MaxAttempts  int
+	RetryBackoff time.Duration
+	ClaimTTL     time.Duration
+	StuckAfter   time.Duration
+}
+
+type (
+	actorKey        struct{}
+	reasonKey       struct{}
+	versionKey      struct{}
+	batchStartedKey struct{}
+)
+
+// WithActor returns a copy of ctx that attributes the process transitions made with it to actor. Transitions
+// made without an actor, such as a worker completing a process, are attributed to "system".
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// WithReason returns a copy of ctx that records reason against the process transitions made with it, in
+// place of the processor's own description of the transition.
+func WithReason(ctx context.Context, reason string) context.Context {
+	return context.WithValue(ctx, reasonKey{}, reason)
+}
+
+// WithVersion returns a copy of ctx with which processes are only transitioned, restarted or redriven if they're
+// at version, failing with ErrVersionMismatch otherwise.
+func WithVersion(ctx context.Context, version int) context.Context {
+	return context.WithValue(ctx, versionKey{}, version)
+}
+
+// WithBatchStarted returns a copy of ctx with which ProcessBatch calls started with the process and the number
+// of elements of each batch it claims, before processing them.
+func WithBatchStarted(ctx context.Context, started func(processID, elements int)) context.Context {
+	return context.WithValue(ctx, batchStartedKey{}, started)
+}
+
+func batchStarted(ctx context.Context, processID, elements int) {
+	if started, ok := ctx.Value(batchStartedKey{}).(func(processID, elements int)); ok {
+		started(processID, elements)
+	}
+}
+
+func actorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+
+	return "system"
+}
+
+func reasonFromContext(ctx context.Context) string {
+	reason, _ := ctx.Value(reasonKey{}).(string)
+	return reason
+}
+
+// checkVersion returns ErrVersionMismatch if ctx was given a version other than process's.
+func checkVersion(ctx context.Context, process models.Process) error {
+	if version, ok := ctx.Value(versionKey{}).(int); ok && version != process.Version {
+		return ErrVersionMismatch
+	}
+
+	return nil
+}
+
+// Processor manages processes and processes their elements in batches. Each method has a Context
+// variant through which cancellation and deadlines propagate to the db.
 type Processor interface {
-	Start() error
-	Pause() error
+	Start(opts StartOptions) (models.Process, error)
+	StartContext(ctx context.Context, opts StartOptions) (models.Process, error)
+	Resume(processID int) (models.Process, error)
+	ResumeContext(ctx context.Context, processID int) (models.Process, error)
+	Pause(processID int) (models.Process, error)
+	PauseContext(ctx context.Context, processID int) (models.Process, error)
+	Cancel(processID int) (models.Process, error)
+	CancelContext(ctx context.Context, processID int) (models.Process, error)
+	Restart(processID int) (models.Process, error)
+	RestartContext(ctx context.Context, processID int) (models.Process, error)
+	Revert(processID int) (models.Process, error)
+	RevertContext(ctx context.Context, processID int) (models.Process, error)
+	Preview(opts PreviewOptions) (models.ProcessPreview, error)
+	PreviewContext(ctx context.Context, opts PreviewOptions) (models.ProcessPreview, error)
+	GetHistory(processID int) ([]models.ProcessTransition, error)
+	GetHistoryContext(ctx context.Context, processID int) ([]models.ProcessTransition, error)
+	GetElementHistory(elementID int) ([]models.ProcessElement, error)
+	GetElementHistoryContext(ctx context.Context, elementID int) ([]models.ProcessElement, error)
+	GetProcesses() ([]models.Process, error)
+	GetProcessesContext(ctx context.Context) ([]models.Process, error)
 	RunningProcessExists() (bool, error)
-	ProcessBatch(batchSize int) error
-	GetLatestsStat() (int, error)
+	RunningProcessExistsContext(ctx context.Context) (bool, error)
+	ProcessBatch(batchSize int) (int, error)
+	ProcessBatchContext(ctx context.Context, batchSize int) (int, error)
+	ReapClaims() (int, error)
+	ReapClaimsContext(ctx context.Context) (int, error)
+	GetStatus(processID int) (models.ProcessStatus, error)
+	GetStatusContext(ctx context.Context, processID int) (models.ProcessStatus, error)
+	GetFailures(processID int, deadLetteredOnly bool) ([]models.ProcessElementError, error)
+	GetFailuresContext(ctx context.Context, processID int, deadLetteredOnly bool) ([]models.ProcessElementError, error)
+	Redrive(processID int) (int, models.Process, error)
+	RedriveContext(ctx context.Context, processID int) (int, models.Process, error)
 }
 
 type processor struct {
-	db                 db.DB
-	processRepoFactory repository.ProcessRepositoryFactory
-	elementRepoFactory repository.ElementRepositoryFactory
+	db                      db.DB
+	processRepoFactory      repository.ProcessRepositoryFactory
+	elementRepoFactory      repository.ElementRepositoryFactory
+	elementErrorRepoFactory repository.ElementErrorRepositoryFactory
+	transitionRepoFactory   repository.TransitionRepositoryFactory
+	claimRepoFactory        repository.ClaimRepositoryFactory
+	transformers            transformer.Registry
+	cfg                     Config
+	recorder                metrics.Recorder
+	elector                 leader.Elector
+
+	mu              sync.Mutex
+	lastProcessedID int
 }
 
 func NewProcessor(
 	db db.DB,
 	processRepoFactory repository.ProcessRepositoryFactory,
 	elementRepoFactory repository.ElementRepositoryFactory,
+	elementErrorRepoFactory repository.ElementErrorRepositoryFactory,
+	transitionRepoFactory repository.TransitionRepositoryFactory,
+	claimRepoFactory repository.ClaimRepositoryFactory,
+	transformers transformer.Registry,
+	cfg Config,
+	recorder metrics.Recorder,
+	elector leader.Elector,
 ) Processor {
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 1
+	}
+
+	if cfg.ClaimTTL <= 0 {
+		cfg.ClaimTTL = defaultClaimTTL
+	}
+
+	if cfg.StuckAfter <= 0 {
+		cfg.StuckAfter = defaultStuckAfter
+	}
+
 	return &processor{
-		db:                 db,
-		processRepoFactory: processRepoFactory,
-		elementRepoFactory: elementRepoFactory,
+		db:                      db,
+		processRepoFactory:      processRepoFactory,
+		elementRepoFactory:      elementRepoFactory,
+		elementErrorRepoFactory: elementErrorRepoFactory,
+		transitionRepoFactory:   transitionRepoFactory,
+		claimRepoFactory:        claimRepoFactory,
+		transformers:            transformers,
+		cfg:                     cfg,
+		recorder:                recorder,
+		elector:                 elector,
+	}
+}
+
+func (p *processor) Start(opts StartOptions) (models.Process, error) {
+	return p.StartContext(context.Background(), opts)
+}
+
+// StartContext creates a new RUNNING process described by opts. Any number of processes may run concurrently
+// but names must be unique amongst RUNNING and PAUSED processes.
+func (p *processor) StartContext(ctx context.Context, opts StartOptions) (models.Process, error) {
+	if opts.Name == "" || utf8.RuneCountInString(opts.Name) > maxProcessNameLength {
+		return models.Process{}, ErrInvalidProcessName
+	}
+
+	if opts.Transformer == "" {
+		opts.Transformer = transformer.DEFAULT
+	}
+
+	if err := p.validate(opts.Transformer, opts.TransformerConfig, opts.Selector); err != nil {
+		return models.Process{}, err
+	}
+
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.Process{}, errors.Wrap(err, "error beginning transaction")
+	}
+
+	newProcess := models.Process{
+		Name:              opts.Name,
+		Selector:          opts.Selector,
+		Transformer:       opts.Transformer,
+		TransformerConfig: opts.TransformerConfig,
+	}
+
+	process, err := p.processRepoFactory.CreateProcessRepository(tx).CreateNewProcess(ctx, newProcess)
+	if err != nil {
+		p.rollback(tx)
+
+		if err == repository.ErrProcessNameExists {
+			return process, ErrProcessNameExists
+		}
+		return process, err
+	}
+
+	if err := p.recordTransition(ctx, tx, process.ID, "", models.PROCESS_STATUS_RUNNING, "created"); err != nil {
+		p.rollback(tx)
+
+		return models.Process{}, err
+	}
+
+	log.Printf("started process: %d (%s)\n", process.ID, process.Name)
+	return process, p.commit(tx)
+}
+
+// validate checks that a process's transformer can be created from its config and that its selector is valid.
+func (p *processor) validate(transformerName, transformerConfig string, selector models.ElementSelector) error {
+	if _, err := p.transformers.Create(transformerName, transformerConfig); err != nil {
+		return errors.Wrap(ErrInvalidTransformer, err.Error())
+	}
+
+	if err := selector.Validate(); err != nil {
+		return errors.Wrap(ErrInvalidSelector, err.Error())
+	}
+
+	return nil
+}
+
+func (p *processor) Preview(opts PreviewOptions) (models.ProcessPreview, error) {
+	return p.PreviewContext(context.Background(), opts)
+}
+
+// PreviewContext reports how many elements a process described by opts would operate on if it were started
+// now, and a sample of their data before and after being transformed. Nothing is written.
+func (p *processor) PreviewContext(ctx context.Context, opts PreviewOptions) (models.ProcessPreview, error) {
+	if opts.Transformer == "" {
+		opts.Transformer = transformer.DEFAULT
+	}
+
+	if opts.SampleSize <= 0 {
+		opts.SampleSize = defaultPreviewSampleSize
+	} else if opts.SampleSize > maxPreviewSampleSize {
+		opts.SampleSize = maxPreviewSampleSize
+	}
+
+	if err := p.validate(opts.Transformer, opts.TransformerConfig, opts.Selector); err != nil {
+		return models.ProcessPreview{}, err
+	}
+
+	// an unsaved process, it has no id so has processed no elements
+	process := models.Process{
+		Selector:          opts.Selector,
+		Transformer:       opts.Transformer,
+		TransformerConfig: opts.TransformerConfig,
+		CreatedAt:         time.Now().UTC(),
+	}
+
+	affected, err := p.elementRepoFactory.CreateElementRepository(p.db).CountElementsCreatedBefore(ctx, process.CreatedAt, process.Selector)
+	if err != nil {
+		return models.ProcessPreview{}, errors.Wrap(err, "error counting elements to be processed")
+	}
+
+	samples, err := p.dryRunBatch(ctx, process, opts.SampleSize)
+	if err != nil {
+		return models.ProcessPreview{}, err
 	}
+
+	return models.ProcessPreview{
+		Affected: affected,
+		Samples:  samples,
+	}, nil
+}
+
+func (p *processor) Resume(processID int) (models.Process, error) {
+	return p.ResumeContext(context.Background(), processID)
+}
+
+// ResumeContext sets a PAUSED process back to RUNNING. It keeps the transformer it was started with.
+func (p *processor) ResumeContext(ctx context.Context, processID int) (models.Process, error) {
+	log.Printf("resuming process: %d\n", processID)
+	return p.transitionProcess(ctx, processID, models.PROCESS_STATUS_RUNNING, "resumed")
+}
+
+func (p *processor) Pause(processID int) (models.Process, error) {
+	return p.PauseContext(context.Background(), processID)
 }
 
-func (p *processor) Start() error {
-	tx, err := p.db.Beginx()
+func (p *processor) PauseContext(ctx context.Context, processID int) (models.Process, error) {
+	log.Printf("pausing process: %d\n", processID)
+	return p.transitionProcess(ctx, processID, models.PROCESS_STATUS_PAUSED, "paused")
+}
+
+func (p *processor) Cancel(processID int) (models.Process, error) {
+	return p.CancelContext(context.Background(), processID)
+}
+
+// CancelContext permanently stops a RUNNING or PAUSED process. Elements it has already processed are left as
+// they are and it can't be resumed, but its name is free to be used by a new process.
+func (p *processor) CancelContext(ctx context.Context, processID int) (models.Process, error) {
+	log.Printf("cancelling process: %d\n", processID)
+	return p.transitionProcess(ctx, processID, models.PROCESS_STATUS_CANCELLED, "cancelled")
+}
+
+func (p *processor) Restart(processID int) (models.Process, error) {
+	return p.RestartContext(context.Background(), processID)
+}
+
+// RestartContext starts a fresh copy of a process, cancelling the existing one first if it's still running or
+// paused. A REVERTING process can't be restarted until its revert finishes.
+func (p *processor) RestartContext(ctx context.Context, processID int) (models.Process, error) {
+	tx, err := p.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return errors.Wrap(err, "error beginning transaction")
+		return models.Process{}, errors.Wrap(err, "error beginning transaction")
 	}
 
-	pausedProcesses, err := p.processRepoFactory.CreateProcessRepository(p.db).GetByStatus(models.PROCESS_STATUS_PAUSED)
+	processRepo := p.processRepoFactory.CreateProcessRepository(tx)
+
+	process, err := processRepo.GetByIDForUpdate(ctx, processID)
 	if err != nil {
-		return errors.Wrap(err, "error retreiving running processes")
+		p.rollback(tx)
+
+		if err == repository.ErrNoProcessExists {
+			return models.Process{}, ErrNoProcessExists
+		}
+		return models.Process{}, err
+	}
+
+	if err := checkVersion(ctx, process); err != nil {
+		p.rollback(tx)
+
+		return models.Process{}, err
 	}
 
-	if len(pausedProcesses) > 0 {
-		pausedProcess := pausedProcesses[0]
-		pausedProcess.Status = models.PROCESS_STATUS_RUNNING
+	if process.Status == models.PROCESS_STATUS_REVERTING {
+		p.rollback(tx)
 
-		log.Printf("resuming process: %d\n", pausedProcess.ID)
-		return p.processRepoFactory.CreateProcessRepository(p.db).UpdateProcess(pausedProcess)
+		return models.Process{}, &models.TransitionError{From: process.Status, To: models.PROCESS_STATUS_CANCELLED}
 	}
 
-	if _, err := p.processRepoFactory.CreateProcessRepository(tx).CreateNewProcess(); err != nil {
-		if err == repository.ErrRunningProcessExists {
-			return ErrRunningProcessExists
+	if process.Status.ReservesName() {
+		log.Printf("cancelling process: %d\n", process.ID)
+
+		if _, err := p.transition(ctx, tx, process, models.PROCESS_STATUS_CANCELLED, "restarted"); err != nil {
+			p.rollback(tx)
+
+			return models.Process{}, err
 		}
-		return err
 	}
 
-	return tx.Commit()
+	restarted, err := processRepo.CreateNewProcess(ctx, models.Process{
+		Name:              process.Name,
+		Selector:          process.Selector,
+		Transformer:       process.Transformer,
+		TransformerConfig: process.TransformerConfig,
+	})
+	if err != nil {
+		p.rollback(tx)
+
+		if err == repository.ErrProcessNameExists {
+			return models.Process{}, ErrProcessNameExists
+		}
+		return models.Process{}, err
+	}
+
+	if err := p.recordTransition(ctx, tx, restarted.ID, "", models.PROCESS_STATUS_RUNNING, fmt.Sprintf("restart of process %d", process.ID)); err != nil {
+		p.rollback(tx)
+
+		return models.Process{}, err
+	}
+
+	log.Printf("restarted process %d as: %d\n", process.ID, restarted.ID)
+	return restarted, p.commit(tx)
+}
+
+func (p *processor) Revert(processID int) (models.Process, error) {
+	return p.RevertContext(context.Background(), processID)
 }
 
-func (p *processor) Pause() error {
-	processRepo := p.processRepoFactory.CreateProcessRepository(p.db)
-	latestProcess, err := processRepo.GetLatestProcess()
+// RevertContext sets a COMPLETE or CANCELLED process to REVERTING. The batch workers then restore the data of
+// the elements it changed, unless they've since been changed by another process, and set it to REVERTED.
+func (p *processor) RevertContext(ctx context.Context, processID int) (models.Process, error) {
+	log.Printf("reverting process: %d\n", processID)
+	return p.transitionProcess(ctx, processID, models.PROCESS_STATUS_REVERTING, "revert requested")
+}
+
+func (p *processor) GetHistory(processID int) ([]models.ProcessTransition, error) {
+	return p.GetHistoryContext(context.Background(), processID)
+}
+
+// GetHistoryContext returns the audit history of a process's transitions, oldest first.
+func (p *processor) GetHistoryContext(ctx context.Context, processID int) ([]models.ProcessTransition, error) {
+	if _, err := p.processRepoFactory.CreateProcessRepository(p.db).GetByID(ctx, processID); err != nil {
+		if err == repository.ErrNoProcessExists {
+			return nil, ErrNoProcessExists
+		}
+		return nil, err
+	}
+
+	return p.transitionRepoFactory.CreateTransitionRepository(p.db).GetByProcessID(ctx, processID)
+}
+
+// transitionProcess locks a process and moves it to state to, returning it as moved.
+func (p *processor) transitionProcess(ctx context.Context, processID int, to models.ProcessState, reason string) (models.Process, error) {
+	tx, err := p.db.BeginTxx(ctx, nil)
 	if err != nil {
+		return models.Process{}, errors.Wrap(err, "error beginning transaction")
+	}
+
+	process, err := p.processRepoFactory.CreateProcessRepository(tx).GetByIDForUpdate(ctx, processID)
+	if err != nil {
+		p.rollback(tx)
+
 		if err == repository.ErrNoProcessExists {
-			return ErrNoProcessExists
+			return models.Process{}, ErrNoProcessExists
 		}
-		return err
+		return models.Process{}, err
+	}
+
+	if err := checkVersion(ctx, process); err != nil {
+		p.rollback(tx)
+
+		return models.Process{}, err
+	}
+
+	updated, err := p.transition(ctx, tx, process, to, reason)
+	if err != nil {
+		p.rollback(tx)
+
+		return models.Process{}, err
+	}
+
+	return updated, p.commit(tx)
+}
+
+// transition moves process to state to, persisting it and recording the move in its audit history. It returns
+// ErrConcurrentModification if the process has been updated since it was read.
+func (p *processor) transition(
+	ctx context.Context,
+	q db.Querier,
+	process models.Process,
+	to models.ProcessState,
+	reason string,
+) (models.Process, error) {
+	updated, err := process.Transition(to, time.Now().UTC())
+	if err != nil {
+		return process, err
 	}
 
-	if latestProcess.Status != models.PROCESS_STATUS_RUNNING {
-		return ErrNoRunningProcessExists
+	if err := p.processRepoFactory.CreateProcessRepository(q).UpdateProcess(ctx, updated); err != nil {
+		switch err {
+		case repository.ErrProcessNameExists:
+			return process, ErrProcessNameExists
+		case repository.ErrConcurrentModification:
+			return process, ErrConcurrentModification
+		}
+		return process, errors.Wrap(err, "error updating process")
 	}
 
-	latestProcess.Status = models.PROCESS_STATUS_PAUSED
+	if err := p.recordTransition(ctx, q, process.ID, process.Status, to, reason); err != nil {
+		return process, err
+	}
 
-	return processRepo.UpdateProcess(latestProcess)
+	updated.Version++
+	return updated, nil
+}
+
+// recordTransition appends a transition to a process's audit history. The actor is taken from ctx, as is the
+// reason if one was given, otherwise reason is used.
+func (p *processor) recordTransition(
+	ctx context.Context,
+	q db.Querier,
+	processID int,
+	from models.ProcessState,
+	to models.ProcessState,
+	reason string,
+) error {
+	if given := reasonFromContext(ctx); given != "" {
+		reason = given
+	}
+
+	err := p.transitionRepoFactory.CreateTransitionRepository(q).RecordTransition(ctx, models.ProcessTransition{
+		ProcessID: processID,
+		From:      from,
+		To:        to,
+		Actor:     actorFromContext(ctx),
+		Reason:    reason,
+	})
+	return errors.Wrap(err, "error recording process transition")
+}
+
+func (p *processor) GetElementHistory(elementID int) ([]models.ProcessElement, error) {
+	return p.GetElementHistoryContext(context.Background(), elementID)
+}
+
+// GetElementHistoryContext returns every change made to an element by a process, oldest first.
+func (p *processor) GetElementHistoryContext(ctx context.Context, elementID int) ([]models.ProcessElement, error) {
+	elementRepo := p.elementRepoFactory.CreateElementRepository(p.db)
+
+	if _, err := elementRepo.GetElementByID(ctx, elementID); err != nil {
+		if err == repository.ErrNoElementExists {
+			return nil, ErrNoElementExists
+		}
+		return nil, err
+	}
+
+	return elementRepo.GetHistoryByElementID(ctx, elementID)
+}
+
+func (p *processor) GetProcesses() ([]models.Process, error) {
+	return p.GetProcessesContext(context.Background())
+}
+
+func (p *processor) GetProcessesContext(ctx context.Context) ([]models.Process, error) {
+	return p.processRepoFactory.CreateProcessRepository(p.db).GetAll(ctx)
 }
 
 func (p *processor) RunningProcessExists() (bool, error) {
-	runningProcesses, err := p.processRepoFactory.CreateProcessRepository(p.db).GetByStatus(models.PROCESS_STATUS_RUNNING)
+	return p.RunningProcessExistsContext(context.Background())
+}
+
+// RunningProcessExistsContext reports whether any process has elements for the batch workers, i.e. is RUNNING
+// or REVERTING.
+func (p *processor) RunningProcessExistsContext(ctx context.Context) (bool, error) {
+	runningProcesses, err := p.processRepoFactory.CreateProcessRepository(p.db).GetByStatus(ctx, models.PROCESS_STATUS_RUNNING, models.PROCESS_STATUS_REVERTING)
 	if err != nil {
 		return false, errors.Wrap(err, "error retreiving running processes")
 	}
@@ -102,143 +593,799 @@ func (p *processor) RunningProcessExists() (bool, error) {
 	return len(runningProcesses) > 0, nil
 }
 
-func (p *processor) ProcessBatch(batchSize int) error {
-	// query db for running process
-	runningProcesses, err := p.processRepoFactory.CreateProcessRepository(p.db).GetByStatus(models.PROCESS_STATUS_RUNNING)
+// nextProcess picks the running process to receive the next batch, round robin by process id,
+// so that concurrently running processes share batches fairly.
+func (p *processor) nextProcess(runningProcesses []models.Process) models.Process {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	next := runningProcesses[0]
+	for _, process := range runningProcesses {
+		if process.ID > p.lastProcessedID {
+			next = process
+			break
+		}
+	}
+
+	p.lastProcessedID = next.ID
+	return next
+}
+
+func (p *processor) ProcessBatch(batchSize int) (int, error) {
+	return p.ProcessBatchContext(context.Background(), batchSize)
+}
+
+// ProcessBatchContext processes, or reverts, a batch of the next process's elements, returning the batch size.
+func (p *processor) ProcessBatchContext(ctx context.Context, batchSize int) (int, error) {
+	// query db for running and reverting processes
+	runningProcesses, err := p.processRepoFactory.CreateProcessRepository(p.db).GetByStatus(ctx, models.PROCESS_STATUS_RUNNING, models.PROCESS_STATUS_REVERTING)
 	if err != nil {
-		return errors.Wrap(err, "error retreiving running processes")
+		return 0, errors.Wrap(err, "error retreiving running processes")
 	}
 
 	if len(runningProcesses) == 0 {
-		return ErrNoRunningProcessExists
+		return 0, ErrNoRunningProcessExists
 	}
 
-	if len(runningProcesses) > 1 {
-		return errors.New("more thasn one process running. Aborting") // this should never happen but cover the edge case.
+	process := p.nextProcess(runningProcesses)
+
+	if process.Status == models.PROCESS_STATUS_REVERTING {
+		return p.revertBatch(ctx, process, batchSize)
 	}
 
-	process := runningProcesses[0]
+	elementTransformer, err := p.transformers.Create(process.Transformer, process.TransformerConfig)
+	if err != nil {
+		return 0, errors.Wrapf(err, "error creating transformer for process: %d", process.ID)
+	}
 
 	/*
 		start a transaction and perform all following steps using it
 			- any error should rollback the transaction
 	*/
 
-	tx, err := p.db.Beginx()
+	batchStart := time.Now()
+
+	tx, err := p.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return errors.Wrap(err, "error beginning transaction")
+		return 0, errors.Wrap(err, "error beginning transaction")
 	}
 
-	processRepo := p.processRepoFactory.CreateProcessRepository(tx)
 	elementRepo := p.elementRepoFactory.CreateElementRepository(tx)
+	elementErrorRepo := p.elementErrorRepoFactory.CreateElementErrorRepository(tx)
 
 	/*
 		query element table for elements <= batchSize that:
 			- have no entry in the ProcessElement table
 			- are unlocked
 			- were created on or before the created_at field of the current running process
+			- are matched by the process's selector
+			- are neither dead lettered nor waiting to be retried
 	*/
 
-	elementsToBeProcessed, err := elementRepo.LockElementsForUpdate(process.ID, batchSize)
+	lockStart := time.Now()
+	elementsToBeProcessed, err := elementRepo.LockElementsForUpdate(ctx, process, batchSize)
+	p.recorder.LockWait(time.Since(lockStart))
 	if err != nil {
-		if err := tx.Rollback(); err != nil {
-			log.Fatalf("error rolling back transacton: %q", err)
-		}
+		p.rollback(tx)
 
-		return errors.Wrap(err, "error locking elements")
+		return 0, errors.Wrap(err, "error locking elements")
 	}
 
 	if len(elementsToBeProcessed) == 0 {
 
 		/*
-			if no elements are found:
+			if no elements are found and this instance is the leader:
 			 - query the ProcessElement table for the number of elements that have been processed during the current running process
+			 - query the ProcessElementError table for the number of elements that have been dead lettered during the current running process
 			 - query the Element table for the total number of elements that:
 				- were created on or before the created_at field of the current running process
-			- if these numbers are equal then the current running process has no more elements to process
-				- if there are more elements to process they're currently locked and being processed by another instance so return nil here as no error has occurred
+				- are matched by the process's selector
+			- if the processed and dead lettered elements account for all of them then the current running process has no more elements to process
+				- if there are more elements to process they're currently locked and being processed by another instance, or waiting to be retried, so return nil here as no error has occurred
 				- if there are no more elements to process
-					- lock the process table
-					- update the current running process's status to COMPLETE and unlock the table
+					- lock the process's row, and check it's still running
+					- update the current running process's status to COMPLETE
+			the other instances leave the check to the leader
 		*/
 
-		processedElements, err := elementRepo.GetElementsByProcessID(process.ID)
+		if !p.elector.IsLeader() {
+			p.rollback(tx)
+			return 0, nil
+		}
+
+		processedElements, err := elementRepo.CountElementsByProcessID(ctx, process.ID)
 		if err != nil {
-			if err := tx.Rollback(); err != nil {
-				log.Fatalf("error rolling back transacton: %q", err)
-			}
+			p.rollback(tx)
 
-			return errors.Wrap(err, "error retreiving processed elements")
+			return 0, errors.Wrap(err, "error counting processed elements")
 		}
 
-		elementsCreatedBeforeProcess, err := elementRepo.GetElementsCreatedBefore(process.CreatedAt)
+		deadLetteredElements, err := elementErrorRepo.CountDeadLettered(ctx, process.ID)
 		if err != nil {
-			if err := tx.Rollback(); err != nil {
-				log.Fatalf("error rolling back transacton: %q", err)
-			}
+			p.rollback(tx)
 
-			return errors.Wrap(err, "error retreiving elements to be processed")
+			return 0, errors.Wrap(err, "error counting dead lettered elements")
 		}
 
-		if len(processedElements) < len(elementsCreatedBeforeProcess) {
-			return nil // another instance has locked rows
+		elementsCreatedBeforeProcess, err := elementRepo.CountElementsCreatedBefore(ctx, process.CreatedAt, process.Selector)
+		if err != nil {
+			p.rollback(tx)
+
+			return 0, errors.Wrap(err, "error counting elements to be processed")
 		}
 
-		process.Status = models.PROCESS_STATUS_COMPLETE
+		if processedElements+deadLetteredElements < elementsCreatedBeforeProcess {
+			// another instance has locked rows or elements are waiting to be retried
+			p.rollback(tx)
+			return 0, nil
+		}
 
-		log.Printf("completing proces: %d\n", process.ID)
-		if err := processRepo.UpdateProcess(process); err != nil {
-			if err := tx.Rollback(); err != nil {
-				log.Fatalf("error rolling back transacton: %q", err)
+		if process, err = p.lockProcess(ctx, tx, process); err != nil {
+			p.rollback(tx)
+
+			if err == errProcessChanged {
+				return 0, nil
 			}
+			return 0, err
+		}
+
+		log.Printf("completing proces: %d\n", process.ID)
+		if _, err := p.transition(ctx, tx, process, models.PROCESS_STATUS_COMPLETE, "all elements processed or dead lettered"); err != nil {
+			p.rollback(tx)
 
-			return errors.Wrap(err, "error completing process")
+			return 0, errors.Wrap(err, "error completing process")
 		}
 
-		return tx.Commit()
+		return 0, p.commit(tx)
 	}
 
 	/*
 		if elements are found:
-		- process all of the elements - convert data field to uppercase
-		- persist all of the updated elements
+		- process each element within a savepoint - apply the process's transformer to the data field and persist it
+			- if processing fails roll back to the savepoint and record the failure against the element
+				- the element is retried after a backoff until it has been attempted the maximum number of times, then it's dead lettered
+			- if processing succeeds clear any failures previously recorded against the element
 		- commit the transaction
-		- return nil
+		- return the number of elements in the batch
 	*/
 
 	log.Printf("processing %d elements as part of process: %d\n", len(elementsToBeProcessed), process.ID)
+	batchStarted(ctx, process.ID, len(elementsToBeProcessed))
+
+	elementIDs := make([]int, 0, len(elementsToBeProcessed))
+	for _, element := range elementsToBeProcessed {
+		elementIDs = append(elementIDs, element.ID)
+	}
+
+	release, err := p.claimElements(ctx, tx, process.ID, elementIDs)
+	if err != nil {
+		p.rollback(tx)
+
+		return 0, err
+	}
+	defer release()
+
+	failures, err := elementErrorRepo.GetFailuresByElementIDs(ctx, process.ID, elementIDs)
+	if err != nil {
+		p.rollback(tx)
+
+		return 0, errors.Wrap(err, "error retreiving element failures")
+	}
+
+	// results counts the elements in the batch by outcome, recorded once the batch is committed
+	results := map[string]int{}
 
 	for _, element := range elementsToBeProcessed {
-		element.Data = strings.ToUpper(element.Data)
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT process_element"); err != nil {
+			p.rollback(tx)
 
-		if err := elementRepo.UpdateElementForProcess(element, process.ID); err != nil {
-			if err := tx.Rollback(); err != nil {
-				log.Fatalf("error rolling back transacton: %q", err)
+			return 0, errors.Wrap(err, "error creating savepoint")
+		}
+
+		failure, failedBefore := failures[element.ID]
+
+		if elementErr := processElement(ctx, elementRepo, elementTransformer, element, process.ID); elementErr != nil {
+			if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT process_element"); err != nil {
+				p.rollback(tx)
+
+				return 0, errors.Wrap(err, "error rolling back to savepoint")
 			}
 
-			return errors.Wrap(err, "error updating element")
+			failure = p.nextFailure(failure, elementErr, time.Now().UTC())
+			failure.ProcessID = process.ID
+			failure.ElementID = element.ID
+
+			if failure.DeadLettered {
+				results[metrics.RESULT_DEAD_LETTERED]++
+				log.Printf("dead lettering element %d of process %d after %d attempts: %q\n", element.ID, process.ID, failure.Attempts, elementErr)
+			} else {
+				results[metrics.RESULT_FAILED]++
+				log.Printf("element %d of process %d failed, attempt %d: %q\n", element.ID, process.ID, failure.Attempts, elementErr)
+			}
+
+			if err := elementErrorRepo.SaveFailure(ctx, failure); err != nil {
+				p.rollback(tx)
+
+				return 0, errors.Wrap(err, "error recording element failure")
+			}
+
+			continue
+		}
+
+		results[metrics.RESULT_PROCESSED]++
+
+		if failedBefore {
+			if err := elementErrorRepo.DeleteFailure(ctx, process.ID, element.ID); err != nil {
+				p.rollback(tx)
+
+				return 0, errors.Wrap(err, "error clearing element failure")
+			}
 		}
 	}
 
 	// commit the transaction
 
-	return tx.Commit()
+	if err := p.commit(tx); err != nil {
+		return 0, errors.Wrap(err, "error committing transaction")
+	}
+
+	for result, count := range results {
+		p.recorder.ElementsProcessed(process.ID, result, count)
+	}
+	p.recorder.Batch(len(elementsToBeProcessed), time.Since(batchStart))
+
+	return len(elementsToBeProcessed), nil
 }
 
-func (p *processor) GetLatestsStat() (int, error) {
-	latestProcess, err := p.processRepoFactory.CreateProcessRepository(p.db).GetLatestProcess()
+// claimElements claims, outside tx, the elements it locked and renews the claims until the returned func
+// releases them.
+func (p *processor) claimElements(ctx context.Context, tx db.Tx, processID int, elementIDs []int) (func(), error) {
+	sessionID, err := p.claimRepoFactory.CreateClaimRepository(tx).SessionID(ctx)
 	if err != nil {
-		if err == repository.ErrNoProcessExists {
-			return 0, ErrNoProcessExists
+		return nil, errors.Wrap(err, "error retreiving session id")
+	}
+
+	if sessionID == 0 {
+		return func() {}, nil
+	}
+
+	claimRepo := p.claimRepoFactory.CreateClaimRepository(p.db)
+
+	if err := claimRepo.ClaimElements(ctx, sessionID, processID, elementIDs, p.cfg.ClaimTTL); err != nil {
+		return nil, errors.Wrap(err, "error claiming elements")
+	}
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(p.cfg.ClaimTTL / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if err := claimRepo.RenewClaims(ctx, sessionID, elementIDs, p.cfg.ClaimTTL); err != nil && ctx.Err() == nil {
+				log.Printf("error renewing claims of process %d: %q\n", processID, err)
+			}
 		}
+	}()
 
-		return 0, err
+	return func() {
+		close(stop)
+		<-stopped
+
+		// claims left behind expire, and are reaped without killing the session as the elements are unlocked
+		if err := claimRepo.ReleaseClaims(ctx, sessionID, elementIDs); err != nil && ctx.Err() == nil {
+			log.Printf("error releasing claims of process %d: %q\n", processID, err)
+		}
+	}, nil
+}
+
+func (p *processor) ReapClaims() (int, error) {
+	return p.ReapClaimsContext(context.Background())
+}
+
+// ReapClaimsContext releases expired claims, returning how many were reaped.
+func (p *processor) ReapClaimsContext(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+
+	expired, err := p.claimRepoFactory.CreateClaimRepository(p.db).GetExpired(ctx, now)
+	if err != nil {
+		return 0, errors.Wrap(err, "error retreiving expired claims")
+	}
+
+	reaped := 0
+	for len(expired) > 0 {
+		// the claims are ordered by session
+		sessionID := expired[0].SessionID
+		elementIDs := []int{}
+		for len(expired) > 0 && expired[0].SessionID == sessionID {
+			elementIDs = append(elementIDs, expired[0].ElementID)
+			expired = expired[1:]
+		}
+
+		released, err := p.reapSession(ctx, sessionID, elementIDs, now)
+		if err != nil {
+			return reaped, err
+		}
+		reaped += released
+	}
+
+	return reaped, nil
+}
+
+// reapSession releases a session's expired claims, killing it if it still holds the elements and none of the
+// claims has since been renewed or released.
+func (p *processor) reapSession(ctx context.Context, sessionID int64, elementIDs []int, now time.Time) (int, error) {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "error beginning transaction")
+	}
+
+	unlocked, err := p.claimRepoFactory.CreateClaimRepository(tx).LockElements(ctx, elementIDs)
+	p.rollback(tx)
+	if err != nil {
+		return 0, errors.Wrap(err, "error locking claimed elements")
+	}
+
+	claimRepo := p.claimRepoFactory.CreateClaimRepository(p.db)
+
+	released, err := claimRepo.ReleaseExpired(ctx, sessionID, elementIDs, now)
+	if err != nil {
+		return 0, errors.Wrap(err, "error releasing expired claims")
+	}
+
+	if held := len(elementIDs) - len(unlocked); held > 0 && released == len(elementIDs) {
+		log.Printf("killing session %d, its claims of %d elements expired with them locked\n", sessionID, held)
+
+		if err := claimRepo.KillSession(ctx, sessionID); err != nil {
+			return released, errors.Wrapf(err, "error killing session: %d", sessionID)
+		}
+	}
+
+	return released, nil
+}
+
+// lockProcess locks a process's row, returning errProcessChanged if its status has changed since it was read.
+func (p *processor) lockProcess(ctx context.Context, tx db.Tx, process models.Process) (models.Process, error) {
+	locked, err := p.processRepoFactory.CreateProcessRepository(tx).GetByIDForUpdate(ctx, process.ID)
+	if err != nil {
+		return process, errors.Wrap(err, "error locking process")
+	}
+
+	if locked.Status != process.Status {
+		return process, errProcessChanged
+	}
+
+	return locked, nil
+}
+
+// commit commits tx, recording the outcome. A failed commit is recorded as a rollback.
+func (p *processor) commit(tx db.Tx) error {
+	if err := tx.Commit(); err != nil {
+		p.recorder.Transaction(metrics.TX_ROLLBACK)
+		return err
+	}
+
+	p.recorder.Transaction(metrics.TX_COMMIT)
+	return nil
+}
+
+// rollback rolls back tx, recording the outcome.
+func (p *processor) rollback(tx db.Tx) {
+	db.Rollback(tx)
+	p.recorder.Transaction(metrics.TX_ROLLBACK)
+}
+
+// revertBatch restores the previous data of a batch of the elements a REVERTING process changed, locking them
+// the same way ProcessBatch does. Once every change has been reverted the leader sets the process to REVERTED.
+func (p *processor) revertBatch(ctx context.Context, process models.Process, batchSize int) (int, error) {
+	batchStart := time.Now()
+
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "error beginning transaction")
+	}
+
+	elementRepo := p.elementRepoFactory.CreateElementRepository(tx)
+
+	lockStart := time.Now()
+	processElements, err := elementRepo.LockElementsForRevert(ctx, process.ID, batchSize)
+	p.recorder.LockWait(time.Since(lockStart))
+	if err != nil {
+		p.rollback(tx)
+
+		return 0, errors.Wrap(err, "error locking elements")
+	}
+
+	if len(processElements) == 0 {
+		if !p.elector.IsLeader() {
+			p.rollback(tx)
+			return 0, nil
+		}
+
+		unreverted, err := elementRepo.CountUnrevertedByProcessID(ctx, process.ID)
+		if err != nil {
+			p.rollback(tx)
+
+			return 0, errors.Wrap(err, "error counting unreverted elements")
+		}
+
+		if unreverted > 0 {
+			// another instance has locked rows
+			p.rollback(tx)
+			return 0, nil
+		}
+
+		if process, err = p.lockProcess(ctx, tx, process); err != nil {
+			p.rollback(tx)
+
+			if err == errProcessChanged {
+				return 0, nil
+			}
+			return 0, err
+		}
+
+		log.Printf("reverted process: %d\n", process.ID)
+		if _, err := p.transition(ctx, tx, process, models.PROCESS_STATUS_REVERTED, "all elements reverted"); err != nil {
+			p.rollback(tx)
+
+			return 0, errors.Wrap(err, "error completing revert of process")
+		}
+
+		return 0, p.commit(tx)
+	}
+
+	log.Printf("reverting %d elements as part of process: %d\n", len(processElements), process.ID)
+	batchStarted(ctx, process.ID, len(processElements))
+
+	elementIDs := make([]int, 0, len(processElements))
+	for _, processElement := range processElements {
+		elementIDs = append(elementIDs, processElement.ElementID)
 	}
 
-	processElements, err := p.elementRepoFactory.CreateElementRepository(p.db).GetElementsByProcessID(latestProcess.ID)
+	release, err := p.claimElements(ctx, tx, process.ID, elementIDs)
 	if err != nil {
+		p.rollback(tx)
+
 		return 0, err
 	}
+	defer release()
+
+	for _, processElement := range processElements {
+		if err := elementRepo.RevertElementForProcess(ctx, processElement); err != nil {
+			p.rollback(tx)
+
+			return 0, errors.Wrapf(err, "error reverting element: %d", processElement.ElementID)
+		}
+	}
+
+	if err := p.commit(tx); err != nil {
+		return 0, errors.Wrap(err, "error committing transaction")
+	}
+
+	p.recorder.ElementsProcessed(process.ID, metrics.RESULT_REVERTED, len(processElements))
+	p.recorder.Batch(len(processElements), time.Since(batchStart))
 
 	return len(processElements), nil
 }
+
+// dryRunBatch transforms a batch of unlocked elements in a transaction that's always rolled back.
+func (p *processor) dryRunBatch(ctx context.Context, process models.Process, batchSize int) ([]models.PreviewSample, error) {
+	elementTransformer, err := p.transformers.Create(process.Transformer, process.TransformerConfig)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidTransformer, err.Error())
+	}
+
+	// samples are drawn from the elements the process would operate on, those created before it
+	selector := process.Selector
+	if selector.CreatedBefore == nil || process.CreatedAt.Before(*selector.CreatedBefore) {
+		selector.CreatedBefore = &process.CreatedAt
+	}
+
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "error beginning transaction")
+	}
+	// always rolled back, so not recorded as a rolled back transaction
+	defer db.Rollback(tx)
+
+	elementRepo := p.elementRepoFactory.CreateElementRepository(tx)
+
+	elements, err := elementRepo.ListElements(ctx, 0, batchSize, selector)
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing elements")
+	}
+
+	samples := make([]models.PreviewSample, 0, len(elements))
+	for _, element := range elements {
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT process_element"); err != nil {
+			return nil, errors.Wrap(err, "error creating savepoint")
+		}
+
+		sample := models.PreviewSample{
+			ElementID: element.ID,
+			Before:    element.Data,
+		}
+
+		data, elementErr := elementTransformer.Transform(element.Data)
+		if elementErr != nil {
+			elementErr = errors.Wrap(elementErr, "error transforming element")
+		} else {
+			element.Data = data
+			elementErr = errors.Wrap(elementRepo.UpdateElement(ctx, element), "error updating element")
+		}
+
+		if elementErr != nil {
+			if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT process_element"); err != nil {
+				return nil, errors.Wrap(err, "error rolling back to savepoint")
+			}
+
+			sample.Error = elementErr.Error()
+		} else {
+			sample.After = data
+		}
+
+		samples = append(samples, sample)
+	}
+
+	return samples, nil
+}
+
+// processElement transforms and persists a single element.
+func processElement(
+	ctx context.Context,
+	elementRepo repository.ElementRepository,
+	elementTransformer transformer.Transformer,
+	element models.Element,
+	processID int,
+) error {
+	data, err := elementTransformer.Transform(element.Data)
+	if err != nil {
+		return errors.Wrap(err, "error transforming element")
+	}
+
+	previousData := element.Data
+	element.Data = data
+
+	return errors.Wrap(elementRepo.UpdateElementForProcess(ctx, element, previousData, processID), "error updating element")
+}
+
+// nextFailure records another failed attempt, scheduling a retry with exponential backoff or
+// dead lettering the element once it has been attempted cfg.MaxAttempts times.
+func (p *processor) nextFailure(failure models.ProcessElementError, err error, now time.Time) models.ProcessElementError {
+	failure.Attempts++
+	failure.LastError = err.Error()
+	failure.NextAttemptAt = nil
+
+	if failure.Attempts >= p.cfg.MaxAttempts {
+		failure.DeadLettered = true
+		return failure
+	}
+
+	backoff := p.cfg.RetryBackoff
+	for i := 1; i < failure.Attempts && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+
+	nextAttemptAt := now.Add(backoff)
+	failure.NextAttemptAt = &nextAttemptAt
+
+	return failure
+}
+
+func (p *processor) GetStatus(processID int) (models.ProcessStatus, error) {
+	return p.GetStatusContext(context.Background(), processID)
+}
+
+// GetStatusContext reports the progress of a process, and whether it's stuck.
+func (p *processor) GetStatusContext(ctx context.Context, processID int) (models.ProcessStatus, error) {
+	process, err := p.processRepoFactory.CreateProcessRepository(p.db).GetByID(ctx, processID)
+	if err != nil {
+		if err == repository.ErrNoProcessExists {
+			return models.ProcessStatus{}, ErrNoProcessExists
+		}
+
+		return models.ProcessStatus{}, err
+	}
+
+	elementRepo := p.elementRepoFactory.CreateElementRepository(p.db)
+
+	processed, err := elementRepo.CountElementsByProcessID(ctx, process.ID)
+	if err != nil {
+		return models.ProcessStatus{}, errors.Wrap(err, "error counting processed elements")
+	}
+
+	deadLettered, err := p.elementErrorRepoFactory.CreateElementErrorRepository(p.db).CountDeadLettered(ctx, process.ID)
+	if err != nil {
+		return models.ProcessStatus{}, errors.Wrap(err, "error counting dead lettered elements")
+	}
+
+	eligible, err := elementRepo.CountElementsCreatedBefore(ctx, process.CreatedAt, process.Selector)
+	if err != nil {
+		return models.ProcessStatus{}, errors.Wrap(err, "error counting elements to be processed")
+	}
+
+	now := time.Now().UTC()
+	status := newProcessStatus(process, eligible, processed, deadLettered, now)
+
+	lastProgressAt, err := elementRepo.GetLastProgressAt(ctx, process.ID)
+	if err != nil {
+		return models.ProcessStatus{}, errors.Wrap(err, "error retreiving last progress")
+	}
+
+	// a process hasn't progressed since it was last started, resumed or redriven
+	transitions, err := p.transitionRepoFactory.CreateTransitionRepository(p.db).GetByProcessID(ctx, process.ID)
+	if err != nil {
+		return models.ProcessStatus{}, errors.Wrap(err, "error retreiving process transitions")
+	}
+
+	if len(transitions) > 0 {
+		if transitionedAt := transitions[len(transitions)-1].CreatedAt; lastProgressAt == nil || transitionedAt.After(*lastProgressAt) {
+			lastProgressAt = &transitionedAt
+		}
+	}
+
+	status.LastProgressAt = lastProgressAt
+	status.Stuck = stalled(status, p.cfg.StuckAfter, now)
+
+	if status.Stuck && status.Status == models.PROCESS_STATUS_RUNNING {
+		waiting, err := p.elementErrorRepoFactory.CreateElementErrorRepository(p.db).CountWaiting(ctx, process.ID, now)
+		if err != nil {
+			return models.ProcessStatus{}, errors.Wrap(err, "error counting elements waiting to be retried")
+		}
+
+		status.Stuck = status.Remaining > waiting
+	}
+
+	return status, nil
+}
+
+// stalled reports whether a RUNNING process with elements remaining, or a REVERTING process, has made no
+// progress for stuckAfter.
+func stalled(status models.ProcessStatus, stuckAfter time.Duration, now time.Time) bool {
+	active := (status.Status == models.PROCESS_STATUS_RUNNING && status.Remaining > 0) ||
+		status.Status == models.PROCESS_STATUS_REVERTING
+
+	return active && status.LastProgressAt != nil && now.Sub(*status.LastProgressAt) >= stuckAfter
+}
+
+func newProcessStatus(process models.Process, eligible, processed, deadLettered int, now time.Time) models.ProcessStatus {
+	// a selector matching on data may no longer match elements once they're transformed
+	if eligible < processed+deadLettered {
+		eligible = processed + deadLettered
+	}
+
+	status := models.ProcessStatus{
+		ProcessID:     process.ID,
+		Name:          process.Name,
+		Status:        process.Status,
+		CreatedAt:     process.CreatedAt,
+		StartedAt:     process.StartedAt,
+		PausedAt:      process.PausedAt,
+		CompletedAt:   process.CompletedAt,
+		CancelledAt:   process.CancelledAt,
+		Version:       process.Version,
+		TotalEligible: eligible,
+		Processed:     processed,
+		DeadLettered:  deadLettered,
+	}
+
+	if remaining := eligible - processed - deadLettered; remaining > 0 {
+		status.Remaining = remaining
+	}
+
+	if eligible > 0 {
+		status.PercentComplete = float64(processed) / float64(eligible) * 100
+	} else if process.Status == models.PROCESS_STATUS_COMPLETE {
+		status.PercentComplete = 100
+	}
+
+	if process.StartedAt == nil {
+		return status
+	}
+
+	end := now
+	if process.CompletedAt != nil {
+		end = *process.CompletedAt
+	} else if process.CancelledAt != nil {
+		end = *process.CancelledAt
+	} else if process.PausedAt != nil {
+		end = *process.PausedAt
+	}
+
+	running := end.Sub(*process.StartedAt) - time.Duration(process.PausedSeconds)*time.Second
+	if running <= 0 {
+		return status
+	}
+
+	status.Throughput = float64(processed) / running.Seconds()
+
+	if status.Remaining > 0 && status.Throughput > 0 && process.Status == models.PROCESS_STATUS_RUNNING {
+		etaSeconds := float64(status.Remaining) / status.Throughput
+		eta := now.Add(time.Duration(etaSeconds * float64(time.Second)))
+
+		status.ETASeconds = &etaSeconds
+		status.ETA = &eta
+	}
+
+	return status
+}
+
+func (p *processor) GetFailures(processID int, deadLetteredOnly bool) ([]models.ProcessElementError, error) {
+	return p.GetFailuresContext(context.Background(), processID, deadLetteredOnly)
+}
+
+func (p *processor) GetFailuresContext(ctx context.Context, processID int, deadLetteredOnly bool) ([]models.ProcessElementError, error) {
+	if _, err := p.processRepoFactory.CreateProcessRepository(p.db).GetByID(ctx, processID); err != nil {
+		if err == repository.ErrNoProcessExists {
+			return nil, ErrNoProcessExists
+		}
+
+		return nil, err
+	}
+
+	return p.elementErrorRepoFactory.CreateElementErrorRepository(p.db).GetFailuresByProcessID(ctx, processID, deadLetteredOnly)
+}
+
+func (p *processor) Redrive(processID int) (int, models.Process, error) {
+	return p.RedriveContext(context.Background(), processID)
+}
+
+// RedriveContext makes a process's dead lettered elements eligible for processing again, returning how many
+// were redriven and the process. A process that completed with dead lettered elements is set back to RUNNING.
+func (p *processor) RedriveContext(ctx context.Context, processID int) (int, models.Process, error) {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, models.Process{}, errors.Wrap(err, "error beginning transaction")
+	}
+
+	processRepo := p.processRepoFactory.CreateProcessRepository(tx)
+
+	process, err := processRepo.GetByIDForUpdate(ctx, processID)
+	if err != nil {
+		p.rollback(tx)
+
+		if err == repository.ErrNoProcessExists {
+			return 0, models.Process{}, ErrNoProcessExists
+		}
+		return 0, models.Process{}, err
+	}
+
+	if err := checkVersion(ctx, process); err != nil {
+		p.rollback(tx)
+
+		return 0, models.Process{}, err
+	}
+
+	redriven, err := p.elementErrorRepoFactory.CreateElementErrorRepository(tx).RedriveDeadLettered(ctx, processID)
+	if err != nil {
+		p.rollback(tx)
+
+		return 0, models.Process{}, errors.Wrap(err, "error redriving dead lettered elements")
+	}
+
+	if redriven > 0 && process.Status == models.PROCESS_STATUS_COMPLETE {
+		log.Printf("reopening process: %d\n", process.ID)
+
+		reason := fmt.Sprintf("%d dead lettered elements redriven", redriven)
+		if process, err = p.transition(ctx, tx, process, models.PROCESS_STATUS_RUNNING, reason); err != nil {
+			p.rollback(tx)
+
+			return 0, models.Process{}, errors.Wrap(err, "error reopening process")
+		}
+	}
+
+	return redriven, process, p.commit(tx)
+}
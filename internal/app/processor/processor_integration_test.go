@@ -3,29 +3,30 @@
 package processor_test
 
 import (
-	"fmt"
+	"context"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	"github.com/eggsbenjamin/square_enix/internal/app/db/dbtest"
+	"github.com/eggsbenjamin/square_enix/internal/app/leader"
+	"github.com/eggsbenjamin/square_enix/internal/app/metrics"
+	mock_metrics "github.com/eggsbenjamin/square_enix/internal/app/metrics/mocks"
 	"github.com/eggsbenjamin/square_enix/internal/app/models"
 	"github.com/eggsbenjamin/square_enix/internal/app/processor"
 	"github.com/eggsbenjamin/square_enix/internal/app/repository"
-	"github.com/eggsbenjamin/square_enix/pkg/env"
-	"github.com/jmoiron/sqlx"
+	"github.com/eggsbenjamin/square_enix/internal/app/transformer"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 )
 
 func TestProcessor(t *testing.T) {
-	dsn := fmt.Sprintf(
-		"%s@tcp(%s:3306)/%s?parseTime=true",
-		env.MustGetEnv("MYSQL_USER"),
-		env.MustGetEnv("MYSQL_HOST"),
-		env.MustGetEnv("MYSQL_DB"),
-	)
-	conn, err := sqlx.Connect("mysql", dsn)
+	conn, err := dbtest.Connect()
 	require.NoError(t, err)
 
-	db := db.NewDB(conn)
+	db := db.NewDB(conn.DB)
 
 	t.Run("ProcessBatch", func(t *testing.T) {
 		t.Run("No Running Process", func(t *testing.T) {
@@ -37,16 +38,24 @@ func TestProcessor(t *testing.T) {
 
 			require.NoError(t, ResetDB(conn))
 
-			_, err = conn.Exec("INSERT INTO Process (status) VALUES ('COMPLETE')")
+			_, err = conn.Exec("INSERT INTO Process (name, status) VALUES ('test', 'COMPLETE')")
 			require.NoError(t, err)
 
 			proc := processor.NewProcessor(
 				db,
 				repository.NewProcessRepositoryFactory(),
 				repository.NewElementRepositoryFactory(),
+				repository.NewElementErrorRepositoryFactory(),
+				repository.NewTransitionRepositoryFactory(),
+				repository.NewClaimRepositoryFactory(),
+				transformer.NewDefaultRegistry(),
+				processor.Config{MaxAttempts: 1},
+				metrics.NewNopRecorder(),
+				leader.NewSoleElector(),
 			)
 
-			require.Equal(t, processor.ErrNoRunningProcessExists, proc.ProcessBatch(1))
+			_, err = proc.ProcessBatch(1)
+			require.Equal(t, processor.ErrNoRunningProcessExists, err)
 		})
 
 		t.Run("Elements to Process", func(t *testing.T) {
@@ -64,27 +73,279 @@ func TestProcessor(t *testing.T) {
 			_, err = conn.Exec("INSERT INTO Element (id, data) VALUES (2, 'test')")
 			require.NoError(t, err)
 
-			_, err = conn.Exec("INSERT INTO Process (id, status, created_at) VALUES (1, 'RUNNING', NOW() + INTERVAL 1 DAY)")
+			_, err = conn.Exec("INSERT INTO Process (id, name, status, created_at) VALUES (1, 'test', 'RUNNING', ?)", time.Now().Add(24*time.Hour))
 			require.NoError(t, err)
 
 			proc := processor.NewProcessor(
 				db,
 				repository.NewProcessRepositoryFactory(),
 				repository.NewElementRepositoryFactory(),
+				repository.NewElementErrorRepositoryFactory(),
+				repository.NewTransitionRepositoryFactory(),
+				repository.NewClaimRepositoryFactory(),
+				transformer.NewDefaultRegistry(),
+				processor.Config{MaxAttempts: 1},
+				metrics.NewNopRecorder(),
+				leader.NewSoleElector(),
 			)
 
-			require.NoError(t, proc.ProcessBatch(2))
+			processed, err := proc.ProcessBatch(2)
+			require.NoError(t, err)
+			require.Equal(t, 2, processed)
 
-			processedElements, err := repository.NewElementRepositoryFactory().CreateElementRepository(db).GetElementsByProcessID(1)
+			processedElements, err := getElementsByProcessID(conn, 1)
 			require.NoError(t, err)
 			require.Equal(t, 2, len(processedElements))
+			require.Equal(t, "TEST", processedElements[0].Data)
 
-			processes, err := repository.NewProcessRepositoryFactory().CreateProcessRepository(db).GetByStatus(models.PROCESS_STATUS_RUNNING)
+			processes, err := repository.NewProcessRepositoryFactory().CreateProcessRepository(db).GetByStatus(context.Background(), models.PROCESS_STATUS_RUNNING)
 			require.NoError(t, err)
 			require.Equal(t, 1, len(processes))
 			require.Equal(t, 1, processes[0].ID)
 		})
 
+		t.Run("Process Transformer", func(t *testing.T) {
+			defer func() {
+				if err := ResetDB(conn); err != nil {
+					t.Logf("error resetting Process table: %q\n", err)
+				}
+			}()
+
+			require.NoError(t, ResetDB(conn))
+
+			_, err = conn.Exec("INSERT INTO Element (id, data) VALUES (1, 'test')")
+			require.NoError(t, err)
+
+			_, err = conn.Exec(`
+				INSERT INTO Process (id, name, status, transformer, transformer_config, created_at)
+				VALUES (1, 'test', 'RUNNING', 'regex-replace', '{"pattern": "e", "replacement": "3"}', ?)
+			`, time.Now().Add(24*time.Hour))
+			require.NoError(t, err)
+
+			proc := processor.NewProcessor(
+				db,
+				repository.NewProcessRepositoryFactory(),
+				repository.NewElementRepositoryFactory(),
+				repository.NewElementErrorRepositoryFactory(),
+				repository.NewTransitionRepositoryFactory(),
+				repository.NewClaimRepositoryFactory(),
+				transformer.NewDefaultRegistry(),
+				processor.Config{MaxAttempts: 1},
+				metrics.NewNopRecorder(),
+				leader.NewSoleElector(),
+			)
+
+			processed, err := proc.ProcessBatch(1)
+			require.NoError(t, err)
+			require.Equal(t, 1, processed)
+
+			processedElements, err := getElementsByProcessID(conn, 1)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(processedElements))
+			require.Equal(t, "t3st", processedElements[0].Data)
+		})
+
+		t.Run("Concurrent Processes", func(t *testing.T) {
+			defer func() {
+				if err := ResetDB(conn); err != nil {
+					t.Logf("error resetting Process table: %q\n", err)
+				}
+			}()
+
+			require.NoError(t, ResetDB(conn))
+
+			for id := 1; id <= 4; id++ {
+				_, err = conn.Exec("INSERT INTO Element (id, data) VALUES (?, 'test')", id)
+				require.NoError(t, err)
+			}
+
+			_, err = conn.Exec(`
+				INSERT INTO Process (id, name, status, selector, transformer, created_at)
+				VALUES (1, 'first', 'RUNNING', '{"max_id": 2}', 'upper', ?)
+			`, time.Now().Add(24*time.Hour))
+			require.NoError(t, err)
+
+			_, err = conn.Exec(`
+				INSERT INTO Process (id, name, status, selector, transformer, transformer_config, created_at)
+				VALUES (2, 'second', 'RUNNING', '{"min_id": 3}', 'template', '{"template": "{{ . }}!"}', ?)
+			`, time.Now().Add(24*time.Hour))
+			require.NoError(t, err)
+
+			proc := processor.NewProcessor(
+				db,
+				repository.NewProcessRepositoryFactory(),
+				repository.NewElementRepositoryFactory(),
+				repository.NewElementErrorRepositoryFactory(),
+				repository.NewTransitionRepositoryFactory(),
+				repository.NewClaimRepositoryFactory(),
+				transformer.NewDefaultRegistry(),
+				processor.Config{MaxAttempts: 1},
+				metrics.NewNopRecorder(),
+				leader.NewSoleElector(),
+			)
+
+			// one batch per running process, round robin
+			for i := 0; i < 2; i++ {
+				processed, err := proc.ProcessBatch(10)
+				require.NoError(t, err)
+				require.Equal(t, 2, processed)
+			}
+
+			firstElements, err := getElementsByProcessID(conn, 1)
+			require.NoError(t, err)
+			require.Equal(t, 2, len(firstElements))
+			for _, element := range firstElements {
+				require.True(t, element.ID <= 2)
+				require.Equal(t, "TEST", element.Data)
+			}
+
+			secondElements, err := getElementsByProcessID(conn, 2)
+			require.NoError(t, err)
+			require.Equal(t, 2, len(secondElements))
+			for _, element := range secondElements {
+				require.True(t, element.ID >= 3)
+				require.Equal(t, "test!", element.Data)
+			}
+		})
+
+		t.Run("Failing Elements", func(t *testing.T) {
+			defer func() {
+				if err := ResetDB(conn); err != nil {
+					t.Logf("error resetting Process table: %q\n", err)
+				}
+			}()
+
+			require.NoError(t, ResetDB(conn))
+
+			_, err = conn.Exec("INSERT INTO Element (id, data) VALUES (1, 'test')")
+			require.NoError(t, err)
+
+			_, err = conn.Exec("INSERT INTO Element (id, data) VALUES (2, 'this element is too long to be repeated')")
+			require.NoError(t, err)
+
+			// repeating the data of element 2 exceeds the length of the data column
+			_, err = conn.Exec(`
+				INSERT INTO Process (id, name, status, transformer, transformer_config, created_at)
+				VALUES (1, 'test', 'RUNNING', 'template', '{"template": "{{ . }}{{ . }}"}', ?)
+			`, time.Now().Add(24*time.Hour))
+			require.NoError(t, err)
+
+			newProcessor := func(maxAttempts int) processor.Processor {
+				return processor.NewProcessor(
+					db,
+					repository.NewProcessRepositoryFactory(),
+					repository.NewElementRepositoryFactory(),
+					repository.NewElementErrorRepositoryFactory(),
+					repository.NewTransitionRepositoryFactory(),
+					repository.NewClaimRepositoryFactory(),
+					transformer.NewDefaultRegistry(),
+					processor.Config{MaxAttempts: maxAttempts, RetryBackoff: time.Hour},
+					metrics.NewNopRecorder(),
+					leader.NewSoleElector(),
+				)
+			}
+
+			// the failing element is retried later without failing the batch
+			proc := newProcessor(2)
+			processed, err := proc.ProcessBatch(2)
+			require.NoError(t, err)
+			require.Equal(t, 2, processed)
+
+			processedElements, err := getElementsByProcessID(conn, 1)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(processedElements))
+			require.Equal(t, 1, processedElements[0].ID)
+			require.Equal(t, "testtest", processedElements[0].Data)
+
+			failures, err := proc.GetFailures(1, false)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(failures))
+			require.Equal(t, 2, failures[0].ElementID)
+			require.Equal(t, 1, failures[0].Attempts)
+			require.False(t, failures[0].DeadLettered)
+			require.NotNil(t, failures[0].NextAttemptAt)
+
+			// the element is waiting to be retried so the process can't complete
+			processed, err = proc.ProcessBatch(2)
+			require.NoError(t, err)
+			require.Equal(t, 0, processed)
+			status, err := proc.GetStatus(1)
+			require.NoError(t, err)
+			require.Equal(t, models.PROCESS_STATUS_RUNNING, status.Status)
+
+			// once the element is retried and fails again it's dead lettered and the process completes
+			_, err = conn.Exec("UPDATE ProcessElementError SET next_attempt_at = NULL")
+			require.NoError(t, err)
+
+			processed, err = proc.ProcessBatch(2)
+			require.NoError(t, err)
+			require.Equal(t, 1, processed)
+
+			processed, err = proc.ProcessBatch(2)
+			require.NoError(t, err)
+			require.Equal(t, 0, processed)
+
+			failures, err = proc.GetFailures(1, true)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(failures))
+			require.Equal(t, 2, failures[0].Attempts)
+
+			status, err = proc.GetStatus(1)
+			require.NoError(t, err)
+			require.Equal(t, models.PROCESS_STATUS_COMPLETE, status.Status)
+			require.Equal(t, 1, status.DeadLettered)
+
+			// redriving reopens the process
+			redriven, process, err := proc.Redrive(1)
+			require.NoError(t, err)
+			require.Equal(t, 1, redriven)
+			require.Equal(t, models.PROCESS_STATUS_RUNNING, process.Status)
+
+			status, err = proc.GetStatus(1)
+			require.NoError(t, err)
+			require.Equal(t, models.PROCESS_STATUS_RUNNING, status.Status)
+			require.Equal(t, 0, status.DeadLettered)
+		})
+
+		t.Run("Cancelled Context", func(t *testing.T) {
+			defer func() {
+				if err := ResetDB(conn); err != nil {
+					t.Logf("error resetting Process table: %q\n", err)
+				}
+			}()
+
+			require.NoError(t, ResetDB(conn))
+
+			_, err = conn.Exec("INSERT INTO Element (id, data) VALUES (1, 'test')")
+			require.NoError(t, err)
+
+			_, err = conn.Exec("INSERT INTO Process (id, name, status, created_at) VALUES (1, 'test', 'RUNNING', ?)", time.Now().Add(24*time.Hour))
+			require.NoError(t, err)
+
+			proc := processor.NewProcessor(
+				db,
+				repository.NewProcessRepositoryFactory(),
+				repository.NewElementRepositoryFactory(),
+				repository.NewElementErrorRepositoryFactory(),
+				repository.NewTransitionRepositoryFactory(),
+				repository.NewClaimRepositoryFactory(),
+				transformer.NewDefaultRegistry(),
+				processor.Config{MaxAttempts: 1},
+				metrics.NewNopRecorder(),
+				leader.NewSoleElector(),
+			)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err = proc.ProcessBatchContext(ctx, 1)
+			require.Error(t, err)
+
+			processedElements, err := getElementsByProcessID(conn, 1)
+			require.NoError(t, err)
+			require.Equal(t, 0, len(processedElements))
+		})
+
 		t.Run("No Elements to Process", func(t *testing.T) {
 			defer func() {
 				if err := ResetDB(conn); err != nil {
@@ -100,7 +361,7 @@ func TestProcessor(t *testing.T) {
 			_, err = conn.Exec("INSERT INTO Element (id, data) VALUES (2, 'test')")
 			require.NoError(t, err)
 
-			_, err = conn.Exec("INSERT INTO Process (id, status, created_at) VALUES (1, 'RUNNING', NOW() + INTERVAL 1 DAY)")
+			_, err = conn.Exec("INSERT INTO Process (id, name, status, created_at) VALUES (1, 'test', 'RUNNING', ?)", time.Now().Add(24*time.Hour))
 			require.NoError(t, err)
 
 			_, err = conn.Exec("INSERT INTO ProcessElement (process_id, element_id) VALUES (1, 1)")
@@ -113,19 +374,414 @@ func TestProcessor(t *testing.T) {
 				db,
 				repository.NewProcessRepositoryFactory(),
 				repository.NewElementRepositoryFactory(),
+				repository.NewElementErrorRepositoryFactory(),
+				repository.NewTransitionRepositoryFactory(),
+				repository.NewClaimRepositoryFactory(),
+				transformer.NewDefaultRegistry(),
+				processor.Config{MaxAttempts: 1},
+				metrics.NewNopRecorder(),
+				leader.NewSoleElector(),
 			)
 
-			require.NoError(t, proc.ProcessBatch(2))
+			processed, err := proc.ProcessBatch(2)
+			require.NoError(t, err)
+			require.Equal(t, 0, processed)
 
-			processes, err := repository.NewProcessRepositoryFactory().CreateProcessRepository(db).GetByStatus(models.PROCESS_STATUS_COMPLETE)
+			processes, err := repository.NewProcessRepositoryFactory().CreateProcessRepository(db).GetByStatus(context.Background(), models.PROCESS_STATUS_COMPLETE)
 			require.NoError(t, err)
 			require.Equal(t, 1, len(processes))
 			require.Equal(t, 1, processes[0].ID)
 		})
 	})
+
+	t.Run("Start", func(t *testing.T) {
+		defer func() {
+			if err := ResetDB(conn); err != nil {
+				t.Logf("error resetting Process table: %q\n", err)
+			}
+		}()
+
+		require.NoError(t, ResetDB(conn))
+
+		proc := processor.NewProcessor(
+			db,
+			repository.NewProcessRepositoryFactory(),
+			repository.NewElementRepositoryFactory(),
+			repository.NewElementErrorRepositoryFactory(),
+			repository.NewTransitionRepositoryFactory(),
+			repository.NewClaimRepositoryFactory(),
+			transformer.NewDefaultRegistry(),
+			processor.Config{MaxAttempts: 1},
+			metrics.NewNopRecorder(),
+			leader.NewSoleElector(),
+		)
+
+		// names are limited to 100 characters, not bytes
+		name := strings.Repeat("é", 100)
+		process, err := proc.Start(processor.StartOptions{Name: name})
+		require.NoError(t, err)
+		require.Equal(t, name, process.Name)
+
+		status, err := proc.GetStatus(process.ID)
+		require.NoError(t, err)
+		require.Equal(t, name, status.Name)
+
+		_, err = proc.Start(processor.StartOptions{Name: name + "é"})
+		require.Equal(t, processor.ErrInvalidProcessName, err)
+
+		_, err = proc.Start(processor.StartOptions{})
+		require.Equal(t, processor.ErrInvalidProcessName, err)
+	})
+
+	t.Run("GetStatus", func(t *testing.T) {
+		defer func() {
+			if err := ResetDB(conn); err != nil {
+				t.Logf("error resetting Process table: %q\n", err)
+			}
+		}()
+
+		require.NoError(t, ResetDB(conn))
+
+		for id := 1; id <= 4; id++ {
+			_, err = conn.Exec("INSERT INTO Element (id, data) VALUES (?, 'test')", id)
+			require.NoError(t, err)
+		}
+
+		_, err = conn.Exec(`
+			INSERT INTO Process (id, name, status, created_at, started_at)
+			VALUES (1, 'test', 'RUNNING', ?, ?)
+		`, time.Now().Add(24*time.Hour), time.Now().Add(-time.Minute))
+		require.NoError(t, err)
+
+		_, err = conn.Exec("INSERT INTO ProcessElement (process_id, element_id) VALUES (1, 1)")
+		require.NoError(t, err)
+
+		proc := processor.NewProcessor(
+			db,
+			repository.NewProcessRepositoryFactory(),
+			repository.NewElementRepositoryFactory(),
+			repository.NewElementErrorRepositoryFactory(),
+			repository.NewTransitionRepositoryFactory(),
+			repository.NewClaimRepositoryFactory(),
+			transformer.NewDefaultRegistry(),
+			processor.Config{MaxAttempts: 1},
+			metrics.NewNopRecorder(),
+			leader.NewSoleElector(),
+		)
+
+		status, err := proc.GetStatus(1)
+		require.NoError(t, err)
+		require.Equal(t, 1, status.ProcessID)
+		require.Equal(t, models.PROCESS_STATUS_RUNNING, status.Status)
+		require.Equal(t, 4, status.TotalEligible)
+		require.Equal(t, 1, status.Processed)
+		require.Equal(t, 3, status.Remaining)
+		require.Equal(t, 25.0, status.PercentComplete)
+		require.True(t, status.Throughput > 0)
+		require.NotNil(t, status.ETA)
+
+		_, err = proc.GetStatus(2)
+		require.Equal(t, processor.ErrNoProcessExists, err)
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		defer func() {
+			if err := ResetDB(conn); err != nil {
+				t.Logf("error resetting Process table: %q\n", err)
+			}
+		}()
+
+		require.NoError(t, ResetDB(conn))
+
+		_, err = conn.Exec("INSERT INTO Element (id, data) VALUES (1, 'test')")
+		require.NoError(t, err)
+
+		_, err = conn.Exec("INSERT INTO Process (id, name, status, created_at) VALUES (1, 'test', 'PAUSED', ?)", time.Now().Add(24*time.Hour))
+		require.NoError(t, err)
+
+		proc := processor.NewProcessor(
+			db,
+			repository.NewProcessRepositoryFactory(),
+			repository.NewElementRepositoryFactory(),
+			repository.NewElementErrorRepositoryFactory(),
+			repository.NewTransitionRepositoryFactory(),
+			repository.NewClaimRepositoryFactory(),
+			transformer.NewDefaultRegistry(),
+			processor.Config{MaxAttempts: 1},
+			metrics.NewNopRecorder(),
+			leader.NewSoleElector(),
+		)
+
+		// a cancel of a version other than the process's is refused
+		ctx := processor.WithActor(context.Background(), "operator")
+		_, err := proc.CancelContext(processor.WithVersion(ctx, 1), 1)
+		require.Equal(t, processor.ErrVersionMismatch, err)
+
+		cancelled, err := proc.CancelContext(processor.WithVersion(ctx, 0), 1)
+		require.NoError(t, err)
+		require.Equal(t, 1, cancelled.Version)
+
+		status, err := proc.GetStatus(1)
+		require.NoError(t, err)
+		require.Equal(t, models.PROCESS_STATUS_CANCELLED, status.Status)
+		require.NotNil(t, status.CancelledAt)
+		require.Equal(t, 1, status.Version)
+
+		history, err := proc.GetHistory(1)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(history))
+		require.Equal(t, models.PROCESS_STATUS_PAUSED, history[0].From)
+		require.Equal(t, models.PROCESS_STATUS_CANCELLED, history[0].To)
+		require.Equal(t, "operator", history[0].Actor)
+		require.Equal(t, "cancelled", history[0].Reason)
+
+		// a cancelled process can't be resumed, cancelled again or processed
+		_, err = proc.Resume(1)
+		require.Equal(t, &models.TransitionError{From: models.PROCESS_STATUS_CANCELLED, To: models.PROCESS_STATUS_RUNNING}, err)
+		_, err = proc.Cancel(1)
+		require.Equal(t, &models.TransitionError{From: models.PROCESS_STATUS_CANCELLED, To: models.PROCESS_STATUS_CANCELLED}, err)
+
+		_, err = proc.ProcessBatch(1)
+		require.Equal(t, processor.ErrNoRunningProcessExists, err)
+
+		_, err = proc.Cancel(2)
+		require.Equal(t, processor.ErrNoProcessExists, err)
+	})
+
+	t.Run("Restart", func(t *testing.T) {
+		defer func() {
+			if err := ResetDB(conn); err != nil {
+				t.Logf("error resetting Process table: %q\n", err)
+			}
+		}()
+
+		require.NoError(t, ResetDB(conn))
+
+		_, err = conn.Exec(`
+			INSERT INTO Process (id, name, status, selector, transformer, created_at)
+			VALUES (1, 'test', 'PAUSED', '{"max_id": 2}', 'lower', ?)
+		`, time.Now())
+		require.NoError(t, err)
+		require.NoError(t, conn.SyncSequences())
+
+		proc := processor.NewProcessor(
+			db,
+			repository.NewProcessRepositoryFactory(),
+			repository.NewElementRepositoryFactory(),
+			repository.NewElementErrorRepositoryFactory(),
+			repository.NewTransitionRepositoryFactory(),
+			repository.NewClaimRepositoryFactory(),
+			transformer.NewDefaultRegistry(),
+			processor.Config{MaxAttempts: 1},
+			metrics.NewNopRecorder(),
+			leader.NewSoleElector(),
+		)
+
+		restarted, err := proc.Restart(1)
+		require.NoError(t, err)
+		require.NotEqual(t, 1, restarted.ID)
+		require.Equal(t, "test", restarted.Name)
+		require.Equal(t, models.PROCESS_STATUS_RUNNING, restarted.Status)
+		require.Equal(t, models.ElementSelector{MaxID: 2}, restarted.Selector)
+		require.Equal(t, transformer.LOWER, restarted.Transformer)
+
+		status, err := proc.GetStatus(1)
+		require.NoError(t, err)
+		require.Equal(t, models.PROCESS_STATUS_CANCELLED, status.Status)
+
+		history, err := proc.GetHistory(restarted.ID)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(history))
+		require.Equal(t, models.ProcessState(""), history[0].From)
+		require.Equal(t, models.PROCESS_STATUS_RUNNING, history[0].To)
+		require.Equal(t, "system", history[0].Actor)
+		require.Equal(t, "restart of process 1", history[0].Reason)
+
+		_, err = proc.Restart(restarted.ID + 1)
+		require.Equal(t, processor.ErrNoProcessExists, err)
+
+		// a reverting process can't be restarted
+		_, err = conn.Exec("INSERT INTO Process (id, name, status, created_at) VALUES (100, 'reverting', 'REVERTING', ?)", time.Now())
+		require.NoError(t, err)
+
+		_, err = proc.Restart(100)
+		require.Equal(t, &models.TransitionError{From: models.PROCESS_STATUS_REVERTING, To: models.PROCESS_STATUS_CANCELLED}, err)
+
+		status, err = proc.GetStatus(100)
+		require.NoError(t, err)
+		require.Equal(t, models.PROCESS_STATUS_REVERTING, status.Status)
+	})
+
+	t.Run("Revert", func(t *testing.T) {
+		defer func() {
+			if err := ResetDB(conn); err != nil {
+				t.Logf("error resetting Process table: %q\n", err)
+			}
+		}()
+
+		require.NoError(t, ResetDB(conn))
+
+		_, err = conn.Exec("INSERT INTO Element (id, data, created_at) VALUES (1, 'one', ?), (2, 'two', ?)", time.Now().Add(-24*time.Hour), time.Now().Add(-24*time.Hour))
+		require.NoError(t, err)
+
+		proc := processor.NewProcessor(
+			db,
+			repository.NewProcessRepositoryFactory(),
+			repository.NewElementRepositoryFactory(),
+			repository.NewElementErrorRepositoryFactory(),
+			repository.NewTransitionRepositoryFactory(),
+			repository.NewClaimRepositoryFactory(),
+			transformer.NewDefaultRegistry(),
+			processor.Config{MaxAttempts: 1},
+			metrics.NewNopRecorder(),
+			leader.NewSoleElector(),
+		)
+
+		upper, err := proc.Start(processor.StartOptions{Name: "upper"})
+		require.NoError(t, err)
+
+		for {
+			processed, err := proc.ProcessBatch(10)
+			require.NoError(t, err)
+			if processed == 0 {
+				break
+			}
+		}
+
+		_, err = proc.ProcessBatch(10)
+		require.Equal(t, processor.ErrNoRunningProcessExists, err)
+
+		// element 2 is changed again by a later process so is left as it is when the first is reverted
+		_, err = conn.Exec("UPDATE Element SET data = 'deux' WHERE id = 2")
+		require.NoError(t, err)
+
+		_, err = proc.Revert(upper.ID)
+		require.NoError(t, err)
+
+		reverted, err := proc.ProcessBatch(10)
+		require.NoError(t, err)
+		require.Equal(t, 2, reverted)
+
+		reverted, err = proc.ProcessBatch(10)
+		require.NoError(t, err)
+		require.Equal(t, 0, reverted)
+
+		status, err := proc.GetStatus(upper.ID)
+		require.NoError(t, err)
+		require.Equal(t, models.PROCESS_STATUS_REVERTED, status.Status)
+
+		var data []string
+		require.NoError(t, conn.Select(&data, "SELECT data FROM Element ORDER BY id"))
+		require.Equal(t, []string{"one", "deux"}, data)
+
+		history, err := proc.GetElementHistory(1)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(history))
+		require.Equal(t, upper.ID, history[0].ProcessID)
+		require.Equal(t, "upper", history[0].ProcessName)
+		require.Equal(t, transformer.UPPER, history[0].Transformer)
+		require.Equal(t, "one", history[0].PreviousData)
+		require.Equal(t, "ONE", history[0].NewData)
+		require.NotNil(t, history[0].RevertedAt)
+
+		_, err = proc.GetElementHistory(3)
+		require.Equal(t, processor.ErrNoElementExists, err)
+
+		_, err = proc.Revert(upper.ID)
+		_, ok := err.(*models.TransitionError)
+		require.True(t, ok)
+	})
+
+	t.Run("Preview", func(t *testing.T) {
+		defer func() {
+			if err := ResetDB(conn); err != nil {
+				t.Logf("error resetting Process table: %q\n", err)
+			}
+		}()
+
+		require.NoError(t, ResetDB(conn))
+
+		_, err = conn.Exec("INSERT INTO Element (id, data) VALUES (1, 'test'), (2, 'tree'), (3, 'test')")
+		require.NoError(t, err)
+
+		// created after the preview, so neither counted nor sampled
+		_, err = conn.Exec("INSERT INTO Element (id, data, created_at) VALUES (4, 'test', ?)", time.Now().Add(24*time.Hour))
+		require.NoError(t, err)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// previews record no metrics, their transactions are always rolled back
+		proc := processor.NewProcessor(
+			db,
+			repository.NewProcessRepositoryFactory(),
+			repository.NewElementRepositoryFactory(),
+			repository.NewElementErrorRepositoryFactory(),
+			repository.NewTransitionRepositoryFactory(),
+			repository.NewClaimRepositoryFactory(),
+			transformer.NewDefaultRegistry(),
+			processor.Config{MaxAttempts: 1},
+			mock_metrics.NewMockRecorder(ctrl),
+			leader.NewSoleElector(),
+		)
+
+		preview, err := proc.Preview(processor.PreviewOptions{
+			Selector:          models.ElementSelector{MaxID: 2},
+			Transformer:       transformer.REGEX_REPLACE,
+			TransformerConfig: `{"pattern": "e", "replacement": "3"}`,
+			SampleSize:        1,
+		})
+		require.NoError(t, err)
+		require.Equal(t, 2, preview.Affected)
+		require.Equal(t, []models.PreviewSample{{ElementID: 1, Before: "test", After: "t3st"}}, preview.Samples)
+
+		preview, err = proc.Preview(processor.PreviewOptions{
+			Selector:          models.ElementSelector{MinID: 3},
+			Transformer:       transformer.REGEX_REPLACE,
+			TransformerConfig: `{"pattern": "e", "replacement": "3"}`,
+			SampleSize:        2,
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, preview.Affected)
+		require.Equal(t, []models.PreviewSample{{ElementID: 3, Before: "test", After: "t3st"}}, preview.Samples)
+
+		var data []string
+		require.NoError(t, conn.Select(&data, "SELECT data FROM Element ORDER BY id"))
+		require.Equal(t, []string{"test", "tree", "test", "test"}, data)
+
+		var processElements int
+		require.NoError(t, conn.Get(&processElements, "SELECT COUNT(*) FROM ProcessElement"))
+		require.Equal(t, 0, processElements)
+
+		_, err = proc.Preview(processor.PreviewOptions{Transformer: "unknown"})
+		require.Equal(t, processor.ErrInvalidTransformer, errors.Cause(err))
+	})
+}
+
+// getElementsByProcessID returns the elements the process with processID has changed.
+func getElementsByProcessID(conn *dbtest.Conn, processID int) ([]models.Element, error) {
+	elements := []models.Element{}
+	return elements, conn.Select(
+		&elements,
+		`
+			SELECT e.id, e.data, e.created_at FROM Element AS e
+				INNER JOIN ProcessElement AS pe ON e.id = pe.element_id
+			WHERE pe.process_id = ?
+			ORDER BY e.id
+		`,
+		processID,
+	)
 }
 
-func ResetDB(conn *sqlx.DB) error {
+func ResetDB(conn *dbtest.Conn) error {
+	if _, err := conn.Exec("DELETE FROM ProcessElementError"); err != nil {
+		return err
+	}
+
+	if _, err := conn.Exec("DELETE FROM ProcessTransition"); err != nil {
+		return err
+	}
+
 	if _, err := conn.Exec("DELETE FROM ProcessElement"); err != nil {
 		return err
 	}
@@ -134,6 +790,10 @@ func ResetDB(conn *sqlx.DB) error {
 		return err
 	}
 
+	if _, err := conn.Exec("DELETE FROM ElementTag"); err != nil {
+		return err
+	}
+
 	if _, err := conn.Exec("DELETE FROM Element"); err != nil {
 		return err
 	}
@@ -0,0 +1,111 @@
+// +build unit
+
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProcessStatus(t *testing.T) {
+	now := time.Date(2019, 1, 1, 12, 0, 0, 0, time.UTC)
+	startedAt := now.Add(-110 * time.Second)
+
+	t.Run("Running", func(t *testing.T) {
+		process := models.Process{
+			ID:            1,
+			Status:        models.PROCESS_STATUS_RUNNING,
+			StartedAt:     &startedAt,
+			PausedSeconds: 10,
+		}
+
+		status := newProcessStatus(process, 400, 100, 0, now)
+		require.Equal(t, 300, status.Remaining)
+		require.Equal(t, 25.0, status.PercentComplete)
+		require.Equal(t, 1.0, status.Throughput)
+		require.Equal(t, 300.0, *status.ETASeconds)
+		require.Equal(t, now.Add(300*time.Second), *status.ETA)
+	})
+
+	t.Run("Dead Lettered", func(t *testing.T) {
+		process := models.Process{
+			ID:        1,
+			Status:    models.PROCESS_STATUS_RUNNING,
+			StartedAt: &startedAt,
+		}
+
+		status := newProcessStatus(process, 400, 100, 50, now)
+		require.Equal(t, 50, status.DeadLettered)
+		require.Equal(t, 250, status.Remaining)
+	})
+
+	t.Run("Paused", func(t *testing.T) {
+		pausedAt := now.Add(-10 * time.Second)
+		process := models.Process{
+			ID:        1,
+			Status:    models.PROCESS_STATUS_PAUSED,
+			StartedAt: &startedAt,
+			PausedAt:  &pausedAt,
+		}
+
+		status := newProcessStatus(process, 400, 100, 0, now)
+		require.Equal(t, 1.0, status.Throughput)
+		require.Nil(t, status.ETA)
+	})
+
+	t.Run("Complete", func(t *testing.T) {
+		process := models.Process{
+			ID:          1,
+			Status:      models.PROCESS_STATUS_COMPLETE,
+			StartedAt:   &startedAt,
+			CompletedAt: &now,
+		}
+
+		status := newProcessStatus(process, 0, 0, 0, now)
+		require.Equal(t, 0, status.Remaining)
+		require.Equal(t, 100.0, status.PercentComplete)
+		require.Nil(t, status.ETA)
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		cancelledAt := now.Add(-10 * time.Second)
+		process := models.Process{
+			ID:          1,
+			Status:      models.PROCESS_STATUS_CANCELLED,
+			StartedAt:   &startedAt,
+			CancelledAt: &cancelledAt,
+		}
+
+		status := newProcessStatus(process, 400, 100, 0, now)
+		require.Equal(t, 300, status.Remaining)
+		require.Equal(t, 1.0, status.Throughput)
+		require.Nil(t, status.ETA)
+	})
+}
+
+func TestStalled(t *testing.T) {
+	now := time.Date(2019, 1, 1, 12, 0, 0, 0, time.UTC)
+	stuckAfter := 10 * time.Minute
+	longAgo := now.Add(-stuckAfter)
+	recently := now.Add(-time.Minute)
+
+	for _, tc := range []struct {
+		name    string
+		status  models.ProcessStatus
+		stalled bool
+	}{
+		{"Running", models.ProcessStatus{Status: models.PROCESS_STATUS_RUNNING, Remaining: 1, LastProgressAt: &longAgo}, true},
+		{"Running Recently Progressed", models.ProcessStatus{Status: models.PROCESS_STATUS_RUNNING, Remaining: 1, LastProgressAt: &recently}, false},
+		{"Running None Remaining", models.ProcessStatus{Status: models.PROCESS_STATUS_RUNNING, LastProgressAt: &longAgo}, false},
+		{"Reverting", models.ProcessStatus{Status: models.PROCESS_STATUS_REVERTING, LastProgressAt: &longAgo}, true},
+		{"Paused", models.ProcessStatus{Status: models.PROCESS_STATUS_PAUSED, Remaining: 1, LastProgressAt: &longAgo}, false},
+		{"No Progress Recorded", models.ProcessStatus{Status: models.PROCESS_STATUS_RUNNING, Remaining: 1}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.stalled, stalled(tc.status, stuckAfter, now))
+		})
+	}
+}
@@ -0,0 +1,201 @@
+//go:generate mockgen -package repository -source=claim.go -destination ./mocks/claim.go
+
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	"github.com/eggsbenjamin/square_enix/internal/app/models"
+)
+
+// ClaimRepository records the elements locked by each database session's batch, so that a session whose batch
+// outlives its claims can be killed to release them. Claims are written outside the batch's transaction, so
+// they're seen while it's running and kept if its worker dies.
+type ClaimRepository interface {
+	// SessionID returns the id of the session the repository's queries run in, 0 where a session's locks can't
+	// outlive its client so its elements needn't be claimed.
+	SessionID(ctx context.Context) (int64, error)
+
+	// ClaimElements replaces the claims of session sessionID with claims of elementIDs for processID, expiring
+	// ttl from now.
+	ClaimElements(ctx context.Context, sessionID int64, processID int, elementIDs []int, ttl time.Duration) error
+
+	// RenewClaims extends session sessionID's claims of elementIDs to expire ttl from now.
+	RenewClaims(ctx context.Context, sessionID int64, elementIDs []int, ttl time.Duration) error
+
+	// ReleaseClaims deletes session sessionID's claims of elementIDs.
+	ReleaseClaims(ctx context.Context, sessionID int64, elementIDs []int) error
+
+	// ReleaseExpired deletes those of session sessionID's claims of elementIDs that expired before now, returning
+	// how many it deleted.
+	ReleaseExpired(ctx context.Context, sessionID int64, elementIDs []int, now time.Time) (int, error)
+
+	// GetExpired returns the claims that expired before now, ordered by session.
+	GetExpired(ctx context.Context, now time.Time) ([]models.ElementClaim, error)
+
+	// LockElements locks those of elementIDs that aren't locked by another transaction, skipping the rest, and
+	// returns the ids it locked. The repository must be created with a transaction.
+	LockElements(ctx context.Context, elementIDs []int) ([]int, error)
+
+	// KillSession terminates session sessionID, rolling back its transaction.
+	KillSession(ctx context.Context, sessionID int64) error
+}
+
+type claimRepo struct {
+	db      db.Querier
+	dialect db.Dialect
+}
+
+func NewClaimRepository(q db.Querier) ClaimRepository {
+	return &claimRepo{
+		db:      db.Rebound(q),
+		dialect: db.DialectOf(q),
+	}
+}
+
+func (c *claimRepo) SessionID(ctx context.Context) (int64, error) {
+	return c.dialect.SessionID(ctx, c.db)
+}
+
+// ClaimElements deletes the session's previous claims, any left by a batch whose claims weren't released, and any
+// claims of the elements by other sessions, whose locks must have been released for the elements to be locked.
+func (c *claimRepo) ClaimElements(ctx context.Context, sessionID int64, processID int, elementIDs []int, ttl time.Duration) error {
+	if len(elementIDs) == 0 {
+		return nil
+	}
+
+	args := []interface{}{sessionID}
+	for _, id := range elementIDs {
+		args = append(args, id)
+	}
+
+	if _, err := c.db.ExecContext(
+		ctx,
+		`DELETE FROM ElementClaim WHERE session_id = ? OR element_id IN (`+strings.TrimSuffix(strings.Repeat("?,", len(elementIDs)), ",")+`)`,
+		args...,
+	); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(ttl)
+
+	args = make([]interface{}, 0, len(elementIDs)*5)
+	for _, id := range elementIDs {
+		args = append(args, id, processID, sessionID, now, expiresAt)
+	}
+
+	_, err := c.db.ExecContext(
+		ctx,
+		`INSERT INTO ElementClaim (element_id, process_id, session_id, claimed_at, expires_at) VALUES `+
+			strings.TrimSuffix(strings.Repeat("(?, ?, ?, ?, ?), ", len(elementIDs)), ", "),
+		args...,
+	)
+	return err
+}
+
+func (c *claimRepo) RenewClaims(ctx context.Context, sessionID int64, elementIDs []int, ttl time.Duration) error {
+	if len(elementIDs) == 0 {
+		return nil
+	}
+
+	args := []interface{}{time.Now().UTC().Add(ttl), sessionID}
+	for _, id := range elementIDs {
+		args = append(args, id)
+	}
+
+	_, err := c.db.ExecContext(
+		ctx,
+		`UPDATE ElementClaim SET expires_at = ? WHERE session_id = ? AND element_id IN (`+strings.TrimSuffix(strings.Repeat("?,", len(elementIDs)), ",")+`)`,
+		args...,
+	)
+	return err
+}
+
+func (c *claimRepo) ReleaseClaims(ctx context.Context, sessionID int64, elementIDs []int) error {
+	if len(elementIDs) == 0 {
+		return nil
+	}
+
+	args := []interface{}{sessionID}
+	for _, id := range elementIDs {
+		args = append(args, id)
+	}
+
+	_, err := c.db.ExecContext(
+		ctx,
+		`DELETE FROM ElementClaim WHERE session_id = ? AND element_id IN (`+strings.TrimSuffix(strings.Repeat("?,", len(elementIDs)), ",")+`)`,
+		args...,
+	)
+	return err
+}
+
+func (c *claimRepo) ReleaseExpired(ctx context.Context, sessionID int64, elementIDs []int, now time.Time) (int, error) {
+	if len(elementIDs) == 0 {
+		return 0, nil
+	}
+
+	args := []interface{}{sessionID, now}
+	for _, id := range elementIDs {
+		args = append(args, id)
+	}
+
+	res, err := c.db.ExecContext(
+		ctx,
+		`DELETE FROM ElementClaim WHERE session_id = ? AND expires_at < ? AND element_id IN (`+strings.TrimSuffix(strings.Repeat("?,", len(elementIDs)), ",")+`)`,
+		args...,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	released, err := res.RowsAffected()
+	return int(released), err
+}
+
+func (c *claimRepo) GetExpired(ctx context.Context, now time.Time) ([]models.ElementClaim, error) {
+	claims := []models.ElementClaim{}
+	err := c.db.SelectContext(ctx, &claims, "SELECT * FROM ElementClaim WHERE expires_at < ? ORDER BY session_id, element_id", now)
+	return claims, err
+}
+
+func (c *claimRepo) LockElements(ctx context.Context, elementIDs []int) ([]int, error) {
+	locked := []int{}
+	if len(elementIDs) == 0 {
+		return locked, nil
+	}
+
+	args := make([]interface{}, 0, len(elementIDs))
+	for _, id := range elementIDs {
+		args = append(args, id)
+	}
+
+	err := c.db.SelectContext(
+		ctx,
+		&locked,
+		`SELECT id FROM Element WHERE id IN (`+strings.TrimSuffix(strings.Repeat("?,", len(elementIDs)), ",")+`) ORDER BY id `+c.dialect.SkipLocked(),
+		args...,
+	)
+	return locked, err
+}
+
+func (c *claimRepo) KillSession(ctx context.Context, sessionID int64) error {
+	return c.dialect.KillSession(ctx, c.db, sessionID)
+}
+
+type ClaimRepositoryFactory interface {
+	CreateClaimRepository(db db.Querier) ClaimRepository
+}
+
+type claimRepoFactory struct{}
+
+func NewClaimRepositoryFactory() ClaimRepositoryFactory {
+	return &claimRepoFactory{}
+}
+
+func (c *claimRepoFactory) CreateClaimRepository(db db.Querier) ClaimRepository {
+	return NewClaimRepository(db)
+}
@@ -0,0 +1,114 @@
+// +build integration
+
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	"github.com/eggsbenjamin/square_enix/internal/app/db/dbtest"
+	"github.com/eggsbenjamin/square_enix/internal/app/models"
+	"github.com/eggsbenjamin/square_enix/internal/app/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaimRepository(t *testing.T) {
+	conn, err := dbtest.Connect()
+	require.NoError(t, err)
+
+	db := db.NewDB(conn.DB)
+	ctx := context.Background()
+
+	t.Run("Claims", func(t *testing.T) {
+		defer func() {
+			if _, err := conn.Exec("DELETE FROM ElementClaim"); err != nil {
+				t.Logf("error resetting ElementClaim table: %q\n", err)
+			}
+		}()
+
+		_, err := conn.Exec("DELETE FROM ElementClaim")
+		require.NoError(t, err)
+
+		repo := repository.NewClaimRepository(db)
+
+		require.NoError(t, repo.ClaimElements(ctx, 1, 10, []int{1, 2}, -time.Minute))
+		require.NoError(t, repo.ClaimElements(ctx, 2, 10, []int{3}, time.Minute))
+
+		expired, err := repo.GetExpired(ctx, time.Now())
+		require.NoError(t, err)
+		require.Len(t, expired, 2)
+		require.Equal(t, []int{1, 2}, []int{expired[0].ElementID, expired[1].ElementID})
+		require.Equal(t, int64(1), expired[0].SessionID)
+		require.Equal(t, 10, expired[0].ProcessID)
+
+		// a session's next batch replaces its claims, and takes over those of other sessions
+		require.NoError(t, repo.ClaimElements(ctx, 1, 10, []int{3, 4}, -time.Minute))
+
+		expired, err = repo.GetExpired(ctx, time.Now())
+		require.NoError(t, err)
+		require.Len(t, expired, 2)
+		require.Equal(t, []int{3, 4}, []int{expired[0].ElementID, expired[1].ElementID})
+
+		// only the session's own claims are released
+		require.NoError(t, repo.ReleaseClaims(ctx, 2, []int{3, 4}))
+		require.NoError(t, repo.ReleaseClaims(ctx, 1, []int{3}))
+
+		expired, err = repo.GetExpired(ctx, time.Now())
+		require.NoError(t, err)
+		require.Len(t, expired, 1)
+		require.Equal(t, 4, expired[0].ElementID)
+	})
+
+	t.Run("Renews And Releases Expired Claims", func(t *testing.T) {
+		defer func() {
+			if _, err := conn.Exec("DELETE FROM ElementClaim"); err != nil {
+				t.Logf("error resetting ElementClaim table: %q\n", err)
+			}
+		}()
+
+		_, err := conn.Exec("DELETE FROM ElementClaim")
+		require.NoError(t, err)
+
+		repo := repository.NewClaimRepository(db)
+
+		require.NoError(t, repo.ClaimElements(ctx, 1, 10, []int{1, 2, 3}, -time.Minute))
+
+		// only the session's own claims are renewed
+		require.NoError(t, repo.RenewClaims(ctx, 2, []int{1}, time.Minute))
+		require.NoError(t, repo.RenewClaims(ctx, 1, []int{1}, time.Minute))
+
+		// renewed claims aren't released as expired
+		released, err := repo.ReleaseExpired(ctx, 1, []int{1, 2}, time.Now())
+		require.NoError(t, err)
+		require.Equal(t, 1, released)
+
+		expired, err := repo.GetExpired(ctx, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.Len(t, expired, 2)
+		require.Equal(t, []int{1, 3}, []int{expired[0].ElementID, expired[1].ElementID})
+	})
+
+	t.Run("LockElements", func(t *testing.T) {
+		defer func() {
+			if _, err := conn.Exec("DELETE FROM Element"); err != nil {
+				t.Logf("error resetting Element table: %q\n", err)
+			}
+		}()
+
+		_, err := conn.Exec("DELETE FROM Element")
+		require.NoError(t, err)
+
+		ids, err := repository.NewElementRepository(db).InsertElements(ctx, []models.Element{{Data: "a"}, {Data: "b"}})
+		require.NoError(t, err)
+
+		tx, err := db.BeginTxx(ctx, nil)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		locked, err := repository.NewClaimRepository(tx).LockElements(ctx, append(ids, ids[1]+1))
+		require.NoError(t, err)
+		require.Equal(t, ids, locked)
+	})
+}
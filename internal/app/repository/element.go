@@ -3,49 +3,213 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/eggsbenjamin/square_enix/internal/app/db"
 	"github.com/eggsbenjamin/square_enix/internal/app/models"
 )
 
+// insertChunkSize bounds the number of rows inserted by a single multi-row INSERT statement.
+const insertChunkSize = 500
+
+var ErrNoElementExists = errors.New("no element exists")
+
+// likeEscaper escapes the LIKE wildcards, and the escape character itself, so a prefix matches literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
 type ElementRepository interface {
-	UpdateElementForProcess(element models.Element, processID int) error
-	LockElementsForUpdate(processID int, batchSize int) ([]models.Element, error)
-	GetElementsByProcessID(processID int) ([]models.Element, error)
-	GetElementsCreatedBefore(date time.Time) ([]models.Element, error)
+	InsertElements(ctx context.Context, elements []models.Element) ([]int, error)
+	UpdateElement(ctx context.Context, element models.Element) error
+	UpdateElementForProcess(ctx context.Context, element models.Element, previousData string, processID int) error
+	LockElementsForUpdate(ctx context.Context, process models.Process, batchSize int) ([]models.Element, error)
+	LockElementsForRevert(ctx context.Context, processID int, batchSize int) ([]models.ProcessElement, error)
+	RevertElementForProcess(ctx context.Context, processElement models.ProcessElement) error
+	GetElementByID(ctx context.Context, id int) (models.Element, error)
+	GetHistoryByElementID(ctx context.Context, elementID int) ([]models.ProcessElement, error)
+	ListElements(ctx context.Context, afterID int, limit int, selector models.ElementSelector) ([]models.Element, error)
+	GetTagsByElementIDs(ctx context.Context, elementIDs []int) (map[int][]string, error)
+	CountElementsByProcessID(ctx context.Context, processID int) (int, error)
+	CountUnrevertedByProcessID(ctx context.Context, processID int) (int, error)
+	CountElementsCreatedBefore(ctx context.Context, date time.Time, selector models.ElementSelector) (int, error)
+	GetLastProgressAt(ctx context.Context, processID int) (*time.Time, error)
 }
 
 type elementRepo struct {
-	db db.Querier
+	db      db.Querier
+	dialect db.Dialect
 }
 
-func NewElementRepository(db db.Querier) ElementRepository {
+func NewElementRepository(q db.Querier) ElementRepository {
 	return &elementRepo{
-		db: db,
+		db:      db.Rebound(q),
+		dialect: db.DialectOf(q),
+	}
+}
+
+// InsertElements inserts elements, and their tags, using chunked multi-row INSERT statements and returns
+// the assigned ids in the same order.
+func (e *elementRepo) InsertElements(ctx context.Context, elements []models.Element) ([]int, error) {
+	ids := make([]int, 0, len(elements))
+
+	for start := 0; start < len(elements); start += insertChunkSize {
+		end := start + insertChunkSize
+		if end > len(elements) {
+			end = len(elements)
+		}
+		chunk := elements[start:end]
+
+		args := make([]interface{}, 0, len(chunk))
+		for _, element := range chunk {
+			args = append(args, element.Data)
+		}
+
+		chunkIDs, err := e.dialect.InsertReturningIDs(
+			ctx,
+			e.db,
+			"INSERT INTO Element (data) VALUES "+strings.TrimSuffix(strings.Repeat("(?),", len(chunk)), ","),
+			args...,
+		)
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, chunkIDs...)
+
+		tagArgs := []interface{}{}
+		for i, element := range chunk {
+			for _, tag := range element.Tags {
+				tagArgs = append(tagArgs, chunkIDs[i], tag)
+			}
+		}
+
+		if len(tagArgs) == 0 {
+			continue
+		}
+
+		if _, err := e.db.ExecContext(
+			ctx,
+			e.dialect.InsertIgnore("ElementTag", []string{"element_id", "tag"}, len(tagArgs)/2),
+			tagArgs...,
+		); err != nil {
+			return ids, err
+		}
 	}
+
+	return ids, nil
 }
 
-func (p *elementRepo) UpdateElementForProcess(element models.Element, processID int) error {
-	if _, err := p.db.Exec(
+func (p *elementRepo) UpdateElement(ctx context.Context, element models.Element) error {
+	_, err := p.db.ExecContext(
+		ctx,
 		"UPDATE Element SET data = ? WHERE id = ?",
 		element.Data,
 		element.ID,
-	); err != nil {
+	)
+	return err
+}
+
+// UpdateElementForProcess updates an element's data and records that it has been processed by the process,
+// along with its previous data so the change can be reverted.
+func (p *elementRepo) UpdateElementForProcess(ctx context.Context, element models.Element, previousData string, processID int) error {
+	if err := p.UpdateElement(ctx, element); err != nil {
 		return err
 	}
 
-	_, err := p.db.Exec(
-		"INSERT INTO ProcessElement (process_id, element_id) VALUES (?, ?)",
+	_, err := p.db.ExecContext(
+		ctx,
+		"INSERT INTO ProcessElement (process_id, element_id, previous_data, new_data) VALUES (?, ?, ?, ?)",
 		processID,
 		element.ID,
+		previousData,
+		element.Data,
 	)
 	return err
 }
 
-func (e *elementRepo) LockElementsForUpdate(processID int, batchSize int) ([]models.Element, error) {
+// LockElementsForRevert locks up to batchSize of the elements a process changed that haven't been reverted,
+// along with their ProcessElement rows, skipping those locked by other transactions.
+func (e *elementRepo) LockElementsForRevert(ctx context.Context, processID int, batchSize int) ([]models.ProcessElement, error) {
+	processElements := []models.ProcessElement{}
+	return processElements, e.db.SelectContext(
+		ctx,
+		&processElements,
+		`
+			SELECT pe.* FROM ProcessElement AS pe
+				INNER JOIN Element AS e ON e.id = pe.element_id
+			WHERE pe.process_id = ? AND pe.reverted_at IS NULL
+			LIMIT ?
+			`+e.dialect.SkipLocked()+`
+		`,
+		processID,
+		batchSize,
+	)
+}
+
+// RevertElementForProcess restores an element's data to what it was before the process changed it and records
+// that the change has been reverted. An element whose data has since been changed again, by another process,
+// is left as it is.
+func (e *elementRepo) RevertElementForProcess(ctx context.Context, processElement models.ProcessElement) error {
+	if _, err := e.db.ExecContext(
+		ctx,
+		"UPDATE Element SET data = ? WHERE id = ? AND data = ?",
+		processElement.PreviousData,
+		processElement.ElementID,
+		processElement.NewData,
+	); err != nil {
+		return err
+	}
+
+	_, err := e.db.ExecContext(
+		ctx,
+		"UPDATE ProcessElement SET reverted_at = CURRENT_TIMESTAMP WHERE process_id = ? AND element_id = ?",
+		processElement.ProcessID,
+		processElement.ElementID,
+	)
+	return err
+}
+
+func (e *elementRepo) GetElementByID(ctx context.Context, id int) (models.Element, error) {
+	element := models.Element{}
+	if err := e.db.GetContext(ctx, &element, `SELECT * FROM Element WHERE id = ?`, id); err != nil {
+		if err == sql.ErrNoRows {
+			return element, ErrNoElementExists
+		}
+		return element, err
+	}
+
+	return element, nil
+}
+
+// GetHistoryByElementID returns every change made to an element by a process, oldest first.
+func (e *elementRepo) GetHistoryByElementID(ctx context.Context, elementID int) ([]models.ProcessElement, error) {
+	history := []models.ProcessElement{}
+	return history, e.db.SelectContext(
+		ctx,
+		&history,
+		`
+			SELECT pe.*, p.name AS process_name, p.transformer FROM ProcessElement AS pe
+				INNER JOIN Process AS p ON p.id = pe.process_id
+			WHERE pe.element_id = ?
+			ORDER BY pe.processed_at, pe.process_id
+		`,
+		elementID,
+	)
+}
+
+// LockElementsForUpdate locks up to batchSize unprocessed elements of a process, skipping those
+// locked by other transactions, dead lettered or waiting to be retried.
+func (e *elementRepo) LockElementsForUpdate(ctx context.Context, process models.Process, batchSize int) ([]models.Element, error) {
+	selectorClause, selectorArgs := elementSelectorClause(e.dialect, "e", process.Selector)
+
+	args := []interface{}{process.ID, process.ID, time.Now().UTC(), process.CreatedAt}
+	args = append(args, selectorArgs...)
+	args = append(args, batchSize)
+
 	elements := []models.Element{}
-	return elements, e.db.Select(&elements, `
+	return elements, e.db.SelectContext(ctx, &elements, `
 		SELECT e.* FROM Element AS e
 		WHERE
 		NOT EXISTS (
@@ -53,41 +217,191 @@ func (e *elementRepo) LockElementsForUpdate(processID int, batchSize int) ([]mod
 			WHERE process_id = ? AND element_id = e.id
 		)
 		AND
-			e.created_at < (SELECT created_at FROM Process WHERE id = ?)
+		NOT EXISTS (
+			SELECT * FROM ProcessElementError
+			WHERE process_id = ? AND element_id = e.id
+			AND (dead_lettered OR next_attempt_at > ?)
+		)
+		AND
+			e.created_at < ?
+		`+selectorClause+`
 		LIMIT ?
-		FOR UPDATE SKIP LOCKED
+		`+e.dialect.SkipLocked()+`
 	`,
-		processID,
-		processID,
-		batchSize,
+		args...,
 	)
 }
 
-func (e *elementRepo) GetElementsByProcessID(processID int) ([]models.Element, error) {
+// ListElements returns up to limit of the elements matched by selector with ids greater than afterID, in id
+// order, so that every element can be paged through by passing the last id of each page to the next call.
+func (e *elementRepo) ListElements(ctx context.Context, afterID int, limit int, selector models.ElementSelector) ([]models.Element, error) {
+	selectorClause, selectorArgs := elementSelectorClause(e.dialect, "e", selector)
+
+	args := append([]interface{}{afterID}, selectorArgs...)
+	args = append(args, limit)
+
 	elements := []models.Element{}
-	return elements, e.db.Select(
+	return elements, e.db.SelectContext(
+		ctx,
 		&elements,
 		`
-			SELECT e.id, e.data, e.created_at FROM Element AS e
-				INNER JOIN ProcessElement AS pe ON e.id = pe.element_id
-			WHERE pe.process_id = ?
+			SELECT e.* FROM Element AS e
+			WHERE e.id > ?
+		`+selectorClause+`
+			ORDER BY e.id
+			LIMIT ?
 		`,
+		args...,
+	)
+}
+
+// GetTagsByElementIDs returns the tags of the given elements keyed by element id. Untagged elements are absent.
+func (e *elementRepo) GetTagsByElementIDs(ctx context.Context, elementIDs []int) (map[int][]string, error) {
+	tags := map[int][]string{}
+	if len(elementIDs) == 0 {
+		return tags, nil
+	}
+
+	args := make([]interface{}, 0, len(elementIDs))
+	for _, id := range elementIDs {
+		args = append(args, id)
+	}
+
+	rows := []struct {
+		ElementID int    `db:"element_id"`
+		Tag       string `db:"tag"`
+	}{}
+	if err := e.db.SelectContext(
+		ctx,
+		&rows,
+		`SELECT element_id, tag FROM ElementTag WHERE element_id IN (`+strings.TrimSuffix(strings.Repeat("?,", len(elementIDs)), ",")+`) ORDER BY element_id, tag`,
+		args...,
+	); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		tags[row.ElementID] = append(tags[row.ElementID], row.Tag)
+	}
+
+	return tags, nil
+}
+
+func (e *elementRepo) CountElementsByProcessID(ctx context.Context, processID int) (int, error) {
+	var count int
+	return count, e.db.GetContext(
+		ctx,
+		&count,
+		`SELECT COUNT(*) FROM ProcessElement WHERE process_id = ?`,
 		processID,
 	)
 }
 
-func (e *elementRepo) GetElementsCreatedBefore(date time.Time) ([]models.Element, error) {
-	elements := []models.Element{}
-	return elements, e.db.Select(
-		&elements,
+func (e *elementRepo) CountUnrevertedByProcessID(ctx context.Context, processID int) (int, error) {
+	var count int
+	return count, e.db.GetContext(
+		ctx,
+		&count,
+		`SELECT COUNT(*) FROM ProcessElement WHERE process_id = ? AND reverted_at IS NULL`,
+		processID,
+	)
+}
+
+// GetLastProgressAt returns when an element of a process was last processed, reverted or failed, nil if none
+// has been. Each is the first row of an ordered query, rather than a MAX, which SQLite returns untyped.
+func (e *elementRepo) GetLastProgressAt(ctx context.Context, processID int) (*time.Time, error) {
+	var last *time.Time
+
+	for _, query := range []string{
+		"SELECT processed_at FROM ProcessElement WHERE process_id = ? ORDER BY processed_at DESC LIMIT 1",
+		"SELECT reverted_at FROM ProcessElement WHERE process_id = ? AND reverted_at IS NOT NULL ORDER BY reverted_at DESC LIMIT 1",
+		"SELECT updated_at FROM ProcessElementError WHERE process_id = ? ORDER BY updated_at DESC LIMIT 1",
+	} {
+		var at time.Time
+		if err := e.db.GetContext(ctx, &at, query, processID); err != nil {
+			if err == sql.ErrNoRows {
+				continue
+			}
+			return nil, err
+		}
+
+		if last == nil || at.After(*last) {
+			last = &at
+		}
+	}
+
+	return last, nil
+}
+
+func (e *elementRepo) CountElementsCreatedBefore(ctx context.Context, date time.Time, selector models.ElementSelector) (int, error) {
+	selectorClause, selectorArgs := elementSelectorClause(e.dialect, "e", selector)
+
+	var count int
+	return count, e.db.GetContext(
+		ctx,
+		&count,
 		`
-			SELECT e.* FROM Element AS e
+			SELECT COUNT(*) FROM Element AS e
 			WHERE e.created_at < ?
-		`,
-		date,
+		`+selectorClause,
+		append([]interface{}{date}, selectorArgs...)...,
 	)
 }
 
+// elementSelectorClause returns the AND conditions, and their args, restricting the Element
+// table aliased as alias to the elements matched by selector.
+func elementSelectorClause(dialect db.Dialect, alias string, selector models.ElementSelector) (string, []interface{}) {
+	conditions := []string{}
+	args := []interface{}{}
+
+	if selector.MinID > 0 {
+		conditions = append(conditions, alias+".id >= ?")
+		args = append(args, selector.MinID)
+	}
+
+	if selector.MaxID > 0 {
+		conditions = append(conditions, alias+".id <= ?")
+		args = append(args, selector.MaxID)
+	}
+
+	if selector.CreatedAfter != nil {
+		conditions = append(conditions, alias+".created_at >= ?")
+		args = append(args, *selector.CreatedAfter)
+	}
+
+	if selector.CreatedBefore != nil {
+		conditions = append(conditions, alias+".created_at < ?")
+		args = append(args, *selector.CreatedBefore)
+	}
+
+	if selector.DataPrefix != "" {
+		conditions = append(conditions, alias+".data LIKE ?"+dialect.LikeEscape())
+		args = append(args, likeEscaper.Replace(selector.DataPrefix)+"%")
+	}
+
+	if selector.DataRegex != "" {
+		conditions = append(conditions, alias+".data "+dialect.RegexpOperator()+" ?")
+		args = append(args, selector.DataRegex)
+	}
+
+	if len(selector.Tags) > 0 {
+		conditions = append(
+			conditions,
+			"EXISTS (SELECT * FROM ElementTag WHERE element_id = "+alias+".id AND tag IN ("+
+				strings.TrimSuffix(strings.Repeat("?, ", len(selector.Tags)), ", ")+"))",
+		)
+		for _, tag := range selector.Tags {
+			args = append(args, tag)
+		}
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return " AND " + strings.Join(conditions, " AND ") + " ", args
+}
+
 type ElementRepositoryFactory interface {
 	CreateElementRepository(db db.Querier) ElementRepository
 }
@@ -0,0 +1,182 @@
+//go:generate mockgen -package repository -source=element_error.go -destination ./mocks/element_error.go
+
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	"github.com/eggsbenjamin/square_enix/internal/app/models"
+)
+
+// maxErrorLength is the length of the ProcessElementError.last_error VARCHAR column.
+const maxErrorLength = 1024
+
+// truncate cuts s to at most n characters, as a VARCHAR(n) column counts them, so a multi-byte character is
+// never split.
+func truncate(s string, n int) string {
+	chars := 0
+	for i := range s {
+		if chars == n {
+			return s[:i]
+		}
+		chars++
+	}
+
+	return s
+}
+
+type ElementErrorRepository interface {
+	SaveFailure(ctx context.Context, failure models.ProcessElementError) error
+	DeleteFailure(ctx context.Context, processID, elementID int) error
+	GetFailuresByElementIDs(ctx context.Context, processID int, elementIDs []int) (map[int]models.ProcessElementError, error)
+	GetFailuresByProcessID(ctx context.Context, processID int, deadLetteredOnly bool) ([]models.ProcessElementError, error)
+	CountDeadLettered(ctx context.Context, processID int) (int, error)
+	CountWaiting(ctx context.Context, processID int, now time.Time) (int, error)
+	RedriveDeadLettered(ctx context.Context, processID int) (int, error)
+}
+
+type elementErrorRepo struct {
+	db      db.Querier
+	dialect db.Dialect
+}
+
+func NewElementErrorRepository(q db.Querier) ElementErrorRepository {
+	return &elementErrorRepo{
+		db:      db.Rebound(q),
+		dialect: db.DialectOf(q),
+	}
+}
+
+// SaveFailure inserts or replaces the failure record of an element within a process.
+func (e *elementErrorRepo) SaveFailure(ctx context.Context, failure models.ProcessElementError) error {
+	failure.LastError = truncate(failure.LastError, maxErrorLength)
+
+	_, err := e.db.ExecContext(
+		ctx,
+		e.dialect.Upsert(
+			"ProcessElementError",
+			[]string{"process_id", "element_id", "attempts", "last_error", "next_attempt_at", "dead_lettered", "updated_at"},
+			[]string{"process_id", "element_id"},
+			[]string{"attempts", "last_error", "next_attempt_at", "dead_lettered", "updated_at"},
+		),
+		failure.ProcessID,
+		failure.ElementID,
+		failure.Attempts,
+		failure.LastError,
+		failure.NextAttemptAt,
+		failure.DeadLettered,
+		time.Now().UTC(),
+	)
+	return err
+}
+
+func (e *elementErrorRepo) DeleteFailure(ctx context.Context, processID, elementID int) error {
+	_, err := e.db.ExecContext(
+		ctx,
+		"DELETE FROM ProcessElementError WHERE process_id = ? AND element_id = ?",
+		processID,
+		elementID,
+	)
+	return err
+}
+
+// GetFailuresByElementIDs returns the failure records of the given elements keyed by element id.
+func (e *elementErrorRepo) GetFailuresByElementIDs(ctx context.Context, processID int, elementIDs []int) (map[int]models.ProcessElementError, error) {
+	failuresByElementID := map[int]models.ProcessElementError{}
+	if len(elementIDs) == 0 {
+		return failuresByElementID, nil
+	}
+
+	args := []interface{}{processID}
+	for _, id := range elementIDs {
+		args = append(args, id)
+	}
+
+	failures := []models.ProcessElementError{}
+	if err := e.db.SelectContext(
+		ctx,
+		&failures,
+		`
+			SELECT * FROM ProcessElementError
+			WHERE process_id = ? AND element_id IN (`+strings.TrimSuffix(strings.Repeat("?,", len(elementIDs)), ",")+`)
+		`,
+		args...,
+	); err != nil {
+		return failuresByElementID, err
+	}
+
+	for _, failure := range failures {
+		failuresByElementID[failure.ElementID] = failure
+	}
+
+	return failuresByElementID, nil
+}
+
+func (e *elementErrorRepo) GetFailuresByProcessID(ctx context.Context, processID int, deadLetteredOnly bool) ([]models.ProcessElementError, error) {
+	query := "SELECT * FROM ProcessElementError WHERE process_id = ?"
+	if deadLetteredOnly {
+		query += " AND dead_lettered"
+	}
+
+	failures := []models.ProcessElementError{}
+	return failures, e.db.SelectContext(ctx, &failures, query+" ORDER BY element_id", processID)
+}
+
+func (e *elementErrorRepo) CountDeadLettered(ctx context.Context, processID int) (int, error) {
+	var count int
+	return count, e.db.GetContext(
+		ctx,
+		&count,
+		"SELECT COUNT(*) FROM ProcessElementError WHERE process_id = ? AND dead_lettered",
+		processID,
+	)
+}
+
+// CountWaiting counts the failed elements of a process that are waiting to be retried after now.
+func (e *elementErrorRepo) CountWaiting(ctx context.Context, processID int, now time.Time) (int, error) {
+	var count int
+	return count, e.db.GetContext(
+		ctx,
+		&count,
+		"SELECT COUNT(*) FROM ProcessElementError WHERE process_id = ? AND NOT dead_lettered AND next_attempt_at > ?",
+		processID,
+		now,
+	)
+}
+
+// RedriveDeadLettered resets the attempts of a process's dead lettered elements so they're retried
+// immediately, returning the number of elements redriven.
+func (e *elementErrorRepo) RedriveDeadLettered(ctx context.Context, processID int) (int, error) {
+	res, err := e.db.ExecContext(
+		ctx,
+		`
+			UPDATE ProcessElementError
+			SET attempts = 0, next_attempt_at = NULL, dead_lettered = FALSE, updated_at = CURRENT_TIMESTAMP
+			WHERE process_id = ? AND dead_lettered
+		`,
+		processID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	redriven, err := res.RowsAffected()
+	return int(redriven), err
+}
+
+type ElementErrorRepositoryFactory interface {
+	CreateElementErrorRepository(db db.Querier) ElementErrorRepository
+}
+
+type elementErrorRepoFactory struct{}
+
+func NewElementErrorRepositoryFactory() ElementErrorRepositoryFactory {
+	return &elementErrorRepoFactory{}
+}
+
+func (e *elementErrorRepoFactory) CreateElementErrorRepository(db db.Querier) ElementErrorRepository {
+	return NewElementErrorRepository(db)
+}
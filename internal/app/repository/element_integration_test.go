@@ -3,60 +3,248 @@
 package repository_test
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	"github.com/eggsbenjamin/square_enix/internal/app/db/dbtest"
+	"github.com/eggsbenjamin/square_enix/internal/app/models"
 	"github.com/eggsbenjamin/square_enix/internal/app/repository"
-	"github.com/eggsbenjamin/square_enix/pkg/env"
-	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/require"
 )
 
 func TestElementRepository(t *testing.T) {
-	dsn := fmt.Sprintf(
-		"%s@tcp(%s:3306)/%s?parseTime=true",
-		env.MustGetEnv("MYSQL_USER"),
-		env.MustGetEnv("MYSQL_HOST"),
-		env.MustGetEnv("MYSQL_DB"),
-	)
-	conn, err := sqlx.Connect("mysql", dsn)
+	conn, err := dbtest.Connect()
 	require.NoError(t, err)
 
-	db := db.NewQuerier(conn)
+	db := db.NewQuerier(conn.DB)
 
-	t.Run("GetElementsCreatedBefore", func(t *testing.T) {
+	t.Run("CountElementsCreatedBefore", func(t *testing.T) {
 		defer func() {
-			_, err := conn.Exec("DELETE FROM ProcessElement")
+			_, err := conn.Exec("DELETE FROM ProcessElementError")
+			_, err = conn.Exec("DELETE FROM ProcessElement")
 			_, err = conn.Exec("DELETE FROM Process")
+			_, err = conn.Exec("DELETE FROM ElementTag")
 			_, err = conn.Exec("DELETE FROM Element")
 			if err != nil {
 				t.Logf("error resetting Process table: %q\n", err)
 			}
 		}()
 
-		_, err := conn.Exec("DELETE FROM ProcessElement")
+		_, err := conn.Exec("DELETE FROM ProcessElementError")
+		require.NoError(t, err)
+
+		_, err = conn.Exec("DELETE FROM ProcessElement")
 		require.NoError(t, err)
 
 		_, err = conn.Exec("DELETE FROM Process")
 		require.NoError(t, err)
 
+		_, err = conn.Exec("DELETE FROM ElementTag")
+		require.NoError(t, err)
+
 		_, err = conn.Exec("DELETE FROM Element")
 		require.NoError(t, err)
 
 		_, err = conn.Exec("INSERT INTO Element (id, data ) VALUES (1, 'test')")
 		require.NoError(t, err)
 
-		_, err = conn.Exec("INSERT INTO Element (id, data, created_at) VALUES (2, 'test', NOW() + INTERVAL 1 DAY)")
+		_, err = conn.Exec("INSERT INTO Element (id, data, created_at) VALUES (2, 'test', ?)", time.Now().Add(24*time.Hour))
+		require.NoError(t, err)
+
+		repo := repository.NewElementRepository(db)
+		require.NoError(t, err)
+
+		count, err := repo.CountElementsCreatedBefore(context.Background(), time.Now(), models.ElementSelector{})
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+	})
+
+	t.Run("InsertElements", func(t *testing.T) {
+		defer func() {
+			_, err := conn.Exec("DELETE FROM ProcessElementError")
+			_, err = conn.Exec("DELETE FROM ProcessElement")
+			_, err = conn.Exec("DELETE FROM Process")
+			_, err = conn.Exec("DELETE FROM ElementTag")
+			_, err = conn.Exec("DELETE FROM Element")
+			if err != nil {
+				t.Logf("error resetting Process table: %q\n", err)
+			}
+		}()
+
+		_, err := conn.Exec("DELETE FROM ProcessElementError")
+		require.NoError(t, err)
+
+		_, err = conn.Exec("DELETE FROM ProcessElement")
+		require.NoError(t, err)
+
+		_, err = conn.Exec("DELETE FROM Process")
+		require.NoError(t, err)
+
+		_, err = conn.Exec("DELETE FROM ElementTag")
+		require.NoError(t, err)
+
+		_, err = conn.Exec("DELETE FROM Element")
+		require.NoError(t, err)
+
+		elements := make([]models.Element, 1201)
+		for i := range elements {
+			elements[i].Data = fmt.Sprintf("test %d", i)
+		}
+
+		repo := repository.NewElementRepository(db)
+
+		ids, err := repo.InsertElements(context.Background(), elements)
+		require.NoError(t, err)
+		require.Equal(t, len(elements), len(ids))
+
+		for i, id := range ids {
+			var data string
+			require.NoError(t, conn.Get(&data, "SELECT data FROM Element WHERE id = ?", id))
+			require.Equal(t, elements[i].Data, data)
+		}
+	})
+
+	t.Run("SaveFailure Truncates Errors", func(t *testing.T) {
+		defer func() {
+			_, err := conn.Exec("DELETE FROM ProcessElementError")
+			_, err = conn.Exec("DELETE FROM Process")
+			_, err = conn.Exec("DELETE FROM Element")
+			if err != nil {
+				t.Logf("error resetting ProcessElementError table: %q\n", err)
+			}
+		}()
+
+		_, err := conn.Exec("DELETE FROM ProcessElementError")
+		require.NoError(t, err)
+
+		_, err = conn.Exec("INSERT INTO Process (id, name, status) VALUES (1, 'test', 'RUNNING')")
+		require.NoError(t, err)
+
+		_, err = conn.Exec("INSERT INTO Element (id, data) VALUES (1, 'test')")
+		require.NoError(t, err)
+
+		// a multi-byte character straddles the column's length in bytes
+		repo := repository.NewElementErrorRepository(db)
+		require.NoError(t, repo.SaveFailure(context.Background(), models.ProcessElementError{
+			ProcessID: 1,
+			ElementID: 1,
+			Attempts:  1,
+			LastError: "x" + strings.Repeat("é", 1100),
+		}))
+
+		failures, err := repo.GetFailuresByProcessID(context.Background(), 1, false)
+		require.NoError(t, err)
+		require.Len(t, failures, 1)
+		require.True(t, utf8.ValidString(failures[0].LastError))
+		require.Equal(t, 1024, utf8.RuneCountInString(failures[0].LastError))
+	})
+
+	t.Run("CountWaiting", func(t *testing.T) {
+		defer func() {
+			_, err := conn.Exec("DELETE FROM ProcessElementError")
+			_, err = conn.Exec("DELETE FROM Process")
+			_, err = conn.Exec("DELETE FROM Element")
+			if err != nil {
+				t.Logf("error resetting ProcessElementError table: %q\n", err)
+			}
+		}()
+
+		_, err := conn.Exec("DELETE FROM ProcessElementError")
+		require.NoError(t, err)
+
+		_, err = conn.Exec("INSERT INTO Process (id, name, status) VALUES (1, 'test', 'RUNNING')")
+		require.NoError(t, err)
+
+		_, err = conn.Exec("INSERT INTO Element (id, data) VALUES (1, 'test'), (2, 'test'), (3, 'test')")
+		require.NoError(t, err)
+
+		// only the element due to be retried later is waiting, not the one due now nor the dead lettered one
+		now := time.Now().UTC()
+		later, earlier := now.Add(time.Hour), now.Add(-time.Hour)
+
+		repo := repository.NewElementErrorRepository(db)
+		for _, failure := range []models.ProcessElementError{
+			{ProcessID: 1, ElementID: 1, Attempts: 1, NextAttemptAt: &later},
+			{ProcessID: 1, ElementID: 2, Attempts: 1, NextAttemptAt: &earlier},
+			{ProcessID: 1, ElementID: 3, Attempts: 3, DeadLettered: true},
+		} {
+			require.NoError(t, repo.SaveFailure(context.Background(), failure))
+		}
+
+		waiting, err := repo.CountWaiting(context.Background(), 1, now)
+		require.NoError(t, err)
+		require.Equal(t, 1, waiting)
+	})
+
+	t.Run("Selector", func(t *testing.T) {
+		defer func() {
+			_, err := conn.Exec("DELETE FROM ProcessElementError")
+			_, err = conn.Exec("DELETE FROM ProcessElement")
+			_, err = conn.Exec("DELETE FROM Process")
+			_, err = conn.Exec("DELETE FROM ElementTag")
+			_, err = conn.Exec("DELETE FROM Element")
+			if err != nil {
+				t.Logf("error resetting Process table: %q\n", err)
+			}
+		}()
+
+		_, err := conn.Exec("DELETE FROM ProcessElementError")
+		require.NoError(t, err)
+
+		_, err = conn.Exec("DELETE FROM ProcessElement")
+		require.NoError(t, err)
+
+		_, err = conn.Exec("DELETE FROM Process")
+		require.NoError(t, err)
+
+		_, err = conn.Exec("DELETE FROM ElementTag")
+		require.NoError(t, err)
+
+		_, err = conn.Exec("DELETE FROM Element")
 		require.NoError(t, err)
 
 		repo := repository.NewElementRepository(db)
+
+		ids, err := repo.InsertElements(context.Background(), []models.Element{
+			{Data: "apple", Tags: []string{"fruit", "red"}},
+			{Data: "a_b", Tags: []string{"other"}},
+			{Data: "avocado", Tags: []string{"fruit"}},
+			{Data: "beetroot", Tags: []string{"red"}},
+		})
 		require.NoError(t, err)
 
-		elements, err := repo.GetElementsCreatedBefore(time.Now())
+		_, err = conn.Exec("UPDATE Element SET created_at = ? WHERE id = ?", time.Now().Add(-48*time.Hour), ids[0])
 		require.NoError(t, err)
-		require.Equal(t, 1, len(elements))
-		require.Equal(t, 1, elements[0].ID)
+
+		weekAgo := time.Now().Add(-7 * 24 * time.Hour)
+		dayAgo := time.Now().Add(-24 * time.Hour)
+
+		tests := []struct {
+			name     string
+			selector models.ElementSelector
+			expected int
+		}{
+			{"Unbounded", models.ElementSelector{}, 4},
+			{"ID Range", models.ElementSelector{MinID: ids[1], MaxID: ids[2]}, 2},
+			{"Created Window", models.ElementSelector{CreatedAfter: &weekAgo, CreatedBefore: &dayAgo}, 1},
+			{"Data Prefix", models.ElementSelector{DataPrefix: "a"}, 3},
+			{"Data Prefix Wildcard", models.ElementSelector{DataPrefix: "a_"}, 1},
+			{"Data Regex", models.ElementSelector{DataRegex: "^[ab].*t$"}, 1},
+			{"Tags", models.ElementSelector{Tags: []string{"fruit", "other"}}, 3},
+			{"Combined", models.ElementSelector{DataPrefix: "a", Tags: []string{"red"}}, 1},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				count, err := repo.CountElementsCreatedBefore(context.Background(), time.Now().Add(time.Hour), test.selector)
+				require.NoError(t, err)
+				require.Equal(t, test.expected, count)
+			})
+		}
 	})
 }
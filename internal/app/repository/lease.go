@@ -0,0 +1,101 @@
+//go:generate mockgen -package repository -source=lease.go -destination ./mocks/lease.go
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	"github.com/eggsbenjamin/square_enix/internal/app/models"
+)
+
+// LeaseRepository manages leases held by one instance at a time. Expiry is judged by the clock of the instance
+// acquiring a lease, so the clocks of the instances sharing one must agree to well within its ttl.
+type LeaseRepository interface {
+	// AcquireLease takes the lease named name for holder until ttl from now, or renews it if holder already
+	// holds it. It reports whether holder holds the lease, which it doesn't while another holder's is unexpired.
+	AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (models.Lease, bool, error)
+
+	// ReleaseLease gives up holder's lease named name, if it holds it, so another holder can take it at once.
+	ReleaseLease(ctx context.Context, name, holder string) error
+}
+
+type leaseRepo struct {
+	db      db.Querier
+	dialect db.Dialect
+}
+
+func NewLeaseRepository(q db.Querier) LeaseRepository {
+	return &leaseRepo{
+		db:      db.Rebound(q),
+		dialect: db.DialectOf(q),
+	}
+}
+
+// AcquireLease inserts the lease if no instance has held it before, otherwise takes it over if it's held by
+// holder or has expired. Concurrent takeovers of an expired lease are serialised by the row's lock, the WHERE
+// clause of all but the first is no longer true once it's committed.
+func (l *leaseRepo) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (models.Lease, bool, error) {
+	now := time.Now().UTC()
+	expiresAt := now.Add(ttl)
+
+	if _, err := l.db.ExecContext(
+		ctx,
+		l.dialect.InsertIgnore("Lease", []string{"name", "holder", "expires_at"}, 1),
+		name,
+		holder,
+		expiresAt,
+	); err != nil {
+		return models.Lease{}, false, err
+	}
+
+	if _, err := l.db.ExecContext(
+		ctx,
+		`
+			UPDATE Lease
+			SET holder = ?, expires_at = ?
+			WHERE name = ? AND (holder = ? OR expires_at <= ?)
+		`,
+		holder,
+		expiresAt,
+		name,
+		holder,
+		now,
+	); err != nil {
+		return models.Lease{}, false, err
+	}
+
+	lease := models.Lease{}
+	if err := l.db.GetContext(ctx, &lease, "SELECT name, holder, expires_at FROM Lease WHERE name = ?", name); err != nil {
+		return models.Lease{}, false, err
+	}
+
+	return lease, lease.Holder == holder, nil
+}
+
+// ReleaseLease expires the lease rather than deleting it, so its row is only ever inserted once.
+func (l *leaseRepo) ReleaseLease(ctx context.Context, name, holder string) error {
+	_, err := l.db.ExecContext(
+		ctx,
+		"UPDATE Lease SET expires_at = ? WHERE name = ? AND holder = ?",
+		time.Now().UTC(),
+		name,
+		holder,
+	)
+	return err
+}
+
+type LeaseRepositoryFactory interface {
+	CreateLeaseRepository(db db.Querier) LeaseRepository
+}
+
+type leaseRepoFactory struct{}
+
+func NewLeaseRepositoryFactory() LeaseRepositoryFactory {
+	return &leaseRepoFactory{}
+}
+
+func (l *leaseRepoFactory) CreateLeaseRepository(db db.Querier) LeaseRepository {
+	return NewLeaseRepository(db)
+}
@@ -0,0 +1,90 @@
+// +build integration
+
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	"github.com/eggsbenjamin/square_enix/internal/app/db/dbtest"
+	"github.com/eggsbenjamin/square_enix/internal/app/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaseRepository(t *testing.T) {
+	conn, err := dbtest.Connect()
+	require.NoError(t, err)
+
+	db := db.NewQuerier(conn.DB)
+	ctx := context.Background()
+
+	t.Run("AcquireLease", func(t *testing.T) {
+		defer func() {
+			if _, err := conn.Exec("DELETE FROM Lease"); err != nil {
+				t.Logf("error resetting Lease table: %q\n", err)
+			}
+		}()
+
+		_, err := conn.Exec("DELETE FROM Lease")
+		require.NoError(t, err)
+
+		repo := repository.NewLeaseRepository(db)
+
+		lease, acquired, err := repo.AcquireLease(ctx, "test", "a", time.Minute)
+		require.NoError(t, err)
+		require.True(t, acquired)
+		require.Equal(t, "a", lease.Holder)
+		require.WithinDuration(t, time.Now().Add(time.Minute), lease.ExpiresAt, 2*time.Second)
+
+		lease, acquired, err = repo.AcquireLease(ctx, "test", "b", time.Minute)
+		require.NoError(t, err)
+		require.False(t, acquired)
+		require.Equal(t, "a", lease.Holder)
+
+		// the holder renews its lease
+		_, acquired, err = repo.AcquireLease(ctx, "test", "a", time.Minute)
+		require.NoError(t, err)
+		require.True(t, acquired)
+
+		// an expired lease is taken over
+		_, err = conn.Exec("UPDATE Lease SET expires_at = ? WHERE name = 'test'", time.Now().Add(-time.Minute))
+		require.NoError(t, err)
+
+		lease, acquired, err = repo.AcquireLease(ctx, "test", "b", time.Minute)
+		require.NoError(t, err)
+		require.True(t, acquired)
+		require.Equal(t, "b", lease.Holder)
+	})
+
+	t.Run("ReleaseLease", func(t *testing.T) {
+		defer func() {
+			if _, err := conn.Exec("DELETE FROM Lease"); err != nil {
+				t.Logf("error resetting Lease table: %q\n", err)
+			}
+		}()
+
+		_, err := conn.Exec("DELETE FROM Lease")
+		require.NoError(t, err)
+
+		repo := repository.NewLeaseRepository(db)
+
+		_, acquired, err := repo.AcquireLease(ctx, "test", "a", time.Minute)
+		require.NoError(t, err)
+		require.True(t, acquired)
+
+		// only the holder releases the lease
+		require.NoError(t, repo.ReleaseLease(ctx, "test", "b"))
+
+		_, acquired, err = repo.AcquireLease(ctx, "test", "b", time.Minute)
+		require.NoError(t, err)
+		require.False(t, acquired)
+
+		require.NoError(t, repo.ReleaseLease(ctx, "test", "a"))
+
+		_, acquired, err = repo.AcquireLease(ctx, "test", "b", time.Minute)
+		require.NoError(t, err)
+		require.True(t, acquired)
+	})
+}
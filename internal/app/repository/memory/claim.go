@@ -0,0 +1,169 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	"github.com/eggsbenjamin/square_enix/internal/app/models"
+	"github.com/eggsbenjamin/square_enix/internal/app/repository"
+)
+
+type claimRepo struct {
+	conn conn
+}
+
+func NewClaimRepository(q db.Querier) repository.ClaimRepository {
+	return &claimRepo{
+		conn: connOf(q),
+	}
+}
+
+// SessionID returns the id of the repository's transaction, or of a transaction begun for the call if it was
+// created with a DB, as each transaction is a session of its own.
+func (c *claimRepo) SessionID(ctx context.Context) (int64, error) {
+	var session int64
+	err := c.conn.run(ctx, func(t *tx) error {
+		session = t.session
+		return nil
+	})
+
+	return session, err
+}
+
+func (c *claimRepo) ClaimElements(ctx context.Context, sessionID int64, processID int, elementIDs []int, ttl time.Duration) error {
+	return c.conn.run(ctx, func(t *tx) error {
+		claimed := map[int]bool{}
+		for _, id := range elementIDs {
+			claimed[id] = true
+		}
+
+		for key, row := range t.rows(claimTable) {
+			if claim := row.(models.ElementClaim); claim.SessionID == sessionID || claimed[claim.ElementID] {
+				t.delete(claimTable, key)
+			}
+		}
+
+		now := time.Now().UTC()
+		for _, id := range elementIDs {
+			t.put(claimTable, id, models.ElementClaim{
+				ElementID: id,
+				ProcessID: processID,
+				SessionID: sessionID,
+				ClaimedAt: now,
+				ExpiresAt: now.Add(ttl),
+			})
+		}
+
+		return nil
+	})
+}
+
+func (c *claimRepo) RenewClaims(ctx context.Context, sessionID int64, elementIDs []int, ttl time.Duration) error {
+	return c.conn.run(ctx, func(t *tx) error {
+		expiresAt := time.Now().UTC().Add(ttl)
+		for _, id := range elementIDs {
+			if row, ok := t.get(claimTable, id); ok && row.(models.ElementClaim).SessionID == sessionID {
+				claim := row.(models.ElementClaim)
+				claim.ExpiresAt = expiresAt
+				t.put(claimTable, id, claim)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (c *claimRepo) ReleaseClaims(ctx context.Context, sessionID int64, elementIDs []int) error {
+	return c.conn.run(ctx, func(t *tx) error {
+		for _, id := range elementIDs {
+			if row, ok := t.get(claimTable, id); ok && row.(models.ElementClaim).SessionID == sessionID {
+				t.delete(claimTable, id)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (c *claimRepo) ReleaseExpired(ctx context.Context, sessionID int64, elementIDs []int, now time.Time) (int, error) {
+	released := 0
+	err := c.conn.run(ctx, func(t *tx) error {
+		for _, id := range elementIDs {
+			if row, ok := t.get(claimTable, id); ok {
+				if claim := row.(models.ElementClaim); claim.SessionID == sessionID && claim.ExpiresAt.Before(now) {
+					t.delete(claimTable, id)
+					released++
+				}
+			}
+		}
+
+		return nil
+	})
+
+	return released, err
+}
+
+func (c *claimRepo) GetExpired(ctx context.Context, now time.Time) ([]models.ElementClaim, error) {
+	claims := []models.ElementClaim{}
+	err := c.conn.run(ctx, func(t *tx) error {
+		for _, row := range t.rows(claimTable) {
+			if claim := row.(models.ElementClaim); claim.ExpiresAt.Before(now) {
+				claims = append(claims, claim)
+			}
+		}
+
+		return nil
+	})
+
+	sort.Slice(claims, func(i, j int) bool {
+		if claims[i].SessionID != claims[j].SessionID {
+			return claims[i].SessionID < claims[j].SessionID
+		}
+		return claims[i].ElementID < claims[j].ElementID
+	})
+
+	return claims, err
+}
+
+func (c *claimRepo) LockElements(ctx context.Context, elementIDs []int) ([]int, error) {
+	locked := []int{}
+	err := c.conn.run(ctx, func(t *tx) error {
+		for _, id := range elementIDs {
+			if _, ok := t.get(elementTable, id); ok && t.claim(elementTable, id) {
+				locked = append(locked, id)
+			}
+		}
+
+		return nil
+	})
+
+	sort.Ints(locked)
+	return locked, err
+}
+
+// KillSession rolls back the session's transaction, whose owner's subsequent operations fail with
+// sql.ErrTxDone, as they would with the bad connection of a killed session.
+func (c *claimRepo) KillSession(ctx context.Context, sessionID int64) error {
+	var s *store
+	if err := c.conn.run(ctx, func(t *tx) error {
+		s = t.store
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	s.kill(sessionID)
+	return nil
+}
+
+type claimRepoFactory struct{}
+
+func NewClaimRepositoryFactory() repository.ClaimRepositoryFactory {
+	return &claimRepoFactory{}
+}
+
+func (c *claimRepoFactory) CreateClaimRepository(db db.Querier) repository.ClaimRepository {
+	return NewClaimRepository(db)
+}
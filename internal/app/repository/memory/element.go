@@ -0,0 +1,520 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	"github.com/eggsbenjamin/square_enix/internal/app/models"
+	"github.com/eggsbenjamin/square_enix/internal/app/repository"
+)
+
+// maxDataLength and maxTagLength are the lengths of the Element.data and ElementTag.tag columns.
+const (
+	maxDataLength = 50
+	maxTagLength  = 50
+)
+
+var (
+	ErrDataTooLong  = errors.New("data too long")
+	ErrDuplicateKey = errors.New("duplicate key")
+)
+
+type processElementKey struct {
+	processID int
+	elementID int
+}
+
+type elementTagKey struct {
+	elementID int
+	tag       string
+}
+
+type elementRepo struct {
+	conn conn
+}
+
+func NewElementRepository(q db.Querier) repository.ElementRepository {
+	return &elementRepo{
+		conn: connOf(q),
+	}
+}
+
+// InsertElements inserts elements, and their tags, returning the assigned ids in the same order. An element's
+// CreatedAt defaults to now, an element may be given an earlier one to be eligible for an existing process.
+func (e *elementRepo) InsertElements(ctx context.Context, elements []models.Element) ([]int, error) {
+	ids := make([]int, 0, len(elements))
+	err := e.conn.run(ctx, func(t *tx) error {
+		now := time.Now().UTC()
+
+		for _, element := range elements {
+			if err := checkLength("data", element.Data, maxDataLength); err != nil {
+				return err
+			}
+
+			inserted := models.Element{
+				ID:        t.nextID(elementTable),
+				Data:      element.Data,
+				CreatedAt: element.CreatedAt,
+			}
+			if inserted.CreatedAt.IsZero() {
+				inserted.CreatedAt = now
+			}
+			t.put(elementTable, inserted.ID, inserted)
+			ids = append(ids, inserted.ID)
+
+			for _, tag := range element.Tags {
+				if err := checkLength("tag", tag, maxTagLength); err != nil {
+					return err
+				}
+
+				t.put(elementTagTable, elementTagKey{inserted.ID, tag}, tag)
+			}
+		}
+
+		return nil
+	})
+
+	return ids, err
+}
+
+func (e *elementRepo) UpdateElement(ctx context.Context, element models.Element) error {
+	return e.conn.run(ctx, func(t *tx) error {
+		return updateElement(t, element)
+	})
+}
+
+// UpdateElementForProcess updates an element's data and records that it has been processed by the process,
+// along with its previous data so the change can be reverted.
+func (e *elementRepo) UpdateElementForProcess(ctx context.Context, element models.Element, previousData string, processID int) error {
+	return e.conn.run(ctx, func(t *tx) error {
+		if err := updateElement(t, element); err != nil {
+			return err
+		}
+
+		key := processElementKey{processID, element.ID}
+		if _, ok := t.get(processElementTable, key); ok {
+			return fmt.Errorf("%w: element %d has been processed by process %d", ErrDuplicateKey, element.ID, processID)
+		}
+
+		t.put(processElementTable, key, models.ProcessElement{
+			ProcessID:    processID,
+			ElementID:    element.ID,
+			PreviousData: previousData,
+			NewData:      element.Data,
+			ProcessedAt:  time.Now().UTC(),
+		})
+
+		return nil
+	})
+}
+
+func updateElement(t *tx, element models.Element) error {
+	if err := checkLength("data", element.Data, maxDataLength); err != nil {
+		return err
+	}
+
+	row, ok := t.get(elementTable, element.ID)
+	if !ok {
+		return nil
+	}
+
+	updated := row.(models.Element)
+	updated.Data = element.Data
+	t.put(elementTable, updated.ID, updated)
+
+	return nil
+}
+
+// LockElementsForUpdate claims up to batchSize unprocessed elements of a process, skipping those claimed by
+// other transactions, dead lettered or waiting to be retried.
+func (e *elementRepo) LockElementsForUpdate(ctx context.Context, process models.Process, batchSize int) ([]models.Element, error) {
+	locked := []models.Element{}
+	err := e.conn.run(ctx, func(t *tx) error {
+		now := time.Now().UTC()
+		processed := t.rows(processElementTable)
+		failures := t.rows(elementErrorTable)
+		tags := elementTags(t)
+
+		for _, element := range elements(t) {
+			if len(locked) == batchSize {
+				break
+			}
+
+			key := processElementKey{process.ID, element.ID}
+			if _, ok := processed[key]; ok {
+				continue
+			}
+
+			if row, ok := failures[key]; ok {
+				failure := row.(models.ProcessElementError)
+				if failure.DeadLettered || (failure.NextAttemptAt != nil && failure.NextAttemptAt.After(now)) {
+					continue
+				}
+			}
+
+			if !element.CreatedAt.Before(process.CreatedAt) {
+				continue
+			}
+
+			matched, err := matches(element, tags[element.ID], process.Selector)
+			if err != nil {
+				return err
+			}
+
+			if matched && t.claim(elementTable, element.ID) {
+				locked = append(locked, element)
+			}
+		}
+
+		return nil
+	})
+
+	return locked, err
+}
+
+// LockElementsForRevert claims up to batchSize of the changes a process made that haven't been reverted, along
+// with their elements as the databases lock the rows of both tables, skipping those claimed by other
+// transactions.
+func (e *elementRepo) LockElementsForRevert(ctx context.Context, processID int, batchSize int) ([]models.ProcessElement, error) {
+	locked := []models.ProcessElement{}
+	err := e.conn.run(ctx, func(t *tx) error {
+		for _, processElement := range processElements(t) {
+			if len(locked) == batchSize {
+				break
+			}
+
+			if processElement.ProcessID != processID || processElement.RevertedAt != nil {
+				continue
+			}
+
+			if t.claim(processElementTable, processElementKey{processID, processElement.ElementID}) && t.claim(elementTable, processElement.ElementID) {
+				locked = append(locked, processElement)
+			}
+		}
+
+		return nil
+	})
+
+	return locked, err
+}
+
+// RevertElementForProcess restores an element's data to what it was before the process changed it and records
+// that the change has been reverted. An element whose data has since been changed again, by another process,
+// is left as it is.
+func (e *elementRepo) RevertElementForProcess(ctx context.Context, processElement models.ProcessElement) error {
+	return e.conn.run(ctx, func(t *tx) error {
+		if row, ok := t.get(elementTable, processElement.ElementID); ok {
+			element := row.(models.Element)
+			if element.Data == processElement.NewData {
+				element.Data = processElement.PreviousData
+				t.put(elementTable, element.ID, element)
+			}
+		}
+
+		key := processElementKey{processElement.ProcessID, processElement.ElementID}
+		if row, ok := t.get(processElementTable, key); ok {
+			reverted := row.(models.ProcessElement)
+			now := time.Now().UTC()
+			reverted.RevertedAt = &now
+			t.put(processElementTable, key, reverted)
+		}
+
+		return nil
+	})
+}
+
+func (e *elementRepo) GetElementByID(ctx context.Context, id int) (models.Element, error) {
+	var element models.Element
+	err := e.conn.run(ctx, func(t *tx) error {
+		row, ok := t.get(elementTable, id)
+		if !ok {
+			return repository.ErrNoElementExists
+		}
+
+		element = row.(models.Element)
+		return nil
+	})
+
+	return element, err
+}
+
+// GetHistoryByElementID returns every change made to an element by a process, oldest first.
+func (e *elementRepo) GetHistoryByElementID(ctx context.Context, elementID int) ([]models.ProcessElement, error) {
+	history := []models.ProcessElement{}
+	err := e.conn.run(ctx, func(t *tx) error {
+		for _, processElement := range processElements(t) {
+			if processElement.ElementID != elementID {
+				continue
+			}
+
+			row, ok := t.get(processTable, processElement.ProcessID)
+			if !ok {
+				continue
+			}
+
+			process := row.(models.Process)
+			processElement.ProcessName = process.Name
+			processElement.Transformer = process.Transformer
+			history = append(history, processElement)
+		}
+
+		return nil
+	})
+
+	sort.SliceStable(history, func(i, j int) bool {
+		if !history[i].ProcessedAt.Equal(history[j].ProcessedAt) {
+			return history[i].ProcessedAt.Before(history[j].ProcessedAt)
+		}
+		return history[i].ProcessID < history[j].ProcessID
+	})
+
+	return history, err
+}
+
+// ListElements returns up to limit of the elements matched by selector with ids greater than afterID, in id
+// order, so that every element can be paged through by passing the last id of each page to the next call.
+func (e *elementRepo) ListElements(ctx context.Context, afterID int, limit int, selector models.ElementSelector) ([]models.Element, error) {
+	return e.selectElements(ctx, selector, limit, func(element models.Element) bool {
+		return element.ID > afterID
+	})
+}
+
+// GetTagsByElementIDs returns the tags of the given elements keyed by element id. Untagged elements are absent.
+func (e *elementRepo) GetTagsByElementIDs(ctx context.Context, elementIDs []int) (map[int][]string, error) {
+	tags := map[int][]string{}
+	err := e.conn.run(ctx, func(t *tx) error {
+		all := elementTags(t)
+
+		for _, id := range elementIDs {
+			if elementTags, ok := all[id]; ok {
+				tags[id] = elementTags
+			}
+		}
+
+		return nil
+	})
+
+	return tags, err
+}
+
+func (e *elementRepo) CountElementsByProcessID(ctx context.Context, processID int) (int, error) {
+	return e.countProcessElements(ctx, func(processElement models.ProcessElement) bool {
+		return processElement.ProcessID == processID
+	})
+}
+
+func (e *elementRepo) CountUnrevertedByProcessID(ctx context.Context, processID int) (int, error) {
+	return e.countProcessElements(ctx, func(processElement models.ProcessElement) bool {
+		return processElement.ProcessID == processID && processElement.RevertedAt == nil
+	})
+}
+
+func (e *elementRepo) GetLastProgressAt(ctx context.Context, processID int) (*time.Time, error) {
+	var last *time.Time
+	err := e.conn.run(ctx, func(t *tx) error {
+		latest := func(at time.Time) {
+			if last == nil || at.After(*last) {
+				last = &at
+			}
+		}
+
+		for _, processElement := range processElements(t) {
+			if processElement.ProcessID != processID {
+				continue
+			}
+
+			latest(processElement.ProcessedAt)
+			if processElement.RevertedAt != nil {
+				latest(*processElement.RevertedAt)
+			}
+		}
+
+		for _, row := range t.rows(elementErrorTable) {
+			if failure := row.(models.ProcessElementError); failure.ProcessID == processID {
+				latest(failure.UpdatedAt)
+			}
+		}
+
+		return nil
+	})
+
+	return last, err
+}
+
+func (e *elementRepo) CountElementsCreatedBefore(ctx context.Context, date time.Time, selector models.ElementSelector) (int, error) {
+	elements, err := e.selectElements(ctx, selector, -1, func(element models.Element) bool {
+		return element.CreatedAt.Before(date)
+	})
+	return len(elements), err
+}
+
+// selectElements returns up to limit, or every if limit is negative, of the elements matched by selector and
+// by filter, in id order.
+func (e *elementRepo) selectElements(
+	ctx context.Context,
+	selector models.ElementSelector,
+	limit int,
+	filter func(element models.Element) bool,
+) ([]models.Element, error) {
+	selected := []models.Element{}
+	err := e.conn.run(ctx, func(t *tx) error {
+		tags := elementTags(t)
+
+		for _, element := range elements(t) {
+			if len(selected) == limit {
+				break
+			}
+
+			if !filter(element) {
+				continue
+			}
+
+			matched, err := matches(element, tags[element.ID], selector)
+			if err != nil {
+				return err
+			}
+
+			if matched {
+				selected = append(selected, element)
+			}
+		}
+
+		return nil
+	})
+
+	return selected, err
+}
+
+func (e *elementRepo) countProcessElements(ctx context.Context, filter func(processElement models.ProcessElement) bool) (int, error) {
+	var count int
+	err := e.conn.run(ctx, func(t *tx) error {
+		for _, processElement := range processElements(t) {
+			if filter(processElement) {
+				count++
+			}
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+// matches reports whether an element with tags is matched by selector.
+func matches(element models.Element, tags []string, selector models.ElementSelector) (bool, error) {
+	if selector.MinID > 0 && element.ID < selector.MinID {
+		return false, nil
+	}
+
+	if selector.MaxID > 0 && element.ID > selector.MaxID {
+		return false, nil
+	}
+
+	if selector.CreatedAfter != nil && element.CreatedAt.Before(*selector.CreatedAfter) {
+		return false, nil
+	}
+
+	if selector.CreatedBefore != nil && !element.CreatedAt.Before(*selector.CreatedBefore) {
+		return false, nil
+	}
+
+	if !strings.HasPrefix(element.Data, selector.DataPrefix) {
+		return false, nil
+	}
+
+	if selector.DataRegex != "" {
+		matched, err := regexp.MatchString(selector.DataRegex, element.Data)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	if len(selector.Tags) == 0 {
+		return true, nil
+	}
+
+	for _, tag := range selector.Tags {
+		for _, elementTag := range tags {
+			if tag == elementTag {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+func checkLength(column, value string, max int) error {
+	if len(value) > max {
+		return fmt.Errorf("%w: %s is %d characters, maximum is %d", ErrDataTooLong, column, len(value), max)
+	}
+
+	return nil
+}
+
+// elements returns the elements the transaction sees, ordered by id.
+func elements(t *tx) []models.Element {
+	rows := t.rows(elementTable)
+
+	all := make([]models.Element, 0, len(rows))
+	for _, row := range rows {
+		all = append(all, row.(models.Element))
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].ID < all[j].ID
+	})
+
+	return all
+}
+
+// elementTags returns the sorted tags of the elements the transaction sees keyed by element id.
+func elementTags(t *tx) map[int][]string {
+	tags := map[int][]string{}
+	for key := range t.rows(elementTagTable) {
+		key := key.(elementTagKey)
+		tags[key.elementID] = append(tags[key.elementID], key.tag)
+	}
+
+	for _, elementTags := range tags {
+		sort.Strings(elementTags)
+	}
+
+	return tags
+}
+
+// processElements returns the changes made to elements by processes the transaction sees, ordered by process
+// and element id.
+func processElements(t *tx) []models.ProcessElement {
+	rows := t.rows(processElementTable)
+
+	all := make([]models.ProcessElement, 0, len(rows))
+	for _, row := range rows {
+		all = append(all, row.(models.ProcessElement))
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].ProcessID != all[j].ProcessID {
+			return all[i].ProcessID < all[j].ProcessID
+		}
+		return all[i].ElementID < all[j].ElementID
+	})
+
+	return all
+}
+
+type elementRepoFactory struct{}
+
+func NewElementRepositoryFactory() repository.ElementRepositoryFactory {
+	return &elementRepoFactory{}
+}
+
+func (e *elementRepoFactory) CreateElementRepository(db db.Querier) repository.ElementRepository {
+	return NewElementRepository(db)
+}
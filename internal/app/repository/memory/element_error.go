@@ -0,0 +1,160 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	"github.com/eggsbenjamin/square_enix/internal/app/models"
+	"github.com/eggsbenjamin/square_enix/internal/app/repository"
+)
+
+// maxErrorLength is the length of the ProcessElementError.last_error column.
+const maxErrorLength = 1024
+
+// truncate cuts s to at most n characters, as a VARCHAR(n) column counts them.
+func truncate(s string, n int) string {
+	chars := 0
+	for i := range s {
+		if chars == n {
+			return s[:i]
+		}
+		chars++
+	}
+
+	return s
+}
+
+type elementErrorRepo struct {
+	conn conn
+}
+
+func NewElementErrorRepository(q db.Querier) repository.ElementErrorRepository {
+	return &elementErrorRepo{
+		conn: connOf(q),
+	}
+}
+
+// SaveFailure inserts or replaces the failure record of an element within a process.
+func (e *elementErrorRepo) SaveFailure(ctx context.Context, failure models.ProcessElementError) error {
+	failure.LastError = truncate(failure.LastError, maxErrorLength)
+
+	return e.conn.run(ctx, func(t *tx) error {
+		failure.UpdatedAt = time.Now().UTC()
+		t.put(elementErrorTable, processElementKey{failure.ProcessID, failure.ElementID}, failure)
+
+		return nil
+	})
+}
+
+func (e *elementErrorRepo) DeleteFailure(ctx context.Context, processID, elementID int) error {
+	return e.conn.run(ctx, func(t *tx) error {
+		t.delete(elementErrorTable, processElementKey{processID, elementID})
+		return nil
+	})
+}
+
+// GetFailuresByElementIDs returns the failure records of the given elements keyed by element id.
+func (e *elementErrorRepo) GetFailuresByElementIDs(ctx context.Context, processID int, elementIDs []int) (map[int]models.ProcessElementError, error) {
+	failuresByElementID := map[int]models.ProcessElementError{}
+	err := e.conn.run(ctx, func(t *tx) error {
+		for _, elementID := range elementIDs {
+			if row, ok := t.get(elementErrorTable, processElementKey{processID, elementID}); ok {
+				failuresByElementID[elementID] = row.(models.ProcessElementError)
+			}
+		}
+
+		return nil
+	})
+
+	return failuresByElementID, err
+}
+
+func (e *elementErrorRepo) GetFailuresByProcessID(ctx context.Context, processID int, deadLetteredOnly bool) ([]models.ProcessElementError, error) {
+	failures := []models.ProcessElementError{}
+	err := e.conn.run(ctx, func(t *tx) error {
+		for _, failure := range elementErrors(t) {
+			if failure.ProcessID == processID && (failure.DeadLettered || !deadLetteredOnly) {
+				failures = append(failures, failure)
+			}
+		}
+
+		return nil
+	})
+
+	return failures, err
+}
+
+func (e *elementErrorRepo) CountDeadLettered(ctx context.Context, processID int) (int, error) {
+	failures, err := e.GetFailuresByProcessID(ctx, processID, true)
+	return len(failures), err
+}
+
+func (e *elementErrorRepo) CountWaiting(ctx context.Context, processID int, now time.Time) (int, error) {
+	failures, err := e.GetFailuresByProcessID(ctx, processID, false)
+
+	waiting := 0
+	for _, failure := range failures {
+		if !failure.DeadLettered && failure.NextAttemptAt != nil && failure.NextAttemptAt.After(now) {
+			waiting++
+		}
+	}
+
+	return waiting, err
+}
+
+// RedriveDeadLettered resets the attempts of a process's dead lettered elements so they're retried
+// immediately, returning the number of elements redriven.
+func (e *elementErrorRepo) RedriveDeadLettered(ctx context.Context, processID int) (int, error) {
+	var redriven int
+	err := e.conn.run(ctx, func(t *tx) error {
+		now := time.Now().UTC()
+
+		for _, failure := range elementErrors(t) {
+			if failure.ProcessID != processID || !failure.DeadLettered {
+				continue
+			}
+
+			failure.Attempts = 0
+			failure.NextAttemptAt = nil
+			failure.DeadLettered = false
+			failure.UpdatedAt = now
+			t.put(elementErrorTable, processElementKey{failure.ProcessID, failure.ElementID}, failure)
+			redriven++
+		}
+
+		return nil
+	})
+
+	return redriven, err
+}
+
+// elementErrors returns the failure records the transaction sees, ordered by process and element id.
+func elementErrors(t *tx) []models.ProcessElementError {
+	rows := t.rows(elementErrorTable)
+
+	all := make([]models.ProcessElementError, 0, len(rows))
+	for _, row := range rows {
+		all = append(all, row.(models.ProcessElementError))
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].ProcessID != all[j].ProcessID {
+			return all[i].ProcessID < all[j].ProcessID
+		}
+		return all[i].ElementID < all[j].ElementID
+	})
+
+	return all
+}
+
+type elementErrorRepoFactory struct{}
+
+func NewElementErrorRepositoryFactory() repository.ElementErrorRepositoryFactory {
+	return &elementErrorRepoFactory{}
+}
+
+func (e *elementErrorRepoFactory) CreateElementErrorRepository(db db.Querier) repository.ElementErrorRepository {
+	return NewElementErrorRepository(db)
+}
@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	"github.com/eggsbenjamin/square_enix/internal/app/models"
+	"github.com/eggsbenjamin/square_enix/internal/app/repository"
+)
+
+type leaseRepo struct {
+	conn conn
+}
+
+func NewLeaseRepository(q db.Querier) repository.LeaseRepository {
+	return &leaseRepo{
+		conn: connOf(q),
+	}
+}
+
+// AcquireLease claims the lease's row so concurrent acquisitions are serialised, as the row's lock serialises
+// them on the databases.
+func (l *leaseRepo) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (models.Lease, bool, error) {
+	var lease models.Lease
+	err := l.conn.run(ctx, func(t *tx) error {
+		t.lockTable(leaseTable)
+
+		now := time.Now().UTC()
+		row, ok := t.get(leaseTable, name)
+		if ok {
+			lease = row.(models.Lease)
+		}
+
+		if !ok || lease.Holder == holder || !now.Before(lease.ExpiresAt) {
+			lease = models.Lease{
+				Name:      name,
+				Holder:    holder,
+				ExpiresAt: now.Add(ttl),
+			}
+			t.put(leaseTable, name, lease)
+		}
+
+		return nil
+	})
+
+	return lease, err == nil && lease.Holder == holder, err
+}
+
+func (l *leaseRepo) ReleaseLease(ctx context.Context, name, holder string) error {
+	return l.conn.run(ctx, func(t *tx) error {
+		row, ok := t.get(leaseTable, name)
+		if !ok || row.(models.Lease).Holder != holder {
+			return nil
+		}
+
+		lease := row.(models.Lease)
+		lease.ExpiresAt = time.Now().UTC()
+		t.put(leaseTable, name, lease)
+
+		return nil
+	})
+}
+
+type leaseRepoFactory struct{}
+
+func NewLeaseRepositoryFactory() repository.LeaseRepositoryFactory {
+	return &leaseRepoFactory{}
+}
+
+func (l *leaseRepoFactory) CreateLeaseRepository(db db.Querier) repository.LeaseRepository {
+	return NewLeaseRepository(db)
+}
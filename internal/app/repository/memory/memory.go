@@ -0,0 +1,485 @@
+// Package memory implements the repositories in memory, so the processor, and services embedding it, can be
+// tested without a database. NewDB returns a db.DB whose transactions see the rows committed before each read
+// along with their own writes, which are applied when they're committed and discarded when they're rolled
+// back. The repositories created by this package's factories read and write the DB, or the transaction, they're
+// created with.
+//
+// Elements locked by a transaction are claimed by it until it ends, and skipped by the other transactions
+// locking elements, as SKIP LOCKED skips them. A process read for update is claimed until the end of the
+// transaction, which other transactions reading it for update wait for. A process reserving its name claims
+// the name until the end of the transaction, as the unique key of the names locks it, and acquiring a lease
+// locks the Lease table. Other writes don't lock rows, the last transaction to commit a row wins. Each
+// transaction is a session of its own, killing it rolls the transaction back.
+//
+// The DB and its transactions don't execute SQL. Their Exec, Get and Select methods return ErrUnsupportedQuery,
+// apart from a transaction's ExecContext, which executes the SAVEPOINT statements the processor uses.
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/db"
+)
+
+// DRIVER_MEMORY is the driver name of the DB and its transactions.
+const DRIVER_MEMORY = "memory"
+
+const (
+	processTable        = "Process"
+	elementTable        = "Element"
+	elementTagTable     = "ElementTag"
+	processElementTable = "ProcessElement"
+	elementErrorTable   = "ProcessElementError"
+	transitionTable     = "ProcessTransition"
+	leaseTable          = "Lease"
+	workerTable         = "Worker"
+	claimTable          = "ElementClaim"
+)
+
+var (
+	ErrUnsupportedQuery = errors.New("unsupported query")
+	ErrNoSavepoint      = errors.New("no such savepoint")
+)
+
+var savepointStatement = regexp.MustCompile(`^(?i)(SAVEPOINT|ROLLBACK TO SAVEPOINT|RELEASE SAVEPOINT)\s+(\w+)$`)
+
+// deleted marks a row deleted by a transaction.
+type deleted struct{}
+
+// table is the rows of a table keyed by primary key.
+type table map[interface{}]interface{}
+
+// tables are tables by name.
+type tables map[string]table
+
+func (t tables) copy() tables {
+	copied := make(tables, len(t))
+	for name, rows := range t {
+		copied[name] = make(table, len(rows))
+		for key, row := range rows {
+			copied[name][key] = row
+		}
+	}
+
+	return copied
+}
+
+type rowKey struct {
+	table string
+	key   interface{}
+}
+
+// store is the committed state of a DB.
+type store struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	tables tables
+	ids    map[string]int
+
+	// claims are the rows claimed by a transaction, lockedTables the tables locked by one
+	claims       map[rowKey]*tx
+	lockedTables map[string]*tx
+
+	// sessions are the transactions that haven't ended by session id, each transaction is a session of its own
+	sessions    map[int64]*tx
+	lastSession int64
+}
+
+type memDB struct {
+	unsupported
+	store *store
+}
+
+// NewDB returns an empty in-memory database.
+func NewDB() db.DB {
+	s := &store{
+		tables:       tables{},
+		ids:          map[string]int{},
+		claims:       map[rowKey]*tx{},
+		lockedTables: map[string]*tx{},
+		sessions:     map[int64]*tx{},
+	}
+	s.cond = sync.NewCond(&s.mu)
+
+	return &memDB{store: s}
+}
+
+func (d *memDB) Beginx() (db.Tx, error) {
+	return d.begin(), nil
+}
+
+// BeginTxx begins a transaction that's rolled back when ctx is done, as a database/sql transaction is. opts
+// are ignored, every transaction is read committed.
+func (d *memDB) BeginTxx(ctx context.Context, opts *sql.TxOptions) (db.Tx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	t := d.begin()
+	if ctx.Done() != nil {
+		go func() {
+			select {
+			case <-ctx.Done():
+				t.Rollback()
+			case <-t.finished:
+			}
+		}()
+	}
+
+	return t, nil
+}
+
+func (d *memDB) begin() *tx {
+	d.store.mu.Lock()
+	defer d.store.mu.Unlock()
+
+	d.store.lastSession++
+	t := &tx{
+		store:    d.store,
+		session:  d.store.lastSession,
+		writes:   tables{},
+		finished: make(chan struct{}),
+	}
+	d.store.sessions[t.session] = t
+
+	return t
+}
+
+// kill rolls back the transaction of a session, if it hasn't ended, as killing a database session does.
+func (s *store) kill(session int64) {
+	s.mu.Lock()
+	t, ok := s.sessions[session]
+	s.mu.Unlock()
+
+	if ok {
+		t.Rollback()
+	}
+}
+
+// run runs fn in a transaction of its own, committed if fn succeeds.
+func (d *memDB) run(ctx context.Context, fn func(t *tx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	t := d.begin()
+	if err := fn(t); err != nil {
+		t.Rollback()
+		return err
+	}
+
+	return t.Commit()
+}
+
+type savepoint struct {
+	name   string
+	writes tables
+}
+
+type tx struct {
+	unsupported
+	store   *store
+	session int64
+
+	// mu serialises the transaction's operations with its rollback when its context is done
+	mu         sync.Mutex
+	writes     tables
+	savepoints []savepoint
+	done       bool
+	finished   chan struct{}
+}
+
+func (t *tx) run(ctx context.Context, fn func(t *tx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.done {
+		return sql.ErrTxDone
+	}
+
+	return fn(t)
+}
+
+func (t *tx) Commit() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.done {
+		return sql.ErrTxDone
+	}
+
+	t.store.mu.Lock()
+	for name, rows := range t.writes {
+		if t.store.tables[name] == nil {
+			t.store.tables[name] = table{}
+		}
+
+		for key, row := range rows {
+			if _, ok := row.(deleted); ok {
+				delete(t.store.tables[name], key)
+			} else {
+				t.store.tables[name][key] = row
+			}
+		}
+	}
+	t.store.mu.Unlock()
+
+	t.end()
+	return nil
+}
+
+func (t *tx) Rollback() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.done {
+		return sql.ErrTxDone
+	}
+
+	t.end()
+	return nil
+}
+
+// end releases the transaction's claims and locks.
+func (t *tx) end() {
+	t.store.mu.Lock()
+	for key, holder := range t.store.claims {
+		if holder == t {
+			delete(t.store.claims, key)
+		}
+	}
+	for name, holder := range t.store.lockedTables {
+		if holder == t {
+			delete(t.store.lockedTables, name)
+		}
+	}
+	delete(t.store.sessions, t.session)
+	t.store.cond.Broadcast()
+	t.store.mu.Unlock()
+
+	t.done = true
+	t.writes = nil
+	close(t.finished)
+}
+
+// ExecContext executes the SAVEPOINT, ROLLBACK TO SAVEPOINT and RELEASE SAVEPOINT statements. Rolling back to a
+// savepoint discards the writes made since, the rows claimed since stay claimed.
+func (t *tx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	match := savepointStatement.FindStringSubmatch(strings.TrimSpace(query))
+	if match == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedQuery, query)
+	}
+
+	err := t.run(ctx, func(t *tx) error {
+		statement, name := strings.ToUpper(match[1]), match[2]
+		if statement == "SAVEPOINT" {
+			t.savepoints = append(t.savepoints, savepoint{name, t.writes.copy()})
+			return nil
+		}
+
+		i := t.savepointIndex(name)
+		if i < 0 {
+			return fmt.Errorf("%w: %s", ErrNoSavepoint, name)
+		}
+
+		if statement == "RELEASE SAVEPOINT" {
+			t.savepoints = t.savepoints[:i]
+		} else {
+			t.writes = t.savepoints[i].writes.copy()
+			t.savepoints = t.savepoints[:i+1]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.RowsAffected(0), nil
+}
+
+// savepointIndex returns the index of the latest savepoint named name, -1 if there isn't one.
+func (t *tx) savepointIndex(name string) int {
+	for i := len(t.savepoints) - 1; i >= 0; i-- {
+		if t.savepoints[i].name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (t *tx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return t.ExecContext(context.Background(), query, args...)
+}
+
+// rows returns the rows of a table the transaction sees, those committed overlaid with its own writes.
+func (t *tx) rows(name string) table {
+	t.store.mu.Lock()
+	rows := make(table, len(t.store.tables[name]))
+	for key, row := range t.store.tables[name] {
+		rows[key] = row
+	}
+	t.store.mu.Unlock()
+
+	for key, row := range t.writes[name] {
+		if _, ok := row.(deleted); ok {
+			delete(rows, key)
+		} else {
+			rows[key] = row
+		}
+	}
+
+	return rows
+}
+
+func (t *tx) get(name string, key interface{}) (interface{}, bool) {
+	if row, ok := t.writes[name][key]; ok {
+		_, isDeleted := row.(deleted)
+		return row, !isDeleted
+	}
+
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	row, ok := t.store.tables[name][key]
+	return row, ok
+}
+
+func (t *tx) put(name string, key, row interface{}) {
+	if t.writes[name] == nil {
+		t.writes[name] = table{}
+	}
+
+	t.writes[name][key] = row
+}
+
+func (t *tx) delete(name string, key interface{}) {
+	t.put(name, key, deleted{})
+}
+
+// nextID assigns the next id of a table. As with an auto increment column, ids aren't reused when the
+// transaction assigning them is rolled back.
+func (t *tx) nextID(name string) int {
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	t.store.ids[name]++
+	return t.store.ids[name]
+}
+
+// claim claims a row for the transaction, reporting false if it's claimed by another.
+func (t *tx) claim(name string, key interface{}) bool {
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	k := rowKey{name, key}
+	if holder, ok := t.store.claims[k]; ok && holder != t {
+		return false
+	}
+
+	t.store.claims[k] = t
+	return true
+}
+
+// lock claims a row for the transaction, waiting for any other transaction claiming it to end, as FOR UPDATE
+// waits for a row's lock.
+func (t *tx) lock(name string, key interface{}) {
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	k := rowKey{name, key}
+	for {
+		holder, ok := t.store.claims[k]
+		if !ok || holder == t {
+			break
+		}
+		t.store.cond.Wait()
+	}
+
+	t.store.claims[k] = t
+}
+
+// lockTable locks a table for the transaction, waiting for any other transaction holding it to end.
+func (t *tx) lockTable(name string) {
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	for {
+		holder, ok := t.store.lockedTables[name]
+		if !ok || holder == t {
+			break
+		}
+		t.store.cond.Wait()
+	}
+
+	t.store.lockedTables[name] = t
+}
+
+// conn is a DB or a transaction, on which the repositories run their operations.
+type conn interface {
+	run(ctx context.Context, fn func(t *tx) error) error
+}
+
+func connOf(q db.Querier) conn {
+	c, ok := q.(conn)
+	if !ok {
+		panic(fmt.Sprintf("memory: repositories must be created with a memory DB or one of its transactions, got %T", q))
+	}
+
+	return c
+}
+
+// unsupported implements the db.Querier methods that execute SQL by returning ErrUnsupportedQuery.
+type unsupported struct{}
+
+func (unsupported) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedQuery, query)
+}
+
+func (unsupported) NamedExec(query string, arg interface{}) (sql.Result, error) {
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedQuery, query)
+}
+
+func (unsupported) Get(dest interface{}, query string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrUnsupportedQuery, query)
+}
+
+func (unsupported) Select(dest interface{}, query string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrUnsupportedQuery, query)
+}
+
+func (unsupported) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedQuery, query)
+}
+
+func (unsupported) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedQuery, query)
+}
+
+func (unsupported) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrUnsupportedQuery, query)
+}
+
+func (unsupported) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrUnsupportedQuery, query)
+}
+
+func (unsupported) DriverName() string {
+	return DRIVER_MEMORY
+}
+
+func (unsupported) Rebind(query string) string {
+	return query
+}
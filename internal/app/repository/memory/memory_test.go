@@ -0,0 +1,250 @@
+// +build unit
+
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/models"
+	"github.com/eggsbenjamin/square_enix/internal/app/repository"
+)
+
+func TestTx(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Commit and Rollback", func(t *testing.T) {
+		db := NewDB()
+		repo := NewElementRepository(db)
+
+		committed, err := db.Beginx()
+		require.NoError(t, err)
+
+		ids, err := NewElementRepository(committed).InsertElements(ctx, []models.Element{{Data: "one"}})
+		require.NoError(t, err)
+		require.Equal(t, []int{1}, ids)
+
+		// uncommitted writes are only seen by the transaction making them
+		_, err = repo.GetElementByID(ctx, 1)
+		require.Equal(t, repository.ErrNoElementExists, err)
+
+		require.NoError(t, committed.Commit())
+		element, err := repo.GetElementByID(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, "one", element.Data)
+
+		rolledBack, err := db.Beginx()
+		require.NoError(t, err)
+
+		ids, err = NewElementRepository(rolledBack).InsertElements(ctx, []models.Element{{Data: "two"}})
+		require.NoError(t, err)
+		require.Equal(t, []int{2}, ids)
+
+		require.NoError(t, rolledBack.Rollback())
+		require.Equal(t, sql.ErrTxDone, rolledBack.Commit())
+
+		_, err = repo.GetElementByID(ctx, 2)
+		require.Equal(t, repository.ErrNoElementExists, err)
+
+		// ids aren't reused
+		ids, err = repo.InsertElements(ctx, []models.Element{{Data: "three"}})
+		require.NoError(t, err)
+		require.Equal(t, []int{3}, ids)
+	})
+
+	t.Run("Savepoints", func(t *testing.T) {
+		db := NewDB()
+
+		tx, err := db.Beginx()
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		repo := NewElementRepository(tx)
+		_, err = repo.InsertElements(ctx, []models.Element{{Data: "one"}})
+		require.NoError(t, err)
+
+		_, err = tx.ExecContext(ctx, "SAVEPOINT process_element")
+		require.NoError(t, err)
+
+		require.NoError(t, repo.UpdateElement(ctx, models.Element{ID: 1, Data: "ONE"}))
+
+		_, err = tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT process_element")
+		require.NoError(t, err)
+
+		element, err := repo.GetElementByID(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, "one", element.Data)
+
+		_, err = tx.ExecContext(ctx, "RELEASE SAVEPOINT process_element")
+		require.NoError(t, err)
+
+		_, err = tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT process_element")
+		require.True(t, errors.Is(err, ErrNoSavepoint))
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM Element")
+		require.True(t, errors.Is(err, ErrUnsupportedQuery))
+	})
+
+	t.Run("Claims", func(t *testing.T) {
+		db := NewDB()
+
+		_, err := NewElementRepository(db).InsertElements(ctx, []models.Element{
+			{Data: "one", CreatedAt: time.Now().Add(-time.Minute)},
+			{Data: "two", CreatedAt: time.Now().Add(-time.Minute)},
+			{Data: "three", CreatedAt: time.Now().Add(-time.Minute)},
+		})
+		require.NoError(t, err)
+
+		process := models.Process{ID: 1, CreatedAt: time.Now()}
+
+		first, err := db.Beginx()
+		require.NoError(t, err)
+
+		locked, err := NewElementRepository(first).LockElementsForUpdate(ctx, process, 2)
+		require.NoError(t, err)
+		require.Len(t, locked, 2)
+
+		second, err := db.Beginx()
+		require.NoError(t, err)
+
+		// the elements claimed by the first transaction are skipped
+		locked, err = NewElementRepository(second).LockElementsForUpdate(ctx, process, 2)
+		require.NoError(t, err)
+		require.Len(t, locked, 1)
+		require.Equal(t, 3, locked[0].ID)
+
+		require.NoError(t, first.Rollback())
+
+		locked, err = NewElementRepository(second).LockElementsForUpdate(ctx, process, 3)
+		require.NoError(t, err)
+		require.Len(t, locked, 3)
+
+		require.NoError(t, second.Rollback())
+	})
+
+	t.Run("Rolled Back When Context Is Done", func(t *testing.T) {
+		db := NewDB()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		tx, err := db.BeginTxx(ctx, nil)
+		require.NoError(t, err)
+
+		_, err = NewElementRepository(tx).InsertElements(ctx, []models.Element{{Data: "one"}})
+		require.NoError(t, err)
+
+		cancel()
+		require.Eventually(t, func() bool {
+			return tx.Commit() == sql.ErrTxDone
+		}, time.Second, time.Millisecond)
+
+		count, err := NewElementRepository(db).CountElementsCreatedBefore(context.Background(), time.Now().Add(time.Minute), models.ElementSelector{})
+		require.NoError(t, err)
+		require.Zero(t, count)
+	})
+
+	t.Run("Process Names", func(t *testing.T) {
+		db := NewDB()
+		repo := NewProcessRepository(db)
+
+		process, err := repo.CreateNewProcess(ctx, models.Process{Name: "test"})
+		require.NoError(t, err)
+		require.Equal(t, models.PROCESS_STATUS_RUNNING, process.Status)
+
+		_, err = repo.CreateNewProcess(ctx, models.Process{Name: "test"})
+		require.Equal(t, repository.ErrProcessNameExists, err)
+
+		process.Status = models.PROCESS_STATUS_CANCELLED
+		require.NoError(t, repo.UpdateProcess(ctx, process))
+
+		_, err = repo.CreateNewProcess(ctx, models.Process{Name: "test"})
+		require.NoError(t, err)
+	})
+
+	t.Run("Transitions Truncated", func(t *testing.T) {
+		repo := NewTransitionRepository(NewDB())
+
+		require.NoError(t, repo.RecordTransition(ctx, models.ProcessTransition{
+			ProcessID: 1,
+			To:        models.PROCESS_STATUS_RUNNING,
+			Actor:     strings.Repeat("é", 101),
+			Reason:    "x" + strings.Repeat("é", 1100),
+		}))
+
+		transitions, err := repo.GetByProcessID(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, transitions, 1)
+		require.True(t, utf8.ValidString(transitions[0].Actor))
+		require.Equal(t, 100, utf8.RuneCountInString(transitions[0].Actor))
+		require.True(t, utf8.ValidString(transitions[0].Reason))
+		require.Equal(t, 1024, utf8.RuneCountInString(transitions[0].Reason))
+	})
+
+	t.Run("Process Versions", func(t *testing.T) {
+		db := NewDB()
+		repo := NewProcessRepository(db)
+
+		process, err := repo.CreateNewProcess(ctx, models.Process{Name: "test"})
+		require.NoError(t, err)
+
+		tx, err := db.BeginTxx(ctx, nil)
+		require.NoError(t, err)
+
+		paused := process
+		paused.Status = models.PROCESS_STATUS_PAUSED
+		require.NoError(t, NewProcessRepository(tx).UpdateProcess(ctx, paused))
+
+		// an update of the same version waits for the first to commit, then finds the process has changed
+		updated := make(chan error)
+		go func() {
+			cancelled := process
+			cancelled.Status = models.PROCESS_STATUS_CANCELLED
+			updated <- repo.UpdateProcess(ctx, cancelled)
+		}()
+
+		require.NoError(t, tx.Commit())
+		require.Equal(t, repository.ErrConcurrentModification, <-updated)
+
+		process, err = repo.GetByID(ctx, process.ID)
+		require.NoError(t, err)
+		require.Equal(t, models.PROCESS_STATUS_PAUSED, process.Status)
+		require.Equal(t, 1, process.Version)
+	})
+}
+
+func TestMatches(t *testing.T) {
+	createdAt := time.Date(2019, 1, 1, 12, 0, 0, 0, time.UTC)
+	element := models.Element{ID: 5, Data: "apple", CreatedAt: createdAt}
+	tags := []string{"fruit", "red"}
+
+	before := createdAt.Add(time.Second)
+	tests := []struct {
+		name     string
+		selector models.ElementSelector
+		matched  bool
+	}{
+		{"Empty", models.ElementSelector{}, true},
+		{"IDs", models.ElementSelector{MinID: 5, MaxID: 5}, true},
+		{"Below Min ID", models.ElementSelector{MinID: 6}, false},
+		{"Created", models.ElementSelector{CreatedAfter: &createdAt, CreatedBefore: &before}, true},
+		{"Created Before", models.ElementSelector{CreatedBefore: &createdAt}, false},
+		{"Prefix", models.ElementSelector{DataPrefix: "app"}, true},
+		{"Regex", models.ElementSelector{DataRegex: "^a.+e$"}, true},
+		{"Tags", models.ElementSelector{Tags: []string{"veg", "red"}}, true},
+		{"Other Tags", models.ElementSelector{Tags: []string{"veg"}}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			matched, err := matches(element, tags, test.selector)
+			require.NoError(t, err)
+			require.Equal(t, test.matched, matched)
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	"github.com/eggsbenjamin/square_enix/internal/app/models"
+	"github.com/eggsbenjamin/square_enix/internal/app/repository"
+)
+
+// activeName is the key of a name claimed by the transaction reserving it for a process.
+type activeName string
+
+type processRepo struct {
+	conn conn
+}
+
+func NewProcessRepository(q db.Querier) repository.ProcessRepository {
+	return &processRepo{
+		conn: connOf(q),
+	}
+}
+
+// CreateNewProcess creates a RUNNING process. Names must be unique amongst RUNNING and PAUSED processes.
+func (p *processRepo) CreateNewProcess(ctx context.Context, newProcess models.Process) (models.Process, error) {
+	var process models.Process
+	err := p.conn.run(ctx, func(t *tx) error {
+		if err := reserveName(t, 0, newProcess.Name); err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		process = models.Process{
+			ID:                t.nextID(processTable),
+			Name:              newProcess.Name,
+			Status:            models.PROCESS_STATUS_RUNNING,
+			Selector:          newProcess.Selector,
+			Transformer:       newProcess.Transformer,
+			TransformerConfig: newProcess.TransformerConfig,
+			CreatedAt:         now,
+			StartedAt:         &now,
+		}
+		t.put(processTable, process.ID, process)
+
+		return nil
+	})
+
+	return process, err
+}
+
+// UpdateProcess locks the process, as the databases lock the row they update, so a transaction updating it
+// waits for any other transaction that has to end before comparing versions.
+func (p *processRepo) UpdateProcess(ctx context.Context, process models.Process) error {
+	return p.conn.run(ctx, func(t *tx) error {
+		t.lock(processTable, process.ID)
+
+		row, ok := t.get(processTable, process.ID)
+		if !ok {
+			return repository.ErrNoProcessExists
+		}
+
+		if row.(models.Process).Version != process.Version {
+			return repository.ErrConcurrentModification
+		}
+
+		if process.Status.ReservesName() {
+			if err := reserveName(t, process.ID, row.(models.Process).Name); err != nil {
+				return err
+			}
+		}
+
+		updated := row.(models.Process)
+		updated.Status = process.Status
+		updated.StartedAt = process.StartedAt
+		updated.PausedAt = process.PausedAt
+		updated.CompletedAt = process.CompletedAt
+		updated.CancelledAt = process.CancelledAt
+		updated.PausedSeconds = process.PausedSeconds
+		updated.Version++
+		t.put(processTable, process.ID, updated)
+
+		return nil
+	})
+}
+
+func (p *processRepo) GetByID(ctx context.Context, id int) (models.Process, error) {
+	return p.getByID(ctx, id, false)
+}
+
+// GetByIDForUpdate claims the process until the end of the transaction, waiting for any other transaction
+// claiming it.
+func (p *processRepo) GetByIDForUpdate(ctx context.Context, id int) (models.Process, error) {
+	return p.getByID(ctx, id, true)
+}
+
+func (p *processRepo) getByID(ctx context.Context, id int, forUpdate bool) (models.Process, error) {
+	var process models.Process
+	err := p.conn.run(ctx, func(t *tx) error {
+		if forUpdate {
+			t.lock(processTable, id)
+		}
+
+		row, ok := t.get(processTable, id)
+		if !ok {
+			return repository.ErrNoProcessExists
+		}
+
+		process = row.(models.Process)
+		return nil
+	})
+
+	return process, err
+}
+
+// GetByStatus returns the processes in any of statuses, ordered by id.
+func (p *processRepo) GetByStatus(ctx context.Context, statuses ...models.ProcessState) ([]models.Process, error) {
+	matched := []models.Process{}
+	err := p.conn.run(ctx, func(t *tx) error {
+		for _, process := range processes(t) {
+			for _, status := range statuses {
+				if process.Status == status {
+					matched = append(matched, process)
+					break
+				}
+			}
+		}
+
+		return nil
+	})
+
+	return matched, err
+}
+
+func (p *processRepo) GetAll(ctx context.Context) ([]models.Process, error) {
+	all := []models.Process{}
+	err := p.conn.run(ctx, func(t *tx) error {
+		all = append(all, processes(t)...)
+		return nil
+	})
+
+	return all, err
+}
+
+// reserveName claims name for the process with id, 0 for a new process, failing if another process reserves
+// it. A transaction reserving a name waits for any other transaction reserving it to end.
+func reserveName(t *tx, id int, name string) error {
+	t.lock(processTable, activeName(name))
+
+	for _, existing := range processes(t) {
+		if existing.ID != id && existing.Name == name && existing.Status.ReservesName() {
+			return repository.ErrProcessNameExists
+		}
+	}
+
+	return nil
+}
+
+// processes returns the processes the transaction sees, ordered by id.
+func processes(t *tx) []models.Process {
+	rows := t.rows(processTable)
+
+	all := make([]models.Process, 0, len(rows))
+	for _, row := range rows {
+		all = append(all, row.(models.Process))
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].ID < all[j].ID
+	})
+
+	return all
+}
+
+type processRepoFactory struct{}
+
+func NewProcessRepositoryFactory() repository.ProcessRepositoryFactory {
+	return &processRepoFactory{}
+}
+
+func (p *processRepoFactory) CreateProcessRepository(db db.Querier) repository.ProcessRepository {
+	return NewProcessRepository(db)
+}
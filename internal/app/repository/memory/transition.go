@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	"github.com/eggsbenjamin/square_enix/internal/app/models"
+	"github.com/eggsbenjamin/square_enix/internal/app/repository"
+)
+
+const (
+	// maxActorLength and maxReasonLength are the lengths of the ProcessTransition actor and reason columns.
+	maxActorLength  = 100
+	maxReasonLength = 1024
+)
+
+type transitionRepo struct {
+	conn conn
+}
+
+func NewTransitionRepository(q db.Querier) repository.TransitionRepository {
+	return &transitionRepo{
+		conn: connOf(q),
+	}
+}
+
+// RecordTransition appends a transition to a process's audit history.
+func (r *transitionRepo) RecordTransition(ctx context.Context, transition models.ProcessTransition) error {
+	transition.Actor = truncate(transition.Actor, maxActorLength)
+	transition.Reason = truncate(transition.Reason, maxReasonLength)
+
+	return r.conn.run(ctx, func(t *tx) error {
+		transition.ID = t.nextID(transitionTable)
+		transition.CreatedAt = time.Now().UTC()
+		t.put(transitionTable, transition.ID, transition)
+
+		return nil
+	})
+}
+
+// GetByProcessID returns a process's audit history, oldest first.
+func (r *transitionRepo) GetByProcessID(ctx context.Context, processID int) ([]models.ProcessTransition, error) {
+	transitions := []models.ProcessTransition{}
+	err := r.conn.run(ctx, func(t *tx) error {
+		for _, row := range t.rows(transitionTable) {
+			if transition := row.(models.ProcessTransition); transition.ProcessID == processID {
+				transitions = append(transitions, transition)
+			}
+		}
+
+		return nil
+	})
+
+	sort.Slice(transitions, func(i, j int) bool {
+		return transitions[i].ID < transitions[j].ID
+	})
+
+	return transitions, err
+}
+
+type transitionRepoFactory struct{}
+
+func NewTransitionRepositoryFactory() repository.TransitionRepositoryFactory {
+	return &transitionRepoFactory{}
+}
+
+func (r *transitionRepoFactory) CreateTransitionRepository(db db.Querier) repository.TransitionRepository {
+	return NewTransitionRepository(db)
+}
@@ -0,0 +1,106 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	"github.com/eggsbenjamin/square_enix/internal/app/models"
+	"github.com/eggsbenjamin/square_enix/internal/app/repository"
+)
+
+type workerRepo struct {
+	conn conn
+}
+
+func NewWorkerRepository(q db.Querier) repository.WorkerRepository {
+	return &workerRepo{
+		conn: connOf(q),
+	}
+}
+
+func (w *workerRepo) CreateWorker(ctx context.Context, worker models.Worker) (int, error) {
+	var id int
+	err := w.conn.run(ctx, func(t *tx) error {
+		id = t.nextID(workerTable)
+		t.put(workerTable, id, models.Worker{
+			ID:          id,
+			Hostname:    worker.Hostname,
+			PID:         worker.PID,
+			Worker:      worker.Worker,
+			Version:     worker.Version,
+			StartedAt:   worker.StartedAt,
+			HeartbeatAt: worker.HeartbeatAt,
+			ExpiresAt:   worker.ExpiresAt,
+		})
+
+		return nil
+	})
+
+	return id, err
+}
+
+func (w *workerRepo) UpdateWorker(ctx context.Context, worker models.Worker) error {
+	return w.conn.run(ctx, func(t *tx) error {
+		row, ok := t.get(workerTable, worker.ID)
+		if !ok {
+			return nil
+		}
+
+		updated := row.(models.Worker)
+		updated.HeartbeatAt = worker.HeartbeatAt
+		updated.ExpiresAt = worker.ExpiresAt
+		updated.StoppedAt = worker.StoppedAt
+		updated.ProcessID = worker.ProcessID
+		updated.BatchSize = worker.BatchSize
+		updated.BatchStartedAt = worker.BatchStartedAt
+		t.put(workerTable, worker.ID, updated)
+
+		return nil
+	})
+}
+
+// GetAll returns the workers ordered by id, without their statuses.
+func (w *workerRepo) GetAll(ctx context.Context) ([]models.Worker, error) {
+	workers := []models.Worker{}
+	err := w.conn.run(ctx, func(t *tx) error {
+		for _, row := range t.rows(workerTable) {
+			workers = append(workers, row.(models.Worker))
+		}
+
+		return nil
+	})
+
+	sort.Slice(workers, func(i, j int) bool {
+		return workers[i].ID < workers[j].ID
+	})
+
+	return workers, err
+}
+
+func (w *workerRepo) DeleteExpiredBefore(ctx context.Context, before time.Time) (int, error) {
+	deleted := 0
+	err := w.conn.run(ctx, func(t *tx) error {
+		for key, row := range t.rows(workerTable) {
+			if row.(models.Worker).ExpiresAt.Before(before) {
+				t.delete(workerTable, key)
+				deleted++
+			}
+		}
+
+		return nil
+	})
+
+	return deleted, err
+}
+
+type workerRepoFactory struct{}
+
+func NewWorkerRepositoryFactory() repository.WorkerRepositoryFactory {
+	return &workerRepoFactory{}
+}
+
+func (w *workerRepoFactory) CreateWorkerRepository(db db.Querier) repository.WorkerRepository {
+	return NewWorkerRepository(db)
+}
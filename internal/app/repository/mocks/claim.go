@@ -0,0 +1,191 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: claim.go
+
+// Package repository is a generated GoMock package.
+package repository
+
+import (
+	context "context"
+	db "github.com/eggsbenjamin/square_enix/internal/app/db"
+	models "github.com/eggsbenjamin/square_enix/internal/app/models"
+	repository "github.com/eggsbenjamin/square_enix/internal/app/repository"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+	time "time"
+)
+
+// MockClaimRepository is a mock of ClaimRepository interface
+type MockClaimRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockClaimRepositoryMockRecorder
+}
+
+// MockClaimRepositoryMockRecorder is the mock recorder for MockClaimRepository
+type MockClaimRepositoryMockRecorder struct {
+	mock *MockClaimRepository
+}
+
+// NewMockClaimRepository creates a new mock instance
+func NewMockClaimRepository(ctrl *gomock.Controller) *MockClaimRepository {
+	mock := &MockClaimRepository{ctrl: ctrl}
+	mock.recorder = &MockClaimRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockClaimRepository) EXPECT() *MockClaimRepositoryMockRecorder {
+	return m.recorder
+}
+
+// SessionID mocks base method
+func (m *MockClaimRepository) SessionID(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SessionID", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SessionID indicates an expected call of SessionID
+func (mr *MockClaimRepositoryMockRecorder) SessionID(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SessionID", reflect.TypeOf((*MockClaimRepository)(nil).SessionID), ctx)
+}
+
+// ClaimElements mocks base method
+func (m *MockClaimRepository) ClaimElements(ctx context.Context, sessionID int64, processID int, elementIDs []int, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimElements", ctx, sessionID, processID, elementIDs, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClaimElements indicates an expected call of ClaimElements
+func (mr *MockClaimRepositoryMockRecorder) ClaimElements(ctx, sessionID, processID, elementIDs, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimElements", reflect.TypeOf((*MockClaimRepository)(nil).ClaimElements), ctx, sessionID, processID, elementIDs, ttl)
+}
+
+// RenewClaims mocks base method
+func (m *MockClaimRepository) RenewClaims(ctx context.Context, sessionID int64, elementIDs []int, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenewClaims", ctx, sessionID, elementIDs, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RenewClaims indicates an expected call of RenewClaims
+func (mr *MockClaimRepositoryMockRecorder) RenewClaims(ctx, sessionID, elementIDs, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenewClaims", reflect.TypeOf((*MockClaimRepository)(nil).RenewClaims), ctx, sessionID, elementIDs, ttl)
+}
+
+// ReleaseClaims mocks base method
+func (m *MockClaimRepository) ReleaseClaims(ctx context.Context, sessionID int64, elementIDs []int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseClaims", ctx, sessionID, elementIDs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseClaims indicates an expected call of ReleaseClaims
+func (mr *MockClaimRepositoryMockRecorder) ReleaseClaims(ctx, sessionID, elementIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseClaims", reflect.TypeOf((*MockClaimRepository)(nil).ReleaseClaims), ctx, sessionID, elementIDs)
+}
+
+// ReleaseExpired mocks base method
+func (m *MockClaimRepository) ReleaseExpired(ctx context.Context, sessionID int64, elementIDs []int, now time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseExpired", ctx, sessionID, elementIDs, now)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReleaseExpired indicates an expected call of ReleaseExpired
+func (mr *MockClaimRepositoryMockRecorder) ReleaseExpired(ctx, sessionID, elementIDs, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseExpired", reflect.TypeOf((*MockClaimRepository)(nil).ReleaseExpired), ctx, sessionID, elementIDs, now)
+}
+
+// GetExpired mocks base method
+func (m *MockClaimRepository) GetExpired(ctx context.Context, now time.Time) ([]models.ElementClaim, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpired", ctx, now)
+	ret0, _ := ret[0].([]models.ElementClaim)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpired indicates an expected call of GetExpired
+func (mr *MockClaimRepositoryMockRecorder) GetExpired(ctx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpired", reflect.TypeOf((*MockClaimRepository)(nil).GetExpired), ctx, now)
+}
+
+// LockElements mocks base method
+func (m *MockClaimRepository) LockElements(ctx context.Context, elementIDs []int) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockElements", ctx, elementIDs)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockElements indicates an expected call of LockElements
+func (mr *MockClaimRepositoryMockRecorder) LockElements(ctx, elementIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockElements", reflect.TypeOf((*MockClaimRepository)(nil).LockElements), ctx, elementIDs)
+}
+
+// KillSession mocks base method
+func (m *MockClaimRepository) KillSession(ctx context.Context, sessionID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KillSession", ctx, sessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// KillSession indicates an expected call of KillSession
+func (mr *MockClaimRepositoryMockRecorder) KillSession(ctx, sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KillSession", reflect.TypeOf((*MockClaimRepository)(nil).KillSession), ctx, sessionID)
+}
+
+// MockClaimRepositoryFactory is a mock of ClaimRepositoryFactory interface
+type MockClaimRepositoryFactory struct {
+	ctrl     *gomock.Controller
+	recorder *MockClaimRepositoryFactoryMockRecorder
+}
+
+// MockClaimRepositoryFactoryMockRecorder is the mock recorder for MockClaimRepositoryFactory
+type MockClaimRepositoryFactoryMockRecorder struct {
+	mock *MockClaimRepositoryFactory
+}
+
+// NewMockClaimRepositoryFactory creates a new mock instance
+func NewMockClaimRepositoryFactory(ctrl *gomock.Controller) *MockClaimRepositoryFactory {
+	mock := &MockClaimRepositoryFactory{ctrl: ctrl}
+	mock.recorder = &MockClaimRepositoryFactoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockClaimRepositoryFactory) EXPECT() *MockClaimRepositoryFactoryMockRecorder {
+	return m.recorder
+}
+
+// CreateClaimRepository mocks base method
+func (m *MockClaimRepositoryFactory) CreateClaimRepository(db db.Querier) repository.ClaimRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateClaimRepository", db)
+	ret0, _ := ret[0].(repository.ClaimRepository)
+	return ret0
+}
+
+// CreateClaimRepository indicates an expected call of CreateClaimRepository
+func (mr *MockClaimRepositoryFactoryMockRecorder) CreateClaimRepository(db interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateClaimRepository", reflect.TypeOf((*MockClaimRepositoryFactory)(nil).CreateClaimRepository), db)
+}
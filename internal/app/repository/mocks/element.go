@@ -5,6 +5,7 @@
 package repository
 
 import (
+	context "context"
 	db "github.com/eggsbenjamin/square_enix/internal/app/db"
 	models "github.com/eggsbenjamin/square_enix/internal/app/models"
 	repository "github.com/eggsbenjamin/square_enix/internal/app/repository"
@@ -36,63 +37,211 @@ func (m *MockElementRepository) EXPECT() *MockElementRepositoryMockRecorder {
 	return m.recorder
 }
 
+// InsertElements mocks base method
+func (m *MockElementRepository) InsertElements(ctx context.Context, elements []models.Element) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertElements", ctx, elements)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertElements indicates an expected call of InsertElements
+func (mr *MockElementRepositoryMockRecorder) InsertElements(ctx, elements interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertElements", reflect.TypeOf((*MockElementRepository)(nil).InsertElements), ctx, elements)
+}
+
+// UpdateElement mocks base method
+func (m *MockElementRepository) UpdateElement(ctx context.Context, element models.Element) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateElement", ctx, element)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateElement indicates an expected call of UpdateElement
+func (mr *MockElementRepositoryMockRecorder) UpdateElement(ctx, element interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateElement", reflect.TypeOf((*MockElementRepository)(nil).UpdateElement), ctx, element)
+}
+
 // UpdateElementForProcess mocks base method
-func (m *MockElementRepository) UpdateElementForProcess(element models.Element, processID int) error {
+func (m *MockElementRepository) UpdateElementForProcess(ctx context.Context, element models.Element, previousData string, processID int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateElementForProcess", element, processID)
+	ret := m.ctrl.Call(m, "UpdateElementForProcess", ctx, element, previousData, processID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateElementForProcess indicates an expected call of UpdateElementForProcess
-func (mr *MockElementRepositoryMockRecorder) UpdateElementForProcess(element, processID interface{}) *gomock.Call {
+func (mr *MockElementRepositoryMockRecorder) UpdateElementForProcess(ctx, element, previousData, processID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateElementForProcess", reflect.TypeOf((*MockElementRepository)(nil).UpdateElementForProcess), element, processID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateElementForProcess", reflect.TypeOf((*MockElementRepository)(nil).UpdateElementForProcess), ctx, element, previousData, processID)
 }
 
 // LockElementsForUpdate mocks base method
-func (m *MockElementRepository) LockElementsForUpdate(processID, batchSize int) ([]models.Element, error) {
+func (m *MockElementRepository) LockElementsForUpdate(ctx context.Context, process models.Process, batchSize int) ([]models.Element, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "LockElementsForUpdate", processID, batchSize)
+	ret := m.ctrl.Call(m, "LockElementsForUpdate", ctx, process, batchSize)
 	ret0, _ := ret[0].([]models.Element)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // LockElementsForUpdate indicates an expected call of LockElementsForUpdate
-func (mr *MockElementRepositoryMockRecorder) LockElementsForUpdate(processID, batchSize interface{}) *gomock.Call {
+func (mr *MockElementRepositoryMockRecorder) LockElementsForUpdate(ctx, process, batchSize interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockElementsForUpdate", reflect.TypeOf((*MockElementRepository)(nil).LockElementsForUpdate), processID, batchSize)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockElementsForUpdate", reflect.TypeOf((*MockElementRepository)(nil).LockElementsForUpdate), ctx, process, batchSize)
 }
 
-// GetElementsByProcessID mocks base method
-func (m *MockElementRepository) GetElementsByProcessID(processID int) ([]models.Element, error) {
+// LockElementsForRevert mocks base method
+func (m *MockElementRepository) LockElementsForRevert(ctx context.Context, processID, batchSize int) ([]models.ProcessElement, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetElementsByProcessID", processID)
-	ret0, _ := ret[0].([]models.Element)
+	ret := m.ctrl.Call(m, "LockElementsForRevert", ctx, processID, batchSize)
+	ret0, _ := ret[0].([]models.ProcessElement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockElementsForRevert indicates an expected call of LockElementsForRevert
+func (mr *MockElementRepositoryMockRecorder) LockElementsForRevert(ctx, processID, batchSize interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockElementsForRevert", reflect.TypeOf((*MockElementRepository)(nil).LockElementsForRevert), ctx, processID, batchSize)
+}
+
+// RevertElementForProcess mocks base method
+func (m *MockElementRepository) RevertElementForProcess(ctx context.Context, processElement models.ProcessElement) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevertElementForProcess", ctx, processElement)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevertElementForProcess indicates an expected call of RevertElementForProcess
+func (mr *MockElementRepositoryMockRecorder) RevertElementForProcess(ctx, processElement interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevertElementForProcess", reflect.TypeOf((*MockElementRepository)(nil).RevertElementForProcess), ctx, processElement)
+}
+
+// GetElementByID mocks base method
+func (m *MockElementRepository) GetElementByID(ctx context.Context, id int) (models.Element, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetElementByID", ctx, id)
+	ret0, _ := ret[0].(models.Element)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetElementByID indicates an expected call of GetElementByID
+func (mr *MockElementRepositoryMockRecorder) GetElementByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetElementByID", reflect.TypeOf((*MockElementRepository)(nil).GetElementByID), ctx, id)
+}
+
+// GetHistoryByElementID mocks base method
+func (m *MockElementRepository) GetHistoryByElementID(ctx context.Context, elementID int) ([]models.ProcessElement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHistoryByElementID", ctx, elementID)
+	ret0, _ := ret[0].([]models.ProcessElement)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetElementsByProcessID indicates an expected call of GetElementsByProcessID
-func (mr *MockElementRepositoryMockRecorder) GetElementsByProcessID(processID interface{}) *gomock.Call {
+// GetHistoryByElementID indicates an expected call of GetHistoryByElementID
+func (mr *MockElementRepositoryMockRecorder) GetHistoryByElementID(ctx, elementID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetElementsByProcessID", reflect.TypeOf((*MockElementRepository)(nil).GetElementsByProcessID), processID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistoryByElementID", reflect.TypeOf((*MockElementRepository)(nil).GetHistoryByElementID), ctx, elementID)
 }
 
-// GetElementsCreatedBefore mocks base method
-func (m *MockElementRepository) GetElementsCreatedBefore(date time.Time) ([]models.Element, error) {
+// ListElements mocks base method
+func (m *MockElementRepository) ListElements(ctx context.Context, afterID, limit int, selector models.ElementSelector) ([]models.Element, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetElementsCreatedBefore", date)
+	ret := m.ctrl.Call(m, "ListElements", ctx, afterID, limit, selector)
 	ret0, _ := ret[0].([]models.Element)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetElementsCreatedBefore indicates an expected call of GetElementsCreatedBefore
-func (mr *MockElementRepositoryMockRecorder) GetElementsCreatedBefore(date interface{}) *gomock.Call {
+// ListElements indicates an expected call of ListElements
+func (mr *MockElementRepositoryMockRecorder) ListElements(ctx, afterID, limit, selector interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListElements", reflect.TypeOf((*MockElementRepository)(nil).ListElements), ctx, afterID, limit, selector)
+}
+
+// GetTagsByElementIDs mocks base method
+func (m *MockElementRepository) GetTagsByElementIDs(ctx context.Context, elementIDs []int) (map[int][]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTagsByElementIDs", ctx, elementIDs)
+	ret0, _ := ret[0].(map[int][]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTagsByElementIDs indicates an expected call of GetTagsByElementIDs
+func (mr *MockElementRepositoryMockRecorder) GetTagsByElementIDs(ctx, elementIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTagsByElementIDs", reflect.TypeOf((*MockElementRepository)(nil).GetTagsByElementIDs), ctx, elementIDs)
+}
+
+// CountElementsByProcessID mocks base method
+func (m *MockElementRepository) CountElementsByProcessID(ctx context.Context, processID int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountElementsByProcessID", ctx, processID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountElementsByProcessID indicates an expected call of CountElementsByProcessID
+func (mr *MockElementRepositoryMockRecorder) CountElementsByProcessID(ctx, processID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountElementsByProcessID", reflect.TypeOf((*MockElementRepository)(nil).CountElementsByProcessID), ctx, processID)
+}
+
+// CountUnrevertedByProcessID mocks base method
+func (m *MockElementRepository) CountUnrevertedByProcessID(ctx context.Context, processID int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUnrevertedByProcessID", ctx, processID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUnrevertedByProcessID indicates an expected call of CountUnrevertedByProcessID
+func (mr *MockElementRepositoryMockRecorder) CountUnrevertedByProcessID(ctx, processID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUnrevertedByProcessID", reflect.TypeOf((*MockElementRepository)(nil).CountUnrevertedByProcessID), ctx, processID)
+}
+
+// CountElementsCreatedBefore mocks base method
+func (m *MockElementRepository) CountElementsCreatedBefore(ctx context.Context, date time.Time, selector models.ElementSelector) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountElementsCreatedBefore", ctx, date, selector)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountElementsCreatedBefore indicates an expected call of CountElementsCreatedBefore
+func (mr *MockElementRepositoryMockRecorder) CountElementsCreatedBefore(ctx, date, selector interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountElementsCreatedBefore", reflect.TypeOf((*MockElementRepository)(nil).CountElementsCreatedBefore), ctx, date, selector)
+}
+
+// GetLastProgressAt mocks base method
+func (m *MockElementRepository) GetLastProgressAt(ctx context.Context, processID int) (*time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLastProgressAt", ctx, processID)
+	ret0, _ := ret[0].(*time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLastProgressAt indicates an expected call of GetLastProgressAt
+func (mr *MockElementRepositoryMockRecorder) GetLastProgressAt(ctx, processID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetElementsCreatedBefore", reflect.TypeOf((*MockElementRepository)(nil).GetElementsCreatedBefore), date)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastProgressAt", reflect.TypeOf((*MockElementRepository)(nil).GetLastProgressAt), ctx, processID)
 }
 
 // MockElementRepositoryFactory is a mock of ElementRepositoryFactory interface
@@ -0,0 +1,178 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: element_error.go
+
+// Package repository is a generated GoMock package.
+package repository
+
+import (
+	context "context"
+	db "github.com/eggsbenjamin/square_enix/internal/app/db"
+	models "github.com/eggsbenjamin/square_enix/internal/app/models"
+	repository "github.com/eggsbenjamin/square_enix/internal/app/repository"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+	time "time"
+)
+
+// MockElementErrorRepository is a mock of ElementErrorRepository interface
+type MockElementErrorRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockElementErrorRepositoryMockRecorder
+}
+
+// MockElementErrorRepositoryMockRecorder is the mock recorder for MockElementErrorRepository
+type MockElementErrorRepositoryMockRecorder struct {
+	mock *MockElementErrorRepository
+}
+
+// NewMockElementErrorRepository creates a new mock instance
+func NewMockElementErrorRepository(ctrl *gomock.Controller) *MockElementErrorRepository {
+	mock := &MockElementErrorRepository{ctrl: ctrl}
+	mock.recorder = &MockElementErrorRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockElementErrorRepository) EXPECT() *MockElementErrorRepositoryMockRecorder {
+	return m.recorder
+}
+
+// SaveFailure mocks base method
+func (m *MockElementErrorRepository) SaveFailure(ctx context.Context, failure models.ProcessElementError) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveFailure", ctx, failure)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveFailure indicates an expected call of SaveFailure
+func (mr *MockElementErrorRepositoryMockRecorder) SaveFailure(ctx, failure interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveFailure", reflect.TypeOf((*MockElementErrorRepository)(nil).SaveFailure), ctx, failure)
+}
+
+// DeleteFailure mocks base method
+func (m *MockElementErrorRepository) DeleteFailure(ctx context.Context, processID, elementID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFailure", ctx, processID, elementID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteFailure indicates an expected call of DeleteFailure
+func (mr *MockElementErrorRepositoryMockRecorder) DeleteFailure(ctx, processID, elementID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFailure", reflect.TypeOf((*MockElementErrorRepository)(nil).DeleteFailure), ctx, processID, elementID)
+}
+
+// GetFailuresByElementIDs mocks base method
+func (m *MockElementErrorRepository) GetFailuresByElementIDs(ctx context.Context, processID int, elementIDs []int) (map[int]models.ProcessElementError, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFailuresByElementIDs", ctx, processID, elementIDs)
+	ret0, _ := ret[0].(map[int]models.ProcessElementError)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFailuresByElementIDs indicates an expected call of GetFailuresByElementIDs
+func (mr *MockElementErrorRepositoryMockRecorder) GetFailuresByElementIDs(ctx, processID, elementIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFailuresByElementIDs", reflect.TypeOf((*MockElementErrorRepository)(nil).GetFailuresByElementIDs), ctx, processID, elementIDs)
+}
+
+// GetFailuresByProcessID mocks base method
+func (m *MockElementErrorRepository) GetFailuresByProcessID(ctx context.Context, processID int, deadLetteredOnly bool) ([]models.ProcessElementError, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFailuresByProcessID", ctx, processID, deadLetteredOnly)
+	ret0, _ := ret[0].([]models.ProcessElementError)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFailuresByProcessID indicates an expected call of GetFailuresByProcessID
+func (mr *MockElementErrorRepositoryMockRecorder) GetFailuresByProcessID(ctx, processID, deadLetteredOnly interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFailuresByProcessID", reflect.TypeOf((*MockElementErrorRepository)(nil).GetFailuresByProcessID), ctx, processID, deadLetteredOnly)
+}
+
+// CountDeadLettered mocks base method
+func (m *MockElementErrorRepository) CountDeadLettered(ctx context.Context, processID int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountDeadLettered", ctx, processID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountDeadLettered indicates an expected call of CountDeadLettered
+func (mr *MockElementErrorRepositoryMockRecorder) CountDeadLettered(ctx, processID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountDeadLettered", reflect.TypeOf((*MockElementErrorRepository)(nil).CountDeadLettered), ctx, processID)
+}
+
+// CountWaiting mocks base method
+func (m *MockElementErrorRepository) CountWaiting(ctx context.Context, processID int, now time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountWaiting", ctx, processID, now)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountWaiting indicates an expected call of CountWaiting
+func (mr *MockElementErrorRepositoryMockRecorder) CountWaiting(ctx, processID, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountWaiting", reflect.TypeOf((*MockElementErrorRepository)(nil).CountWaiting), ctx, processID, now)
+}
+
+// RedriveDeadLettered mocks base method
+func (m *MockElementErrorRepository) RedriveDeadLettered(ctx context.Context, processID int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedriveDeadLettered", ctx, processID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RedriveDeadLettered indicates an expected call of RedriveDeadLettered
+func (mr *MockElementErrorRepositoryMockRecorder) RedriveDeadLettered(ctx, processID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedriveDeadLettered", reflect.TypeOf((*MockElementErrorRepository)(nil).RedriveDeadLettered), ctx, processID)
+}
+
+// MockElementErrorRepositoryFactory is a mock of ElementErrorRepositoryFactory interface
+type MockElementErrorRepositoryFactory struct {
+	ctrl     *gomock.Controller
+	recorder *MockElementErrorRepositoryFactoryMockRecorder
+}
+
+// MockElementErrorRepositoryFactoryMockRecorder is the mock recorder for MockElementErrorRepositoryFactory
+type MockElementErrorRepositoryFactoryMockRecorder struct {
+	mock *MockElementErrorRepositoryFactory
+}
+
+// NewMockElementErrorRepositoryFactory creates a new mock instance
+func NewMockElementErrorRepositoryFactory(ctrl *gomock.Controller) *MockElementErrorRepositoryFactory {
+	mock := &MockElementErrorRepositoryFactory{ctrl: ctrl}
+	mock.recorder = &MockElementErrorRepositoryFactoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockElementErrorRepositoryFactory) EXPECT() *MockElementErrorRepositoryFactoryMockRecorder {
+	return m.recorder
+}
+
+// CreateElementErrorRepository mocks base method
+func (m *MockElementErrorRepositoryFactory) CreateElementErrorRepository(db db.Querier) repository.ElementErrorRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateElementErrorRepository", db)
+	ret0, _ := ret[0].(repository.ElementErrorRepository)
+	return ret0
+}
+
+// CreateElementErrorRepository indicates an expected call of CreateElementErrorRepository
+func (mr *MockElementErrorRepositoryFactoryMockRecorder) CreateElementErrorRepository(db interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateElementErrorRepository", reflect.TypeOf((*MockElementErrorRepositoryFactory)(nil).CreateElementErrorRepository), db)
+}
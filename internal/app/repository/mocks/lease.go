@@ -0,0 +1,105 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: lease.go
+
+// Package repository is a generated GoMock package.
+package repository
+
+import (
+	context "context"
+	db "github.com/eggsbenjamin/square_enix/internal/app/db"
+	models "github.com/eggsbenjamin/square_enix/internal/app/models"
+	repository "github.com/eggsbenjamin/square_enix/internal/app/repository"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+	time "time"
+)
+
+// MockLeaseRepository is a mock of LeaseRepository interface
+type MockLeaseRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockLeaseRepositoryMockRecorder
+}
+
+// MockLeaseRepositoryMockRecorder is the mock recorder for MockLeaseRepository
+type MockLeaseRepositoryMockRecorder struct {
+	mock *MockLeaseRepository
+}
+
+// NewMockLeaseRepository creates a new mock instance
+func NewMockLeaseRepository(ctrl *gomock.Controller) *MockLeaseRepository {
+	mock := &MockLeaseRepository{ctrl: ctrl}
+	mock.recorder = &MockLeaseRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockLeaseRepository) EXPECT() *MockLeaseRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AcquireLease mocks base method
+func (m *MockLeaseRepository) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (models.Lease, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcquireLease", ctx, name, holder, ttl)
+	ret0, _ := ret[0].(models.Lease)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AcquireLease indicates an expected call of AcquireLease
+func (mr *MockLeaseRepositoryMockRecorder) AcquireLease(ctx, name, holder, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcquireLease", reflect.TypeOf((*MockLeaseRepository)(nil).AcquireLease), ctx, name, holder, ttl)
+}
+
+// ReleaseLease mocks base method
+func (m *MockLeaseRepository) ReleaseLease(ctx context.Context, name, holder string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseLease", ctx, name, holder)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseLease indicates an expected call of ReleaseLease
+func (mr *MockLeaseRepositoryMockRecorder) ReleaseLease(ctx, name, holder interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseLease", reflect.TypeOf((*MockLeaseRepository)(nil).ReleaseLease), ctx, name, holder)
+}
+
+// MockLeaseRepositoryFactory is a mock of LeaseRepositoryFactory interface
+type MockLeaseRepositoryFactory struct {
+	ctrl     *gomock.Controller
+	recorder *MockLeaseRepositoryFactoryMockRecorder
+}
+
+// MockLeaseRepositoryFactoryMockRecorder is the mock recorder for MockLeaseRepositoryFactory
+type MockLeaseRepositoryFactoryMockRecorder struct {
+	mock *MockLeaseRepositoryFactory
+}
+
+// NewMockLeaseRepositoryFactory creates a new mock instance
+func NewMockLeaseRepositoryFactory(ctrl *gomock.Controller) *MockLeaseRepositoryFactory {
+	mock := &MockLeaseRepositoryFactory{ctrl: ctrl}
+	mock.recorder = &MockLeaseRepositoryFactoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockLeaseRepositoryFactory) EXPECT() *MockLeaseRepositoryFactoryMockRecorder {
+	return m.recorder
+}
+
+// CreateLeaseRepository mocks base method
+func (m *MockLeaseRepositoryFactory) CreateLeaseRepository(db db.Querier) repository.LeaseRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateLeaseRepository", db)
+	ret0, _ := ret[0].(repository.LeaseRepository)
+	return ret0
+}
+
+// CreateLeaseRepository indicates an expected call of CreateLeaseRepository
+func (mr *MockLeaseRepositoryFactoryMockRecorder) CreateLeaseRepository(db interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLeaseRepository", reflect.TypeOf((*MockLeaseRepositoryFactory)(nil).CreateLeaseRepository), db)
+}
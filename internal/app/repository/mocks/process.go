@@ -5,6 +5,7 @@
 package repository
 
 import (
+	context "context"
 	db "github.com/eggsbenjamin/square_enix/internal/app/db"
 	models "github.com/eggsbenjamin/square_enix/internal/app/models"
 	repository "github.com/eggsbenjamin/square_enix/internal/app/repository"
@@ -36,62 +37,97 @@ func (m *MockProcessRepository) EXPECT() *MockProcessRepositoryMockRecorder {
 }
 
 // CreateNewProcess mocks base method
-func (m *MockProcessRepository) CreateNewProcess() (models.Process, error) {
+func (m *MockProcessRepository) CreateNewProcess(ctx context.Context, process models.Process) (models.Process, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateNewProcess")
+	ret := m.ctrl.Call(m, "CreateNewProcess", ctx, process)
 	ret0, _ := ret[0].(models.Process)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateNewProcess indicates an expected call of CreateNewProcess
-func (mr *MockProcessRepositoryMockRecorder) CreateNewProcess() *gomock.Call {
+func (mr *MockProcessRepositoryMockRecorder) CreateNewProcess(ctx, process interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewProcess", reflect.TypeOf((*MockProcessRepository)(nil).CreateNewProcess))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewProcess", reflect.TypeOf((*MockProcessRepository)(nil).CreateNewProcess), ctx, process)
 }
 
 // UpdateProcess mocks base method
-func (m *MockProcessRepository) UpdateProcess(arg0 models.Process) error {
+func (m *MockProcessRepository) UpdateProcess(ctx context.Context, process models.Process) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateProcess", arg0)
+	ret := m.ctrl.Call(m, "UpdateProcess", ctx, process)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateProcess indicates an expected call of UpdateProcess
-func (mr *MockProcessRepositoryMockRecorder) UpdateProcess(arg0 interface{}) *gomock.Call {
+func (mr *MockProcessRepositoryMockRecorder) UpdateProcess(ctx, process interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProcess", reflect.TypeOf((*MockProcessRepository)(nil).UpdateProcess), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProcess", reflect.TypeOf((*MockProcessRepository)(nil).UpdateProcess), ctx, process)
+}
+
+// GetByID mocks base method
+func (m *MockProcessRepository) GetByID(ctx context.Context, id int) (models.Process, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(models.Process)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID
+func (mr *MockProcessRepositoryMockRecorder) GetByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockProcessRepository)(nil).GetByID), ctx, id)
+}
+
+// GetByIDForUpdate mocks base method
+func (m *MockProcessRepository) GetByIDForUpdate(ctx context.Context, id int) (models.Process, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDForUpdate", ctx, id)
+	ret0, _ := ret[0].(models.Process)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDForUpdate indicates an expected call of GetByIDForUpdate
+func (mr *MockProcessRepositoryMockRecorder) GetByIDForUpdate(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDForUpdate", reflect.TypeOf((*MockProcessRepository)(nil).GetByIDForUpdate), ctx, id)
 }
 
 // GetByStatus mocks base method
-func (m *MockProcessRepository) GetByStatus(status string) ([]models.Process, error) {
+func (m *MockProcessRepository) GetByStatus(ctx context.Context, statuses ...models.ProcessState) ([]models.Process, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByStatus", status)
+	varargs := []interface{}{ctx}
+	for _, a := range statuses {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetByStatus", varargs...)
 	ret0, _ := ret[0].([]models.Process)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetByStatus indicates an expected call of GetByStatus
-func (mr *MockProcessRepositoryMockRecorder) GetByStatus(status interface{}) *gomock.Call {
+func (mr *MockProcessRepositoryMockRecorder) GetByStatus(ctx interface{}, statuses ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByStatus", reflect.TypeOf((*MockProcessRepository)(nil).GetByStatus), status)
+	varargs := append([]interface{}{ctx}, statuses...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByStatus", reflect.TypeOf((*MockProcessRepository)(nil).GetByStatus), varargs...)
 }
 
-// GetLatestProcess mocks base method
-func (m *MockProcessRepository) GetLatestProcess() (models.Process, error) {
+// GetAll mocks base method
+func (m *MockProcessRepository) GetAll(ctx context.Context) ([]models.Process, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetLatestProcess")
-	ret0, _ := ret[0].(models.Process)
+	ret := m.ctrl.Call(m, "GetAll", ctx)
+	ret0, _ := ret[0].([]models.Process)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetLatestProcess indicates an expected call of GetLatestProcess
-func (mr *MockProcessRepositoryMockRecorder) GetLatestProcess() *gomock.Call {
+// GetAll indicates an expected call of GetAll
+func (mr *MockProcessRepositoryMockRecorder) GetAll(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestProcess", reflect.TypeOf((*MockProcessRepository)(nil).GetLatestProcess))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockProcessRepository)(nil).GetAll), ctx)
 }
 
 // MockProcessRepositoryFactory is a mock of ProcessRepositoryFactory interface
@@ -0,0 +1,103 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: transition.go
+
+// Package repository is a generated GoMock package.
+package repository
+
+import (
+	context "context"
+	db "github.com/eggsbenjamin/square_enix/internal/app/db"
+	models "github.com/eggsbenjamin/square_enix/internal/app/models"
+	repository "github.com/eggsbenjamin/square_enix/internal/app/repository"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// MockTransitionRepository is a mock of TransitionRepository interface
+type MockTransitionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransitionRepositoryMockRecorder
+}
+
+// MockTransitionRepositoryMockRecorder is the mock recorder for MockTransitionRepository
+type MockTransitionRepositoryMockRecorder struct {
+	mock *MockTransitionRepository
+}
+
+// NewMockTransitionRepository creates a new mock instance
+func NewMockTransitionRepository(ctrl *gomock.Controller) *MockTransitionRepository {
+	mock := &MockTransitionRepository{ctrl: ctrl}
+	mock.recorder = &MockTransitionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockTransitionRepository) EXPECT() *MockTransitionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// RecordTransition mocks base method
+func (m *MockTransitionRepository) RecordTransition(ctx context.Context, transition models.ProcessTransition) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordTransition", ctx, transition)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordTransition indicates an expected call of RecordTransition
+func (mr *MockTransitionRepositoryMockRecorder) RecordTransition(ctx, transition interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordTransition", reflect.TypeOf((*MockTransitionRepository)(nil).RecordTransition), ctx, transition)
+}
+
+// GetByProcessID mocks base method
+func (m *MockTransitionRepository) GetByProcessID(ctx context.Context, processID int) ([]models.ProcessTransition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByProcessID", ctx, processID)
+	ret0, _ := ret[0].([]models.ProcessTransition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByProcessID indicates an expected call of GetByProcessID
+func (mr *MockTransitionRepositoryMockRecorder) GetByProcessID(ctx, processID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByProcessID", reflect.TypeOf((*MockTransitionRepository)(nil).GetByProcessID), ctx, processID)
+}
+
+// MockTransitionRepositoryFactory is a mock of TransitionRepositoryFactory interface
+type MockTransitionRepositoryFactory struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransitionRepositoryFactoryMockRecorder
+}
+
+// MockTransitionRepositoryFactoryMockRecorder is the mock recorder for MockTransitionRepositoryFactory
+type MockTransitionRepositoryFactoryMockRecorder struct {
+	mock *MockTransitionRepositoryFactory
+}
+
+// NewMockTransitionRepositoryFactory creates a new mock instance
+func NewMockTransitionRepositoryFactory(ctrl *gomock.Controller) *MockTransitionRepositoryFactory {
+	mock := &MockTransitionRepositoryFactory{ctrl: ctrl}
+	mock.recorder = &MockTransitionRepositoryFactoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockTransitionRepositoryFactory) EXPECT() *MockTransitionRepositoryFactoryMockRecorder {
+	return m.recorder
+}
+
+// CreateTransitionRepository mocks base method
+func (m *MockTransitionRepositoryFactory) CreateTransitionRepository(db db.Querier) repository.TransitionRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTransitionRepository", db)
+	ret0, _ := ret[0].(repository.TransitionRepository)
+	return ret0
+}
+
+// CreateTransitionRepository indicates an expected call of CreateTransitionRepository
+func (mr *MockTransitionRepositoryFactoryMockRecorder) CreateTransitionRepository(db interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransitionRepository", reflect.TypeOf((*MockTransitionRepositoryFactory)(nil).CreateTransitionRepository), db)
+}
@@ -0,0 +1,134 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: worker.go
+
+// Package repository is a generated GoMock package.
+package repository
+
+import (
+	context "context"
+	db "github.com/eggsbenjamin/square_enix/internal/app/db"
+	models "github.com/eggsbenjamin/square_enix/internal/app/models"
+	repository "github.com/eggsbenjamin/square_enix/internal/app/repository"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+	time "time"
+)
+
+// MockWorkerRepository is a mock of WorkerRepository interface
+type MockWorkerRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockWorkerRepositoryMockRecorder
+}
+
+// MockWorkerRepositoryMockRecorder is the mock recorder for MockWorkerRepository
+type MockWorkerRepositoryMockRecorder struct {
+	mock *MockWorkerRepository
+}
+
+// NewMockWorkerRepository creates a new mock instance
+func NewMockWorkerRepository(ctrl *gomock.Controller) *MockWorkerRepository {
+	mock := &MockWorkerRepository{ctrl: ctrl}
+	mock.recorder = &MockWorkerRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockWorkerRepository) EXPECT() *MockWorkerRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateWorker mocks base method
+func (m *MockWorkerRepository) CreateWorker(ctx context.Context, worker models.Worker) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWorker", ctx, worker)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWorker indicates an expected call of CreateWorker
+func (mr *MockWorkerRepositoryMockRecorder) CreateWorker(ctx, worker interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWorker", reflect.TypeOf((*MockWorkerRepository)(nil).CreateWorker), ctx, worker)
+}
+
+// UpdateWorker mocks base method
+func (m *MockWorkerRepository) UpdateWorker(ctx context.Context, worker models.Worker) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWorker", ctx, worker)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWorker indicates an expected call of UpdateWorker
+func (mr *MockWorkerRepositoryMockRecorder) UpdateWorker(ctx, worker interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWorker", reflect.TypeOf((*MockWorkerRepository)(nil).UpdateWorker), ctx, worker)
+}
+
+// GetAll mocks base method
+func (m *MockWorkerRepository) GetAll(ctx context.Context) ([]models.Worker, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", ctx)
+	ret0, _ := ret[0].([]models.Worker)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll
+func (mr *MockWorkerRepositoryMockRecorder) GetAll(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockWorkerRepository)(nil).GetAll), ctx)
+}
+
+// DeleteExpiredBefore mocks base method
+func (m *MockWorkerRepository) DeleteExpiredBefore(ctx context.Context, before time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredBefore", ctx, before)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredBefore indicates an expected call of DeleteExpiredBefore
+func (mr *MockWorkerRepositoryMockRecorder) DeleteExpiredBefore(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredBefore", reflect.TypeOf((*MockWorkerRepository)(nil).DeleteExpiredBefore), ctx, before)
+}
+
+// MockWorkerRepositoryFactory is a mock of WorkerRepositoryFactory interface
+type MockWorkerRepositoryFactory struct {
+	ctrl     *gomock.Controller
+	recorder *MockWorkerRepositoryFactoryMockRecorder
+}
+
+// MockWorkerRepositoryFactoryMockRecorder is the mock recorder for MockWorkerRepositoryFactory
+type MockWorkerRepositoryFactoryMockRecorder struct {
+	mock *MockWorkerRepositoryFactory
+}
+
+// NewMockWorkerRepositoryFactory creates a new mock instance
+func NewMockWorkerRepositoryFactory(ctrl *gomock.Controller) *MockWorkerRepositoryFactory {
+	mock := &MockWorkerRepositoryFactory{ctrl: ctrl}
+	mock.recorder = &MockWorkerRepositoryFactoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockWorkerRepositoryFactory) EXPECT() *MockWorkerRepositoryFactoryMockRecorder {
+	return m.recorder
+}
+
+// CreateWorkerRepository mocks base method
+func (m *MockWorkerRepositoryFactory) CreateWorkerRepository(db db.Querier) repository.WorkerRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWorkerRepository", db)
+	ret0, _ := ret[0].(repository.WorkerRepository)
+	return ret0
+}
+
+// CreateWorkerRepository indicates an expected call of CreateWorkerRepository
+func (mr *MockWorkerRepositoryFactoryMockRecorder) CreateWorkerRepository(db interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWorkerRepository", reflect.TypeOf((*MockWorkerRepositoryFactory)(nil).CreateWorkerRepository), db)
+}
@@ -3,98 +3,168 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
 	"errors"
-	"log"
+	"strings"
 
 	"github.com/eggsbenjamin/square_enix/internal/app/db"
 	"github.com/eggsbenjamin/square_enix/internal/app/models"
 )
 
 var (
-	ErrNoProcessExists      = errors.New("no process exists")
-	ErrRunningProcessExists = errors.New("running process exists")
+	ErrNoProcessExists   = errors.New("no process exists")
+	ErrProcessNameExists = errors.New("active process with name exists")
+
+	// ErrConcurrentModification is returned by UpdateProcess when the process has been updated since it was read.
+	ErrConcurrentModification = errors.New("process modified concurrently")
 )
 
+// processColumns are the columns of the Process table read into a models.Process, which doesn't have MySQL's
+// active_name column. active_name is generated from the name of a RUNNING or PAUSED process for its unique key,
+// the other databases have a unique index of the names of those processes instead.
+const processColumns = `
+	id, name, status, selector, transformer, transformer_config, created_at, started_at, paused_at, completed_at,
+	cancelled_at, paused_seconds, version
+`
+
 type ProcessRepository interface {
-	CreateNewProcess() (models.Process, error)
-	UpdateProcess(models.Process) error
-	GetByStatus(status string) ([]models.Process, error)
-	GetLatestProcess() (models.Process, error)
+	CreateNewProcess(ctx context.Context, process models.Process) (models.Process, error)
+	UpdateProcess(ctx context.Context, process models.Process) error
+	GetByID(ctx context.Context, id int) (models.Process, error)
+	GetByIDForUpdate(ctx context.Context, id int) (models.Process, error)
+	GetByStatus(ctx context.Context, statuses ...models.ProcessState) ([]models.Process, error)
+	GetAll(ctx context.Context) ([]models.Process, error)
 }
 
 type processRepo struct {
-	db db.Querier
+	db      db.Querier
+	dialect db.Dialect
 }
 
-func NewProcessRepository(db db.Querier) ProcessRepository {
+func NewProcessRepository(q db.Querier) ProcessRepository {
 	return &processRepo{
-		db: db,
+		db:      db.Rebound(q),
+		dialect: db.DialectOf(q),
 	}
 }
 
-func (p *processRepo) CreateNewProcess() (models.Process, error) {
-	defer func() {
-		if _, err := p.db.Exec("UNLOCK TABLES"); err != nil {
-			log.Fatalf("error unlocking Process table: %q", err)
+// CreateNewProcess creates a RUNNING process. Names must be unique amongst RUNNING and PAUSED processes, which
+// a unique key enforces: creating a process whose name is reserved by an uncommitted one waits for its
+// transaction to end, and fails if it commits.
+func (p *processRepo) CreateNewProcess(ctx context.Context, newProcess models.Process) (models.Process, error) {
+	ids, err := p.dialect.InsertReturningIDs(
+		ctx,
+		p.db,
+		`
+			INSERT INTO Process (name, status, selector, transformer, transformer_config, started_at)
+			VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		`,
+		newProcess.Name,
+		models.PROCESS_STATUS_RUNNING,
+		newProcess.Selector,
+		newProcess.Transformer,
+		newProcess.TransformerConfig,
+	)
+	if err != nil {
+		if p.dialect.IsUniqueViolation(err) {
+			return models.Process{}, ErrProcessNameExists
 		}
-	}()
-
-	var process models.Process
-	if _, err := p.db.Exec("LOCK TABLES Process WRITE"); err != nil {
-		return process, err
+		return models.Process{}, err
 	}
 
-	runningProcesses, err := p.GetByStatus(models.PROCESS_STATUS_RUNNING)
+	return p.GetByID(ctx, ids[0])
+}
+
+// UpdateProcess writes a process's status and timestamps and increments its version, provided it's still at
+// the version it was read at, otherwise it fails with ErrConcurrentModification. A process that's set back to
+// RUNNING or PAUSED, e.g. by a redrive, reserves its name again, which fails with ErrProcessNameExists if another
+// process has since reserved it.
+func (p *processRepo) UpdateProcess(ctx context.Context, process models.Process) error {
+	res, err := p.db.ExecContext(
+		ctx,
+		`
+			UPDATE Process
+			SET status = ?, started_at = ?, paused_at = ?, completed_at = ?, cancelled_at = ?, paused_seconds = ?,
+				version = version + 1
+			WHERE id = ? AND version = ?
+		`,
+		process.Status,
+		process.StartedAt,
+		process.PausedAt,
+		process.CompletedAt,
+		process.CancelledAt,
+		process.PausedSeconds,
+		process.ID,
+		process.Version,
+	)
 	if err != nil {
-		return process, err
+		if p.dialect.IsUniqueViolation(err) {
+			return ErrProcessNameExists
+		}
+		return err
 	}
 
-	if len(runningProcesses) > 0 {
-		return process, ErrRunningProcessExists
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return err
 	}
 
-	if _, err := p.db.Exec("INSERT INTO Process (status) VALUES (?)", models.PROCESS_STATUS_RUNNING); err != nil {
-		return process, err
+	if updated == 0 {
+		if _, err := p.GetByID(ctx, process.ID); err != nil {
+			return err
+		}
+		return ErrConcurrentModification
 	}
 
-	return process, p.db.Get(&process, "SELECT * FROM Process WHERE status = ?", models.PROCESS_STATUS_RUNNING)
+	return nil
 }
 
-func (p *processRepo) UpdateProcess(process models.Process) error {
-	defer func() {
-		if _, err := p.db.Exec("UNLOCK TABLES"); err != nil {
-			log.Fatalf("error unlocking Process table: %q", err)
-		}
-	}()
-
-	if _, err := p.db.Exec("LOCK TABLES Process WRITE"); err != nil {
-		return err
-	}
-
-	_, err := p.db.Exec(
-		"UPDATE Process SET status = ? WHERE id = ?",
-		process.Status,
-		process.ID,
-	)
-	return err
+func (p *processRepo) GetByID(ctx context.Context, id int) (models.Process, error) {
+	return p.getByID(ctx, id, "")
 }
 
-func (p *processRepo) GetByStatus(status string) ([]models.Process, error) {
-	processes := []models.Process{}
-	return processes, p.db.Select(&processes, `SELECT * FROM Process WHERE status = ?`, status)
+// GetByIDForUpdate locks the process's row until the end of the repository's transaction, waiting for any other
+// transaction holding it. Reads of the process aren't blocked.
+func (p *processRepo) GetByIDForUpdate(ctx context.Context, id int) (models.Process, error) {
+	return p.getByID(ctx, id, p.dialect.ForUpdate())
 }
 
-func (p *processRepo) GetLatestProcess() (models.Process, error) {
+func (p *processRepo) getByID(ctx context.Context, id int, lock string) (models.Process, error) {
 	process := models.Process{}
-	if err := p.db.Get(&process, `SELECT * FROM Process ORDER BY created_at DESC LIMIT 1`); err != nil {
+	if err := p.db.GetContext(ctx, &process, `SELECT `+processColumns+` FROM Process WHERE id = ? `+lock, id); err != nil {
+		if err == sql.ErrNoRows {
+			return process, ErrNoProcessExists
+		}
 		return process, err
 	}
 
-	if process.ID == 0 {
-		return process, ErrNoProcessExists
+	return process, nil
+}
+
+// GetByStatus returns the processes in any of statuses, ordered by id.
+func (p *processRepo) GetByStatus(ctx context.Context, statuses ...models.ProcessState) ([]models.Process, error) {
+	processes := []models.Process{}
+	if len(statuses) == 0 {
+		return processes, nil
 	}
 
-	return process, nil
+	args := make([]interface{}, 0, len(statuses))
+	for _, status := range statuses {
+		args = append(args, status)
+	}
+
+	return processes, p.db.SelectContext(
+		ctx,
+		&processes,
+		`SELECT `+processColumns+` FROM Process WHERE status IN (`+strings.TrimSuffix(strings.Repeat("?, ", len(statuses)), ", ")+`) ORDER BY id`,
+		args...,
+	)
+}
+
+func (p *processRepo) GetAll(ctx context.Context) ([]models.Process, error) {
+	processes := []models.Process{}
+	return processes, p.db.SelectContext(ctx, &processes, `SELECT `+processColumns+` FROM Process ORDER BY id`)
 }
 
 type ProcessRepositoryFactory interface {
@@ -3,68 +3,69 @@
 package repository_test
 
 import (
-	"fmt"
+	"context"
 	"testing"
 
 	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	"github.com/eggsbenjamin/square_enix/internal/app/db/dbtest"
 	"github.com/eggsbenjamin/square_enix/internal/app/models"
 	"github.com/eggsbenjamin/square_enix/internal/app/repository"
-	"github.com/eggsbenjamin/square_enix/pkg/env"
-	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/require"
 )
 
 func TestProcessRepository(t *testing.T) {
-	dsn := fmt.Sprintf(
-		"%s@tcp(%s:3306)/%s?parseTime=true",
-		env.MustGetEnv("MYSQL_USER"),
-		env.MustGetEnv("MYSQL_HOST"),
-		env.MustGetEnv("MYSQL_DB"),
-	)
-	conn, err := sqlx.Connect("mysql", dsn)
+	conn, err := dbtest.Connect()
 	require.NoError(t, err)
 
-	db := db.NewQuerier(conn)
+	db := db.NewQuerier(conn.DB)
 
 	t.Run("GetByStatus", func(t *testing.T) {
 		defer func() {
-			_, err := conn.Exec("DELETE FROM ProcessElement")
+			_, err := conn.Exec("DELETE FROM ProcessElementError")
+			_, err = conn.Exec("DELETE FROM ProcessElement")
 			_, err = conn.Exec("DELETE FROM Process")
 			if err != nil {
 				t.Logf("error resetting Process table: %q\n", err)
 			}
 		}()
 
-		_, err := conn.Exec("DELETE FROM ProcessElement")
+		_, err := conn.Exec("DELETE FROM ProcessElementError")
+		require.NoError(t, err)
+
+		_, err = conn.Exec("DELETE FROM ProcessElement")
 		require.NoError(t, err)
 
 		_, err = conn.Exec("DELETE FROM Process")
 		require.NoError(t, err)
 
-		_, err = conn.Exec("INSERT INTO Process (status) VALUES ('COMPLETE')")
+		_, err = conn.Exec("INSERT INTO Process (name, status) VALUES ('test', 'COMPLETE')")
 		require.NoError(t, err)
 
-		_, err = conn.Exec("INSERT INTO Process (status) VALUES ('RUNNING')")
+		_, err = conn.Exec("INSERT INTO Process (name, status) VALUES ('test', 'RUNNING')")
 		require.NoError(t, err)
 
 		repo := repository.NewProcessRepository(db)
 
-		processes, err := repo.GetByStatus("RUNNING")
+		processes, err := repo.GetByStatus(context.Background(), "RUNNING")
 		require.NoError(t, err)
 		require.Equal(t, 1, len(processes))
-		require.Equal(t, "RUNNING", processes[0].Status)
+		require.Equal(t, models.PROCESS_STATUS_RUNNING, processes[0].Status)
 	})
 
 	t.Run("CreateNewProcess", func(t *testing.T) {
 		defer func() {
-			_, err := conn.Exec("DELETE FROM ProcessElement")
+			_, err := conn.Exec("DELETE FROM ProcessElementError")
+			_, err = conn.Exec("DELETE FROM ProcessElement")
 			_, err = conn.Exec("DELETE FROM Process")
 			if err != nil {
 				t.Logf("error resetting Process table: %q\n", err)
 			}
 		}()
 
-		_, err := conn.Exec("DELETE FROM ProcessElement")
+		_, err := conn.Exec("DELETE FROM ProcessElementError")
+		require.NoError(t, err)
+
+		_, err = conn.Exec("DELETE FROM ProcessElement")
 		require.NoError(t, err)
 
 		_, err = conn.Exec("DELETE FROM Process")
@@ -72,47 +73,85 @@ func TestProcessRepository(t *testing.T) {
 
 		repo := repository.NewProcessRepository(db)
 
-		process, err := repo.CreateNewProcess()
+		process, err := repo.CreateNewProcess(context.Background(), models.Process{
+			Name:        "test",
+			Selector:    models.ElementSelector{MinID: 1, MaxID: 10},
+			Transformer: "lower",
+		})
 		require.NoError(t, err)
 
 		require.NotZero(t, process.ID)
 		require.NotZero(t, process.CreatedAt)
+		require.Equal(t, "test", process.Name)
 		require.Equal(t, models.PROCESS_STATUS_RUNNING, process.Status)
+		require.Equal(t, models.ElementSelector{MinID: 1, MaxID: 10}, process.Selector)
+		require.Equal(t, "lower", process.Transformer)
+
+		_, err = repo.CreateNewProcess(context.Background(), models.Process{Name: "test"})
+		require.Equal(t, repository.ErrProcessNameExists, err)
+
+		other, err := repo.CreateNewProcess(context.Background(), models.Process{Name: "other"})
+		require.NoError(t, err)
+		require.NotEqual(t, process.ID, other.ID)
+
+		// a completed process frees its name
+		process.Status = models.PROCESS_STATUS_COMPLETE
+		require.NoError(t, repo.UpdateProcess(context.Background(), process))
+
+		_, err = repo.CreateNewProcess(context.Background(), models.Process{Name: "test"})
+		require.NoError(t, err)
+
+		// and can't be set running again while another process has it
+		process.Version++
+		process.Status = models.PROCESS_STATUS_RUNNING
+		require.Equal(t, repository.ErrProcessNameExists, repo.UpdateProcess(context.Background(), process))
 	})
 
 	t.Run("UpdateProcess", func(t *testing.T) {
 		defer func() {
-			_, err := conn.Exec("DELETE FROM ProcessElement")
+			_, err := conn.Exec("DELETE FROM ProcessElementError")
+			_, err = conn.Exec("DELETE FROM ProcessElement")
 			_, err = conn.Exec("DELETE FROM Process")
 			if err != nil {
 				t.Logf("error resetting Process table: %q\n", err)
 			}
 		}()
 
-		_, err := conn.Exec("DELETE FROM ProcessElement")
+		_, err := conn.Exec("DELETE FROM ProcessElementError")
+		require.NoError(t, err)
+
+		_, err = conn.Exec("DELETE FROM ProcessElement")
 		require.NoError(t, err)
 
 		_, err = conn.Exec("DELETE FROM Process")
 		require.NoError(t, err)
 
-		_, err = conn.Exec("INSERT INTO Process (status) VALUES ('RUNNING')")
+		_, err = conn.Exec("INSERT INTO Process (name, status) VALUES ('test', 'RUNNING')")
 		require.NoError(t, err)
 
 		repo := repository.NewProcessRepository(db)
 
-		existingProcesses, err := repo.GetByStatus(models.PROCESS_STATUS_RUNNING)
+		existingProcesses, err := repo.GetByStatus(context.Background(), models.PROCESS_STATUS_RUNNING)
 		require.NoError(t, err)
 		require.Equal(t, 1, len(existingProcesses))
 
 		existingProcess := existingProcesses[0]
 		existingProcess.Status = models.PROCESS_STATUS_COMPLETE
 
-		require.NoError(t, repo.UpdateProcess(existingProcess))
+		require.NoError(t, repo.UpdateProcess(context.Background(), existingProcess))
+
+		// the update applied to the version read, which is no longer current
+		require.Equal(t, repository.ErrConcurrentModification, repo.UpdateProcess(context.Background(), existingProcess))
 
-		updatedProcesses, err := repo.GetByStatus(models.PROCESS_STATUS_COMPLETE)
+		updatedProcesses, err := repo.GetByStatus(context.Background(), models.PROCESS_STATUS_COMPLETE)
 		require.NoError(t, err)
 		updatedProcess := updatedProcesses[0]
 
+		existingProcess.Version++
 		require.Equal(t, existingProcess, updatedProcess)
+
+		missing := updatedProcess
+		missing.ID++
+		require.Equal(t, repository.ErrNoProcessExists, repo.UpdateProcess(context.Background(), missing))
 	})
 }
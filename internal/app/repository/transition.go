@@ -0,0 +1,78 @@
+//go:generate mockgen -package repository -source=transition.go -destination ./mocks/transition.go
+
+package repository
+
+import (
+	"context"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	"github.com/eggsbenjamin/square_enix/internal/app/models"
+)
+
+const (
+	// maxActorLength and maxReasonLength are the lengths of the ProcessTransition actor and reason VARCHAR columns.
+	maxActorLength  = 100
+	maxReasonLength = 1024
+)
+
+type TransitionRepository interface {
+	RecordTransition(ctx context.Context, transition models.ProcessTransition) error
+	GetByProcessID(ctx context.Context, processID int) ([]models.ProcessTransition, error)
+}
+
+type transitionRepo struct {
+	db      db.Querier
+	dialect db.Dialect
+}
+
+func NewTransitionRepository(q db.Querier) TransitionRepository {
+	return &transitionRepo{
+		db:      db.Rebound(q),
+		dialect: db.DialectOf(q),
+	}
+}
+
+// RecordTransition appends a transition to a process's audit history.
+func (t *transitionRepo) RecordTransition(ctx context.Context, transition models.ProcessTransition) error {
+	transition.Actor = truncate(transition.Actor, maxActorLength)
+	transition.Reason = truncate(transition.Reason, maxReasonLength)
+
+	_, err := t.db.ExecContext(
+		ctx,
+		`
+			INSERT INTO ProcessTransition (process_id, from_state, to_state, actor, reason)
+			VALUES (?, ?, ?, ?, ?)
+		`,
+		transition.ProcessID,
+		transition.From,
+		transition.To,
+		transition.Actor,
+		transition.Reason,
+	)
+	return err
+}
+
+// GetByProcessID returns a process's audit history, oldest first.
+func (t *transitionRepo) GetByProcessID(ctx context.Context, processID int) ([]models.ProcessTransition, error) {
+	transitions := []models.ProcessTransition{}
+	return transitions, t.db.SelectContext(
+		ctx,
+		&transitions,
+		`SELECT * FROM ProcessTransition WHERE process_id = ? ORDER BY id`,
+		processID,
+	)
+}
+
+type TransitionRepositoryFactory interface {
+	CreateTransitionRepository(db db.Querier) TransitionRepository
+}
+
+type transitionRepoFactory struct{}
+
+func NewTransitionRepositoryFactory() TransitionRepositoryFactory {
+	return &transitionRepoFactory{}
+}
+
+func (t *transitionRepoFactory) CreateTransitionRepository(db db.Querier) TransitionRepository {
+	return NewTransitionRepository(db)
+}
@@ -0,0 +1,107 @@
+//go:generate mockgen -package repository -source=worker.go -destination ./mocks/worker.go
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	"github.com/eggsbenjamin/square_enix/internal/app/models"
+)
+
+type WorkerRepository interface {
+	CreateWorker(ctx context.Context, worker models.Worker) (int, error)
+	UpdateWorker(ctx context.Context, worker models.Worker) error
+	GetAll(ctx context.Context) ([]models.Worker, error)
+	DeleteExpiredBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+type workerRepo struct {
+	db      db.Querier
+	dialect db.Dialect
+}
+
+func NewWorkerRepository(q db.Querier) WorkerRepository {
+	return &workerRepo{
+		db:      db.Rebound(q),
+		dialect: db.DialectOf(q),
+	}
+}
+
+// CreateWorker registers a worker, returning its id.
+func (w *workerRepo) CreateWorker(ctx context.Context, worker models.Worker) (int, error) {
+	ids, err := w.dialect.InsertReturningIDs(
+		ctx,
+		w.db,
+		`
+			INSERT INTO Worker (hostname, pid, worker, version, started_at, heartbeat_at, expires_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`,
+		worker.Hostname,
+		worker.PID,
+		worker.Worker,
+		worker.Version,
+		worker.StartedAt,
+		worker.HeartbeatAt,
+		worker.ExpiresAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return ids[0], nil
+}
+
+// UpdateWorker records a worker's heartbeat: when it was made and expires, the batch the worker is processing
+// and when it stopped.
+func (w *workerRepo) UpdateWorker(ctx context.Context, worker models.Worker) error {
+	_, err := w.db.ExecContext(
+		ctx,
+		`
+			UPDATE Worker
+			SET heartbeat_at = ?, expires_at = ?, stopped_at = ?, process_id = ?, batch_size = ?, batch_started_at = ?
+			WHERE id = ?
+		`,
+		worker.HeartbeatAt,
+		worker.ExpiresAt,
+		worker.StoppedAt,
+		worker.ProcessID,
+		worker.BatchSize,
+		worker.BatchStartedAt,
+		worker.ID,
+	)
+	return err
+}
+
+// GetAll returns the workers ordered by id, without their statuses.
+func (w *workerRepo) GetAll(ctx context.Context) ([]models.Worker, error) {
+	workers := []models.Worker{}
+	return workers, w.db.SelectContext(ctx, &workers, "SELECT * FROM Worker ORDER BY id")
+}
+
+// DeleteExpiredBefore deletes the workers whose last heartbeat expired before before, whether they stopped or
+// died, returning how many were deleted.
+func (w *workerRepo) DeleteExpiredBefore(ctx context.Context, before time.Time) (int, error) {
+	res, err := w.db.ExecContext(ctx, "DELETE FROM Worker WHERE expires_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := res.RowsAffected()
+	return int(deleted), err
+}
+
+type WorkerRepositoryFactory interface {
+	CreateWorkerRepository(db db.Querier) WorkerRepository
+}
+
+type workerRepoFactory struct{}
+
+func NewWorkerRepositoryFactory() WorkerRepositoryFactory {
+	return &workerRepoFactory{}
+}
+
+func (w *workerRepoFactory) CreateWorkerRepository(db db.Querier) WorkerRepository {
+	return NewWorkerRepository(db)
+}
@@ -0,0 +1,101 @@
+// +build integration
+
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	"github.com/eggsbenjamin/square_enix/internal/app/db/dbtest"
+	"github.com/eggsbenjamin/square_enix/internal/app/models"
+	"github.com/eggsbenjamin/square_enix/internal/app/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerRepository(t *testing.T) {
+	conn, err := dbtest.Connect()
+	require.NoError(t, err)
+
+	db := db.NewQuerier(conn.DB)
+	ctx := context.Background()
+
+	t.Run("Heartbeats", func(t *testing.T) {
+		defer func() {
+			if _, err := conn.Exec("DELETE FROM Worker"); err != nil {
+				t.Logf("error resetting Worker table: %q\n", err)
+			}
+		}()
+
+		_, err := conn.Exec("DELETE FROM Worker")
+		require.NoError(t, err)
+
+		repo := repository.NewWorkerRepository(db)
+
+		now := time.Now().UTC().Truncate(time.Second)
+		worker := models.Worker{
+			Hostname:    "host",
+			PID:         100,
+			Worker:      1,
+			Version:     "v1",
+			StartedAt:   now,
+			HeartbeatAt: now,
+			ExpiresAt:   now.Add(time.Minute),
+		}
+
+		worker.ID, err = repo.CreateWorker(ctx, worker)
+		require.NoError(t, err)
+		require.NotZero(t, worker.ID)
+
+		processID := 5
+		worker.HeartbeatAt = now.Add(time.Second)
+		worker.ExpiresAt = now.Add(time.Minute + time.Second)
+		worker.ProcessID = &processID
+		worker.BatchSize = 10
+		worker.BatchStartedAt = &now
+		require.NoError(t, repo.UpdateWorker(ctx, worker))
+
+		workers, err := repo.GetAll(ctx)
+		require.NoError(t, err)
+		require.Len(t, workers, 1)
+		require.Equal(t, "host", workers[0].Hostname)
+		require.Equal(t, 100, workers[0].PID)
+		require.Equal(t, 1, workers[0].Worker)
+		require.Equal(t, "v1", workers[0].Version)
+		require.True(t, now.Equal(workers[0].StartedAt))
+		require.True(t, worker.HeartbeatAt.Equal(workers[0].HeartbeatAt))
+		require.True(t, worker.ExpiresAt.Equal(workers[0].ExpiresAt))
+		require.Equal(t, 5, *workers[0].ProcessID)
+		require.Equal(t, 10, workers[0].BatchSize)
+		require.True(t, now.Equal(*workers[0].BatchStartedAt))
+		require.Nil(t, workers[0].StoppedAt)
+	})
+
+	t.Run("DeleteExpiredBefore", func(t *testing.T) {
+		defer func() {
+			if _, err := conn.Exec("DELETE FROM Worker"); err != nil {
+				t.Logf("error resetting Worker table: %q\n", err)
+			}
+		}()
+
+		_, err := conn.Exec("DELETE FROM Worker")
+		require.NoError(t, err)
+
+		repo := repository.NewWorkerRepository(db)
+
+		now := time.Now().UTC()
+		for _, expiresAt := range []time.Time{now.Add(-48 * time.Hour), now.Add(-time.Hour), now.Add(time.Minute)} {
+			_, err := repo.CreateWorker(ctx, models.Worker{Hostname: "host", Worker: 1, StartedAt: now, HeartbeatAt: now, ExpiresAt: expiresAt})
+			require.NoError(t, err)
+		}
+
+		deleted, err := repo.DeleteExpiredBefore(ctx, now.Add(-24*time.Hour))
+		require.NoError(t, err)
+		require.Equal(t, 1, deleted)
+
+		workers, err := repo.GetAll(ctx)
+		require.NoError(t, err)
+		require.Len(t, workers, 2)
+	})
+}
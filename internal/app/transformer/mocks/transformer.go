@@ -0,0 +1,113 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: transformer.go
+
+// Package transformer is a generated GoMock package.
+package transformer
+
+import (
+	transformer "github.com/eggsbenjamin/square_enix/internal/app/transformer"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// MockTransformer is a mock of Transformer interface
+type MockTransformer struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransformerMockRecorder
+}
+
+// MockTransformerMockRecorder is the mock recorder for MockTransformer
+type MockTransformerMockRecorder struct {
+	mock *MockTransformer
+}
+
+// NewMockTransformer creates a new mock instance
+func NewMockTransformer(ctrl *gomock.Controller) *MockTransformer {
+	mock := &MockTransformer{ctrl: ctrl}
+	mock.recorder = &MockTransformerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockTransformer) EXPECT() *MockTransformerMockRecorder {
+	return m.recorder
+}
+
+// Transform mocks base method
+func (m *MockTransformer) Transform(data string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transform", data)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Transform indicates an expected call of Transform
+func (mr *MockTransformerMockRecorder) Transform(data interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transform", reflect.TypeOf((*MockTransformer)(nil).Transform), data)
+}
+
+// MockRegistry is a mock of Registry interface
+type MockRegistry struct {
+	ctrl     *gomock.Controller
+	recorder *MockRegistryMockRecorder
+}
+
+// MockRegistryMockRecorder is the mock recorder for MockRegistry
+type MockRegistryMockRecorder struct {
+	mock *MockRegistry
+}
+
+// NewMockRegistry creates a new mock instance
+func NewMockRegistry(ctrl *gomock.Controller) *MockRegistry {
+	mock := &MockRegistry{ctrl: ctrl}
+	mock.recorder = &MockRegistryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockRegistry) EXPECT() *MockRegistryMockRecorder {
+	return m.recorder
+}
+
+// Register mocks base method
+func (m *MockRegistry) Register(name string, constructor transformer.Constructor) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Register", name, constructor)
+}
+
+// Register indicates an expected call of Register
+func (mr *MockRegistryMockRecorder) Register(name, constructor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockRegistry)(nil).Register), name, constructor)
+}
+
+// Create mocks base method
+func (m *MockRegistry) Create(name, config string) (transformer.Transformer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", name, config)
+	ret0, _ := ret[0].(transformer.Transformer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create
+func (mr *MockRegistryMockRecorder) Create(name, config interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRegistry)(nil).Create), name, config)
+}
+
+// Names mocks base method
+func (m *MockRegistry) Names() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Names")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// Names indicates an expected call of Names
+func (mr *MockRegistryMockRecorder) Names() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Names", reflect.TypeOf((*MockRegistry)(nil).Names))
+}
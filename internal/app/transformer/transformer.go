@@ -0,0 +1,210 @@
+//go:generate mockgen -package transformer -source=transformer.go -destination ./mocks/transformer.go
+
+package transformer
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	UPPER         = "upper"
+	LOWER         = "lower"
+	TRIM          = "trim"
+	REGEX_REPLACE = "regex-replace"
+	TEMPLATE      = "template"
+
+	DEFAULT = UPPER
+)
+
+var (
+	ErrUnknownTransformer = errors.New("unknown transformer")
+	ErrInvalidConfig      = errors.New("invalid transformer config")
+)
+
+// Transformer applies a unit of business logic to the data of a single element.
+type Transformer interface {
+	Transform(data string) (string, error)
+}
+
+// Func adapts an ordinary function to the Transformer interface.
+type Func func(data string) (string, error)
+
+func (f Func) Transform(data string) (string, error) {
+	return f(data)
+}
+
+// Constructor builds a Transformer from the raw JSON config stored against a process.
+type Constructor func(config string) (Transformer, error)
+
+type Registry interface {
+	Register(name string, constructor Constructor)
+	Create(name, config string) (Transformer, error)
+	Names() []string
+}
+
+type registry struct {
+	mu           sync.RWMutex
+	constructors map[string]Constructor
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() Registry {
+	return &registry{
+		constructors: map[string]Constructor{},
+	}
+}
+
+// NewDefaultRegistry returns a registry populated with the built in transformers.
+func NewDefaultRegistry() Registry {
+	r := NewRegistry()
+	r.Register(UPPER, newUpper)
+	r.Register(LOWER, newLower)
+	r.Register(TRIM, newTrim)
+	r.Register(REGEX_REPLACE, newRegexReplace)
+	r.Register(TEMPLATE, newTemplate)
+
+	return r
+}
+
+func (r *registry) Register(name string, constructor Constructor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.constructors[name] = constructor
+}
+
+func (r *registry) Create(name, config string) (Transformer, error) {
+	r.mu.RLock()
+	constructor, ok := r.constructors[name]
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, errors.Wrap(ErrUnknownTransformer, name)
+	}
+
+	return constructor(config)
+}
+
+func (r *registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.constructors))
+	for name := range r.constructors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// decodeConfig unmarshals config into dest, treating an empty config as an empty object.
+func decodeConfig(config string, dest interface{}) error {
+	if strings.TrimSpace(config) == "" {
+		return nil
+	}
+
+	if err := json.Unmarshal([]byte(config), dest); err != nil {
+		return errors.Wrap(ErrInvalidConfig, err.Error())
+	}
+
+	return nil
+}
+
+func newUpper(config string) (Transformer, error) {
+	return Func(func(data string) (string, error) {
+		return strings.ToUpper(data), nil
+	}), nil
+}
+
+func newLower(config string) (Transformer, error) {
+	return Func(func(data string) (string, error) {
+		return strings.ToLower(data), nil
+	}), nil
+}
+
+type trimConfig struct {
+	Cutset string `json:"cutset"`
+}
+
+func newTrim(config string) (Transformer, error) {
+	var cfg trimConfig
+	if err := decodeConfig(config, &cfg); err != nil {
+		return nil, err
+	}
+
+	return Func(func(data string) (string, error) {
+		if cfg.Cutset == "" {
+			return strings.TrimSpace(data), nil
+		}
+		return strings.Trim(data, cfg.Cutset), nil
+	}), nil
+}
+
+type regexReplaceConfig struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+}
+
+func newRegexReplace(config string) (Transformer, error) {
+	var cfg regexReplaceConfig
+	if err := decodeConfig(config, &cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.Pattern == "" {
+		return nil, errors.Wrap(ErrInvalidConfig, "pattern is required")
+	}
+
+	re, err := regexp.Compile(cfg.Pattern)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidConfig, err.Error())
+	}
+
+	return Func(func(data string) (string, error) {
+		return re.ReplaceAllString(data, cfg.Replacement), nil
+	}), nil
+}
+
+type templateConfig struct {
+	Template string `json:"template"`
+}
+
+var templateFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+}
+
+// newTemplate executes a text/template with the element's data as dot, e.g. `{{ . | upper }}!`.
+func newTemplate(config string) (Transformer, error) {
+	var cfg templateConfig
+	if err := decodeConfig(config, &cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.Template == "" {
+		return nil, errors.Wrap(ErrInvalidConfig, "template is required")
+	}
+
+	tmpl, err := template.New(TEMPLATE).Funcs(templateFuncs).Option("missingkey=error").Parse(cfg.Template)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidConfig, err.Error())
+	}
+
+	return Func(func(data string) (string, error) {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}), nil
+}
@@ -0,0 +1,53 @@
+// +build unit
+
+package transformer_test
+
+import (
+	"testing"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/transformer"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := transformer.NewDefaultRegistry()
+
+	t.Run("Unknown Transformer", func(t *testing.T) {
+		_, err := registry.Create("unknown", "")
+		require.Equal(t, transformer.ErrUnknownTransformer, errors.Cause(err))
+	})
+
+	t.Run("Invalid Config", func(t *testing.T) {
+		_, err := registry.Create(transformer.REGEX_REPLACE, `{"pattern": "("}`)
+		require.Equal(t, transformer.ErrInvalidConfig, errors.Cause(err))
+
+		_, err = registry.Create(transformer.TEMPLATE, `not json`)
+		require.Equal(t, transformer.ErrInvalidConfig, errors.Cause(err))
+	})
+
+	t.Run("Builtins", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			config string
+			input  string
+			output string
+		}{
+			{transformer.UPPER, "", "test", "TEST"},
+			{transformer.LOWER, "", "TeSt", "test"},
+			{transformer.TRIM, "", "  test  ", "test"},
+			{transformer.TRIM, `{"cutset": "x"}`, "xxtestxx", "test"},
+			{transformer.REGEX_REPLACE, `{"pattern": "[aeiou]", "replacement": "_"}`, "test", "t_st"},
+			{transformer.TEMPLATE, `{"template": "{{ . | upper }}!"}`, "test", "TEST!"},
+		}
+
+		for _, test := range tests {
+			tr, err := registry.Create(test.name, test.config)
+			require.NoError(t, err)
+
+			output, err := tr.Transform(test.input)
+			require.NoError(t, err)
+			require.Equal(t, test.output, output, test.name)
+		}
+	})
+}
@@ -0,0 +1,124 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: workers.go
+
+// Package workers is a generated GoMock package.
+package workers
+
+import (
+	context "context"
+	models "github.com/eggsbenjamin/square_enix/internal/app/models"
+	workers "github.com/eggsbenjamin/square_enix/internal/app/workers"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// MockRegistry is a mock of Registry interface
+type MockRegistry struct {
+	ctrl     *gomock.Controller
+	recorder *MockRegistryMockRecorder
+}
+
+// MockRegistryMockRecorder is the mock recorder for MockRegistry
+type MockRegistryMockRecorder struct {
+	mock *MockRegistry
+}
+
+// NewMockRegistry creates a new mock instance
+func NewMockRegistry(ctrl *gomock.Controller) *MockRegistry {
+	mock := &MockRegistry{ctrl: ctrl}
+	mock.recorder = &MockRegistryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockRegistry) EXPECT() *MockRegistryMockRecorder {
+	return m.recorder
+}
+
+// Register mocks base method
+func (m *MockRegistry) Register(worker int) workers.Registration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Register", worker)
+	ret0, _ := ret[0].(workers.Registration)
+	return ret0
+}
+
+// Register indicates an expected call of Register
+func (mr *MockRegistryMockRecorder) Register(worker interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockRegistry)(nil).Register), worker)
+}
+
+// GetWorkers mocks base method
+func (m *MockRegistry) GetWorkers(ctx context.Context) ([]models.Worker, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWorkers", ctx)
+	ret0, _ := ret[0].([]models.Worker)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWorkers indicates an expected call of GetWorkers
+func (mr *MockRegistryMockRecorder) GetWorkers(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWorkers", reflect.TypeOf((*MockRegistry)(nil).GetWorkers), ctx)
+}
+
+// MockRegistration is a mock of Registration interface
+type MockRegistration struct {
+	ctrl     *gomock.Controller
+	recorder *MockRegistrationMockRecorder
+}
+
+// MockRegistrationMockRecorder is the mock recorder for MockRegistration
+type MockRegistrationMockRecorder struct {
+	mock *MockRegistration
+}
+
+// NewMockRegistration creates a new mock instance
+func NewMockRegistration(ctrl *gomock.Controller) *MockRegistration {
+	mock := &MockRegistration{ctrl: ctrl}
+	mock.recorder = &MockRegistrationMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockRegistration) EXPECT() *MockRegistrationMockRecorder {
+	return m.recorder
+}
+
+// BatchStarted mocks base method
+func (m *MockRegistration) BatchStarted(processID, elements int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "BatchStarted", processID, elements)
+}
+
+// BatchStarted indicates an expected call of BatchStarted
+func (mr *MockRegistrationMockRecorder) BatchStarted(processID, elements interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchStarted", reflect.TypeOf((*MockRegistration)(nil).BatchStarted), processID, elements)
+}
+
+// BatchFinished mocks base method
+func (m *MockRegistration) BatchFinished() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "BatchFinished")
+}
+
+// BatchFinished indicates an expected call of BatchFinished
+func (mr *MockRegistrationMockRecorder) BatchFinished() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchFinished", reflect.TypeOf((*MockRegistration)(nil).BatchFinished))
+}
+
+// Run mocks base method
+func (m *MockRegistration) Run(ctx context.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Run", ctx)
+}
+
+// Run indicates an expected call of Run
+func (mr *MockRegistrationMockRecorder) Run(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockRegistration)(nil).Run), ctx)
+}
@@ -0,0 +1,219 @@
+//go:generate mockgen -package workers -source=workers.go -destination ./mocks/workers.go
+
+package workers
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/db"
+	"github.com/eggsbenjamin/square_enix/internal/app/leader"
+	"github.com/eggsbenjamin/square_enix/internal/app/models"
+	"github.com/eggsbenjamin/square_enix/internal/app/repository"
+)
+
+const (
+	// missedHeartbeats is the number of heartbeats a worker can miss before it's considered dead.
+	missedHeartbeats = 3
+
+	// expiredWorkerRetention is how long workers are listed for after their last heartbeat expired.
+	expiredWorkerRetention = 24 * time.Hour
+)
+
+// Config describes the instance the workers run in and how often they heartbeat.
+type Config struct {
+	Hostname          string
+	PID               int
+	Version           string
+	HeartbeatInterval time.Duration
+}
+
+// Registry records the workers of every instance in the Worker table, so they can be listed along with
+// whether they're alive.
+type Registry interface {
+	// Register returns the registration of this instance's worker numbered worker. The worker is recorded
+	// once the registration is run.
+	Register(worker int) Registration
+
+	// GetWorkers lists the workers of every instance, live, dead and stopped.
+	GetWorkers(ctx context.Context) ([]models.Worker, error)
+}
+
+// Registration records a worker's heartbeats along with the batch it's processing.
+type Registration interface {
+	// BatchStarted and BatchFinished track the batch the worker is processing, which is recorded with its
+	// next heartbeat.
+	BatchStarted(processID, elements int)
+	BatchFinished()
+
+	// Run records the worker, then its heartbeat every heartbeat interval, until ctx is done. The worker is
+	// then recorded as stopped.
+	Run(ctx context.Context)
+}
+
+type registry struct {
+	db                db.DB
+	workerRepoFactory repository.WorkerRepositoryFactory
+	elector           leader.Elector
+	cfg               Config
+}
+
+func NewRegistry(
+	db db.DB,
+	workerRepoFactory repository.WorkerRepositoryFactory,
+	elector leader.Elector,
+	cfg Config,
+) Registry {
+	return &registry{
+		db:                db,
+		workerRepoFactory: workerRepoFactory,
+		elector:           elector,
+		cfg:               cfg,
+	}
+}
+
+func (r *registry) Register(worker int) Registration {
+	return &registration{
+		registry: r,
+		worker: models.Worker{
+			Hostname:  r.cfg.Hostname,
+			PID:       r.cfg.PID,
+			Worker:    worker,
+			Version:   r.cfg.Version,
+			StartedAt: time.Now().UTC(),
+		},
+	}
+}
+
+// GetWorkers flags the workers whose heartbeats have expired as dead.
+func (r *registry) GetWorkers(ctx context.Context) ([]models.Worker, error) {
+	workers, err := r.workerRepoFactory.CreateWorkerRepository(r.db).GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range workers {
+		workers[i] = workers[i].WithStatus(now)
+	}
+
+	return workers, nil
+}
+
+type registration struct {
+	registry *registry
+
+	mu     sync.Mutex
+	worker models.Worker
+}
+
+func (r *registration) BatchStarted(processID, elements int) {
+	now := time.Now().UTC()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.worker.ProcessID = &processID
+	r.worker.BatchSize = elements
+	r.worker.BatchStartedAt = &now
+}
+
+func (r *registration) BatchFinished() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.worker.ProcessID = nil
+	r.worker.BatchSize = 0
+	r.worker.BatchStartedAt = nil
+}
+
+func (r *registration) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.registry.cfg.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		r.heartbeat(ctx)
+
+		select {
+		case <-ctx.Done():
+			r.stop()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// heartbeat records the worker, if it hasn't been recorded yet, or its heartbeat. The first worker of the
+// leader also deletes the workers whose heartbeats expired over a day ago.
+func (r *registration) heartbeat(ctx context.Context) {
+	now := time.Now().UTC()
+
+	r.mu.Lock()
+	r.worker.HeartbeatAt = now
+	r.worker.ExpiresAt = now.Add(missedHeartbeats * r.registry.cfg.HeartbeatInterval)
+	worker := r.worker
+	r.mu.Unlock()
+
+	repo := r.registry.workerRepoFactory.CreateWorkerRepository(r.registry.db)
+
+	if worker.ID == 0 {
+		id, err := repo.CreateWorker(ctx, worker)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("worker %d: error registering: %q\n", worker.Worker, err)
+			}
+			return
+		}
+
+		r.mu.Lock()
+		r.worker.ID = id
+		r.mu.Unlock()
+	} else if err := repo.UpdateWorker(ctx, worker); err != nil {
+		if ctx.Err() == nil {
+			log.Printf("worker %d: error recording heartbeat: %q\n", worker.Worker, err)
+		}
+		return
+	}
+
+	if worker.Worker == 1 && r.registry.elector.IsLeader() {
+		deleted, err := repo.DeleteExpiredBefore(ctx, now.Add(-expiredWorkerRetention))
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("error deleting expired workers: %q\n", err)
+			}
+			return
+		}
+
+		if deleted > 0 {
+			log.Printf("deleted %d expired workers\n", deleted)
+		}
+	}
+}
+
+// stop records the worker as stopped, if it was recorded.
+func (r *registration) stop() {
+	now := time.Now().UTC()
+
+	r.mu.Lock()
+	r.worker.HeartbeatAt = now
+	r.worker.ExpiresAt = now
+	r.worker.StoppedAt = &now
+	r.worker.ProcessID = nil
+	r.worker.BatchSize = 0
+	r.worker.BatchStartedAt = nil
+	worker := r.worker
+	r.mu.Unlock()
+
+	if worker.ID == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.registry.cfg.HeartbeatInterval)
+	defer cancel()
+
+	if err := r.registry.workerRepoFactory.CreateWorkerRepository(r.registry.db).UpdateWorker(ctx, worker); err != nil {
+		log.Printf("worker %d: error recording stop: %q\n", worker.Worker, err)
+	}
+}
@@ -0,0 +1,102 @@
+// +build unit
+
+package workers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/eggsbenjamin/square_enix/internal/app/leader"
+	"github.com/eggsbenjamin/square_enix/internal/app/models"
+	"github.com/eggsbenjamin/square_enix/internal/app/repository/memory"
+)
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	cfg := Config{
+		Hostname:          "host",
+		PID:               100,
+		Version:           "v1",
+		HeartbeatInterval: time.Minute,
+	}
+
+	t.Run("Heartbeats", func(t *testing.T) {
+		registry := NewRegistry(memory.NewDB(), memory.NewWorkerRepositoryFactory(), leader.NewSoleElector(), cfg)
+
+		registration := registry.Register(1).(*registration)
+		registration.heartbeat(ctx)
+
+		workers, err := registry.GetWorkers(ctx)
+		require.NoError(t, err)
+		require.Len(t, workers, 1)
+		require.Equal(t, "host", workers[0].Hostname)
+		require.Equal(t, 100, workers[0].PID)
+		require.Equal(t, 1, workers[0].Worker)
+		require.Equal(t, "v1", workers[0].Version)
+		require.Equal(t, models.WORKER_STATUS_LIVE, workers[0].Status)
+		require.Nil(t, workers[0].ProcessID)
+
+		registration.BatchStarted(5, 10)
+		registration.heartbeat(ctx)
+
+		workers, err = registry.GetWorkers(ctx)
+		require.NoError(t, err)
+		require.Len(t, workers, 1)
+		require.Equal(t, 5, *workers[0].ProcessID)
+		require.Equal(t, 10, workers[0].BatchSize)
+		require.NotNil(t, workers[0].BatchStartedAt)
+
+		registration.BatchFinished()
+		registration.stop()
+
+		workers, err = registry.GetWorkers(ctx)
+		require.NoError(t, err)
+		require.Len(t, workers, 1)
+		require.Equal(t, models.WORKER_STATUS_STOPPED, workers[0].Status)
+		require.Nil(t, workers[0].ProcessID)
+	})
+
+	t.Run("Dead", func(t *testing.T) {
+		cfg := cfg
+		cfg.HeartbeatInterval = 10 * time.Millisecond
+		registry := NewRegistry(memory.NewDB(), memory.NewWorkerRepositoryFactory(), leader.NewSoleElector(), cfg)
+
+		registry.Register(1).(*registration).heartbeat(ctx)
+
+		// the worker misses its heartbeats
+		time.Sleep(missedHeartbeats * cfg.HeartbeatInterval)
+
+		workers, err := registry.GetWorkers(ctx)
+		require.NoError(t, err)
+		require.Len(t, workers, 1)
+		require.Equal(t, models.WORKER_STATUS_DEAD, workers[0].Status)
+	})
+
+	t.Run("Expired Workers Deleted", func(t *testing.T) {
+		db := memory.NewDB()
+		registry := NewRegistry(db, memory.NewWorkerRepositoryFactory(), leader.NewSoleElector(), cfg)
+
+		expired := time.Now().Add(-expiredWorkerRetention - time.Minute)
+		_, err := memory.NewWorkerRepository(db).CreateWorker(ctx, models.Worker{Worker: 1, ExpiresAt: expired})
+		require.NoError(t, err)
+
+		// only the leader's first worker deletes them
+		registry.Register(2).(*registration).heartbeat(ctx)
+
+		workers, err := registry.GetWorkers(ctx)
+		require.NoError(t, err)
+		require.Len(t, workers, 2)
+
+		registry.Register(1).(*registration).heartbeat(ctx)
+
+		workers, err = registry.GetWorkers(ctx)
+		require.NoError(t, err)
+		require.Len(t, workers, 2)
+		for _, worker := range workers {
+			require.Equal(t, models.WORKER_STATUS_LIVE, worker.Status)
+		}
+	})
+}
@@ -0,0 +1,458 @@
+package env
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// configFileEnv names the env var, and configFileFlag the flag, giving the path of a config file.
+const (
+	configFileEnv  = "CONFIG_FILE"
+	configFileFlag = "config"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Errors aggregates every problem found loading a config so they can all be fixed at once.
+type Errors []error
+
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+
+	return "invalid config: " + strings.Join(msgs, "; ")
+}
+
+// Validator is implemented by config structs with constraints beyond their fields being required. Validate
+// may return Errors to report several problems.
+type Validator interface {
+	Validate() error
+}
+
+// Loader fills a config struct from, in increasing precedence, its fields' defaults, a JSON or YAML config file,
+// env vars and flags. Fields are configured by tags:
+//   - env: the env var the field is read from, its lowercase form is also its key in the config file. Fields
+//     without one can't be set by the config file. Further comma separated names are aliases read when the
+//     first isn't set, e.g. the names the env var had before it was renamed
+//   - flag: the flag the field is read from
+//   - default: the field's value when no source sets it. Without one the field keeps the value it had
+//   - required: "true" if a source must set the field
+//   - usage: the flag's usage
+//
+// Fields may be strings, ints, floats, bools, time.Durations or string slices. Durations are Go duration strings,
+// e.g. 500ms, or a bare number of seconds. Lists are comma separated in env vars and flags. Struct fields without
+// tags are loaded recursively, and validated if they implement Validator.
+type Loader struct {
+	fs     *flag.FlagSet
+	cfg    reflect.Value
+	fields []*field
+	file   *string
+}
+
+type field struct {
+	value    reflect.Value
+	owner    reflect.Value
+	env      string
+	aliases  []string
+	flag     string
+	def      string
+	hasDef   bool
+	required bool
+	usage    string
+	set      bool
+	invalid  bool
+}
+
+// name describes the field in errors by the sources it's read from.
+func (f *field) name() string {
+	names := []string{}
+	if f.flag != "" {
+		names = append(names, "-"+f.flag)
+	}
+	if f.env != "" {
+		names = append(names, f.env)
+	}
+
+	return strings.Join(names, "/")
+}
+
+// NewLoader registers cfg's flags, and a flag for the config file, on fs. cfg must be a pointer to a struct, the
+// loader panics if it or its tags are invalid.
+func NewLoader(fs *flag.FlagSet, cfg interface{}) *Loader {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("env: config must be a pointer to a struct, got %T", cfg))
+	}
+
+	l := &Loader{
+		fs:  fs,
+		cfg: v.Elem(),
+	}
+	l.collect(v.Elem())
+
+	for _, f := range l.fields {
+		if f.flag == "" {
+			continue
+		}
+
+		usage := f.usage
+		if f.env != "" {
+			usage = strings.TrimSpace(fmt.Sprintf("%s (%s)", usage, f.env))
+		}
+		fs.Var(&flagValue{field: f}, f.flag, usage)
+	}
+
+	l.file = fs.String(configFileFlag, GetEnv(configFileEnv, ""), fmt.Sprintf("JSON or YAML config file (%s)", configFileEnv))
+
+	return l
+}
+
+func (l *Loader) collect(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		if structField.PkgPath != "" {
+			continue
+		}
+
+		tag := structField.Tag
+		value := v.Field(i)
+
+		if tag.Get("env") == "" && tag.Get("flag") == "" {
+			if value.Kind() == reflect.Struct {
+				l.collect(value)
+			}
+			continue
+		}
+
+		if !supported(value.Type()) {
+			panic(fmt.Sprintf("env: unsupported config field type %s of %s", value.Type(), structField.Name))
+		}
+
+		envs := strings.Split(tag.Get("env"), ",")
+		def, hasDef := tag.Lookup("default")
+		l.fields = append(l.fields, &field{
+			value:    value,
+			owner:    v,
+			env:      envs[0],
+			aliases:  envs[1:],
+			flag:     tag.Get("flag"),
+			def:      def,
+			hasDef:   hasDef,
+			required: tag.Get("required") == "true",
+			usage:    tag.Get("usage"),
+		})
+	}
+}
+
+// Load parses args with the loader's flag set then fills the config. Every problem found is returned together
+// as Errors.
+func (l *Loader) Load(args []string) error {
+	if err := l.fs.Parse(args); err != nil {
+		return err
+	}
+
+	errs := Errors{}
+
+	for _, f := range l.fields {
+		if f.hasDef {
+			if err := setValue(f.value, f.def); err != nil {
+				f.invalid = true
+				errs = append(errs, fmt.Errorf("%s: invalid default %q: %s", f.name(), f.def, err))
+			}
+		}
+	}
+
+	if *l.file != "" {
+		errs = append(errs, l.loadFile(*l.file)...)
+	}
+
+	for _, f := range l.fields {
+		if f.env == "" {
+			continue
+		}
+
+		for _, name := range append([]string{f.env}, f.aliases...) {
+			raw, ok := os.LookupEnv(name)
+			if !ok {
+				continue
+			}
+
+			f.set = true
+			if err := setValue(f.value, raw); err != nil {
+				f.invalid = true
+				errs = append(errs, fmt.Errorf("%s: %s", name, err))
+			}
+			break
+		}
+	}
+
+	l.fs.Visit(func(fl *flag.Flag) {
+		value, ok := fl.Value.(*flagValue)
+		if !ok {
+			return
+		}
+
+		value.field.set = true
+		if err := setValue(value.field.value, value.raw); err != nil {
+			value.field.invalid = true
+			errs = append(errs, fmt.Errorf("-%s: %s", fl.Name, err))
+		}
+	})
+
+	invalid := map[structKey]bool{}
+	for _, f := range l.fields {
+		if f.required && !f.set {
+			f.invalid = true
+			errs = append(errs, fmt.Errorf("%s is required", f.name()))
+		}
+
+		if f.invalid {
+			invalid[keyOf(f.owner)] = true
+		}
+	}
+
+	errs = append(errs, validate(l.cfg, invalid)...)
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (l *Loader) loadFile(path string) Errors {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Errors{fmt.Errorf("error reading config file: %s", err)}
+	}
+
+	values := map[string]interface{}{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		err = json.Unmarshal(contents, &values)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(contents, &values)
+	default:
+		return Errors{fmt.Errorf("config file must be .json, .yaml or .yml, got %q", ext)}
+	}
+	if err != nil {
+		return Errors{fmt.Errorf("error parsing config file: %s", err)}
+	}
+
+	byKey := map[string]*field{}
+	for _, f := range l.fields {
+		if f.env == "" {
+			continue
+		}
+
+		for _, name := range append([]string{f.env}, f.aliases...) {
+			byKey[strings.ToLower(name)] = f
+		}
+	}
+
+	errs := Errors{}
+	for key, value := range values {
+		f, ok := byKey[key]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: unknown config file key %q", path, key))
+			continue
+		}
+
+		f.set = true
+		if err := setFileValue(f.value, value); err != nil {
+			f.invalid = true
+			errs = append(errs, fmt.Errorf("%s: %s: %s", path, key, err))
+		}
+	}
+
+	return errs
+}
+
+// validate runs the Validate method of v and of the structs nested within it. Structs with invalid fields aren't
+// validated, so a missing or malformed field is reported once rather than again by the struct's constraints.
+func validate(v reflect.Value, invalid map[structKey]bool) Errors {
+	errs := Errors{}
+
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).PkgPath == "" && v.Field(i).Kind() == reflect.Struct {
+			errs = append(errs, validate(v.Field(i), invalid)...)
+		}
+	}
+
+	if invalid[keyOf(v)] {
+		return errs
+	}
+
+	if validator, ok := v.Addr().Interface().(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			if nested, ok := err.(Errors); ok {
+				errs = append(errs, nested...)
+			} else {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errs
+}
+
+// structKey identifies a struct within the config, by type as well as address as a struct's first field shares
+// its address.
+type structKey struct {
+	addr uintptr
+	typ  reflect.Type
+}
+
+func keyOf(v reflect.Value) structKey {
+	return structKey{addr: v.Addr().Pointer(), typ: v.Type()}
+}
+
+func supported(t reflect.Type) bool {
+	if t == durationType {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int64, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.String
+	}
+
+	return false
+}
+
+// setValue sets v from its string form, as given by an env var, flag or default.
+func setValue(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := parseDuration(raw)
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid bool %q", raw)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		i, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(i)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		items := []string{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	}
+
+	return nil
+}
+
+// setFileValue sets v from a value decoded from a config file, where lists are lists rather than comma separated.
+func setFileValue(v reflect.Value, value interface{}) error {
+	if list, ok := value.([]interface{}); ok {
+		if v.Kind() != reflect.Slice {
+			return fmt.Errorf("unexpected list")
+		}
+
+		items := make([]string, 0, len(list))
+		for _, item := range list {
+			items = append(items, fmt.Sprint(item))
+		}
+		v.Set(reflect.ValueOf(items))
+		return nil
+	}
+
+	switch value.(type) {
+	case map[string]interface{}, map[interface{}]interface{}:
+		return fmt.Errorf("unexpected object")
+	case float64:
+		// JSON decodes every number as a float64, format integers without an exponent
+		return setValue(v, strconv.FormatFloat(value.(float64), 'f', -1, 64))
+	}
+
+	return setValue(v, fmt.Sprint(value))
+}
+
+// parseDuration parses a Go duration string, or a bare number of seconds.
+func parseDuration(raw string) (time.Duration, error) {
+	raw = strings.TrimSpace(raw)
+	if seconds, err := strconv.ParseFloat(raw, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", raw)
+	}
+
+	return d, nil
+}
+
+// flagValue records the raw value of a config field's flag, it's set on the field once every source is read.
+type flagValue struct {
+	field *field
+	raw   string
+}
+
+func (f *flagValue) String() string {
+	if f == nil || f.field == nil {
+		return ""
+	}
+
+	if f.field.hasDef {
+		return f.field.def
+	}
+
+	if f.field.value.IsZero() {
+		return ""
+	}
+
+	if f.field.value.Kind() == reflect.Slice {
+		return strings.Join(f.field.value.Interface().([]string), ",")
+	}
+
+	return fmt.Sprint(f.field.value.Interface())
+}
+
+func (f *flagValue) Set(raw string) error {
+	f.raw = raw
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool {
+	return f.field.value.Kind() == reflect.Bool
+}
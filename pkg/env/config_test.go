@@ -0,0 +1,164 @@
+// +build unit
+
+package env
+
+import (
+	"errors"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testNested struct {
+	Hosts []string `env:"TEST_HOSTS" flag:"hosts"`
+	Debug bool     `env:"TEST_DEBUG" flag:"debug"`
+}
+
+func (n *testNested) Validate() error {
+	if len(n.Hosts) > 2 {
+		return errors.New("too many hosts")
+	}
+
+	return nil
+}
+
+type testConfig struct {
+	Name     string        `env:"TEST_NAME" flag:"name" required:"true"`
+	Port     int           `env:"TEST_PORT" flag:"port" default:"3306"`
+	Ratio    float64       `env:"TEST_RATIO" flag:"ratio" default:"0.5"`
+	Interval time.Duration `env:"TEST_INTERVAL" flag:"interval" default:"5s"`
+	User     string        `env:"TEST_USER,TEST_OLD_USER" flag:"user"`
+	Nested   testNested
+}
+
+func load(t *testing.T, environ map[string]string, args ...string) (testConfig, error) {
+	for k, v := range environ {
+		require.NoError(t, os.Setenv(k, v))
+	}
+	defer func() {
+		for k := range environ {
+			os.Unsetenv(k)
+		}
+	}()
+
+	var cfg testConfig
+	err := NewLoader(flag.NewFlagSet("test", flag.ContinueOnError), &cfg).Load(args)
+	return cfg, err
+}
+
+func writeFile(t *testing.T, name, contents string) string {
+	dir, err := ioutil.TempDir("", "config")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0600))
+	return path
+}
+
+func TestLoader(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		cfg, err := load(t, nil, "-name", "test")
+		require.NoError(t, err)
+		require.Equal(t, testConfig{Name: "test", Port: 3306, Ratio: 0.5, Interval: 5 * time.Second}, cfg)
+	})
+
+	t.Run("Env", func(t *testing.T) {
+		cfg, err := load(t, map[string]string{
+			"TEST_NAME":     "test",
+			"TEST_PORT":     "3307",
+			"TEST_INTERVAL": "500ms",
+			"TEST_HOSTS":    "a, b",
+			"TEST_DEBUG":    "true",
+		})
+		require.NoError(t, err)
+		require.Equal(t, 3307, cfg.Port)
+		require.Equal(t, 500*time.Millisecond, cfg.Interval)
+		require.Equal(t, []string{"a", "b"}, cfg.Nested.Hosts)
+		require.True(t, cfg.Nested.Debug)
+	})
+
+	t.Run("Flags Override Env", func(t *testing.T) {
+		cfg, err := load(t, map[string]string{"TEST_NAME": "env", "TEST_INTERVAL": "1m"}, "-name", "flag", "-interval", "2", "-debug")
+		require.NoError(t, err)
+		require.Equal(t, "flag", cfg.Name)
+		require.Equal(t, 2*time.Second, cfg.Interval)
+		require.True(t, cfg.Nested.Debug)
+	})
+
+	t.Run("Aliases", func(t *testing.T) {
+		cfg, err := load(t, map[string]string{"TEST_NAME": "test", "TEST_OLD_USER": "old"})
+		require.NoError(t, err)
+		require.Equal(t, "old", cfg.User)
+
+		cfg, err = load(t, map[string]string{"TEST_NAME": "test", "TEST_OLD_USER": "old", "TEST_USER": "new"})
+		require.NoError(t, err)
+		require.Equal(t, "new", cfg.User)
+
+		path := writeFile(t, "config.yaml", "test_name: file\ntest_old_user: old\n")
+		cfg, err = load(t, nil, "-config", path)
+		require.NoError(t, err)
+		require.Equal(t, "old", cfg.User)
+	})
+
+	t.Run("YAML File", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", "test_name: file\ntest_port: 3308\ntest_interval: 1m30s\ntest_hosts:\n  - a\n  - b\n")
+
+		cfg, err := load(t, map[string]string{"TEST_PORT": "3309"}, "-config", path)
+		require.NoError(t, err)
+		require.Equal(t, "file", cfg.Name)
+		require.Equal(t, 3309, cfg.Port)
+		require.Equal(t, 90*time.Second, cfg.Interval)
+		require.Equal(t, []string{"a", "b"}, cfg.Nested.Hosts)
+	})
+
+	t.Run("JSON File", func(t *testing.T) {
+		path := writeFile(t, "config.json", `{"test_name": "file", "test_port": 3308, "test_debug": true}`)
+
+		cfg, err := load(t, map[string]string{"CONFIG_FILE": path})
+		require.NoError(t, err)
+		require.Equal(t, "file", cfg.Name)
+		require.Equal(t, 3308, cfg.Port)
+		require.True(t, cfg.Nested.Debug)
+	})
+
+	t.Run("Unknown File Key", func(t *testing.T) {
+		path := writeFile(t, "config.json", `{"test_name": "file", "test_prot": 3308}`)
+
+		_, err := load(t, nil, "-config", path)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `unknown config file key "test_prot"`)
+	})
+
+	t.Run("Aggregated Errors", func(t *testing.T) {
+		_, err := load(t, map[string]string{
+			"TEST_PORT":     "abc",
+			"TEST_INTERVAL": "soon",
+			"TEST_DEBUG":    "maybe",
+			"TEST_HOSTS":    "a,b,c",
+		})
+		require.Error(t, err)
+
+		errs, ok := err.(Errors)
+		require.True(t, ok)
+		require.Len(t, errs, 4)
+		require.Contains(t, err.Error(), `TEST_PORT: invalid integer "abc"`)
+		require.Contains(t, err.Error(), `TEST_INTERVAL: invalid duration "soon"`)
+		require.Contains(t, err.Error(), `TEST_DEBUG: invalid bool "maybe"`)
+		require.Contains(t, err.Error(), "-name/TEST_NAME is required")
+		require.NotContains(t, err.Error(), "too many hosts")
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		_, err := load(t, map[string]string{"TEST_PORT": "abc"}, "-hosts", "a,b,c")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `TEST_PORT: invalid integer "abc"`)
+		require.Contains(t, err.Error(), "-name/TEST_NAME is required")
+		require.Contains(t, err.Error(), "too many hosts")
+	})
+}
@@ -23,3 +23,21 @@ func MustGetIntEnv(k string) int {
 
 	return v
 }
+
+// GetIntEnv returns the integer value of k, or def if k is not set.
+func GetIntEnv(k string, def int) int {
+	if _, ok := os.LookupEnv(k); !ok {
+		return def
+	}
+
+	return MustGetIntEnv(k)
+}
+
+// GetEnv returns the value of k, or def if k is not set.
+func GetEnv(k string, def string) string {
+	if v, ok := os.LookupEnv(k); ok {
+		return v
+	}
+
+	return def
+}